@@ -23,7 +23,7 @@ func main() {
 	}
 
 	// Create client and logger with ctx
-	client := larklogger.NewClient(webhookURL)
+	client := larklogger.NewClient(webhookURL, larklogger.WithSecret(larklogger.GetWebhookSecret()))
 	logger := larklogger.NewLogger(ctx, client,
 		larklogger.WithEnv("production"),
 		larklogger.WithTitle("System Monitor"),
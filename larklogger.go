@@ -7,6 +7,9 @@ package larklogger
 // Re-export all public types and functions from internal package
 import (
 	"context"
+	"io"
+	"log/slog"
+	"net/http"
 	"time"
 
 	"github.com/KCNyu/lark-logger/src/larklogger"
@@ -15,18 +18,216 @@ import (
 // Logger interface defines the logging methods
 type Logger = larklogger.Logger
 
+// LarkLogger is the Logger returned by NewLogger and NewAppLogger
+type LarkLogger = larklogger.LarkLogger
+
+// SlogHandler is a slog.Handler that sends records to Lark
+type SlogHandler = larklogger.SlogHandler
+
+// SlogOptions configures a SlogHandler
+type SlogOptions = larklogger.SlogOptions
+
 // LogLevel represents the log level
 type LogLevel = larklogger.LogLevel
 
+// Sender delivers log cards; Client and AppClient.To(receiver) implement it
+type Sender = larklogger.Sender
+
+// CardUpdater is implemented by senders that can update the cards they sent
+type CardUpdater = larklogger.CardUpdater
+
+// AppTarget is an AppClient bound to one receiver
+type AppTarget = larklogger.AppTarget
+
 // Client represents the Lark webhook client
 type Client = larklogger.LarkClient
 
+// AppClient sends messages as an app bot through the Lark Open API
+type AppClient = larklogger.AppClient
+
+// Receiver identifies the chat or user an AppClient message is sent to
+type Receiver = larklogger.Receiver
+
+// ReceiveIDType tells the Open API how to interpret a receive_id
+type ReceiveIDType = larklogger.ReceiveIDType
+
+// Receive ID types
+const (
+	ReceiveIDChatID = larklogger.ReceiveIDChatID
+	ReceiveIDOpenID = larklogger.ReceiveIDOpenID
+	ReceiveIDUserID = larklogger.ReceiveIDUserID
+	ReceiveIDEmail  = larklogger.ReceiveIDEmail
+)
+
+// Open API base URLs
+const (
+	FeishuOpenAPIBaseURL = larklogger.FeishuOpenAPIBaseURL
+	LarkOpenAPIBaseURL   = larklogger.LarkOpenAPIBaseURL
+)
+
+// MessageRef identifies a sent message so it can be updated later
+type MessageRef = larklogger.MessageRef
+
+// ErrNoMessageID is returned when updating a message whose message_id is unknown
+var ErrNoMessageID = larklogger.ErrNoMessageID
+
+// ErrCardTooLarge is returned by card updates that stay over the payload limit even after trimming
+var ErrCardTooLarge = larklogger.ErrCardTooLarge
+
 // Card represents a Lark interactive card
 type Card = larklogger.Card
 
 // CardBuilder helps build Lark cards
 type CardBuilder = larklogger.CardBuilder
 
+// InboundOptions holds the credentials that authenticate callbacks and events from Lark
+type InboundOptions = larklogger.InboundOptions
+
+// CallbackHandler is an http.Handler for card action callbacks
+type CallbackHandler = larklogger.CallbackHandler
+
+// CallbackFunc handles a card action
+type CallbackFunc = larklogger.CallbackFunc
+
+// CardAction is a click on a callback button
+type CardAction = larklogger.CardAction
+
+// CallbackResponse answers a card action with a toast and/or a replacement card
+type CallbackResponse = larklogger.CallbackResponse
+
+// Toast is a short message shown to the user who clicked
+type Toast = larklogger.Toast
+
+// Toast types
+const (
+	ToastInfo    = larklogger.ToastInfo
+	ToastSuccess = larklogger.ToastSuccess
+	ToastWarning = larklogger.ToastWarning
+	ToastError   = larklogger.ToastError
+)
+
+// EventReceiver is an http.Handler for event subscriptions
+type EventReceiver = larklogger.EventReceiver
+
+// EventOption configures an EventReceiver
+type EventOption = larklogger.EventOption
+
+// Event is a v2 event as received
+type Event = larklogger.Event
+
+// EventFunc handles a raw event
+type EventFunc = larklogger.EventFunc
+
+// EventHeader is the header of a v2 event
+type EventHeader = larklogger.EventHeader
+
+// MessageReceiveEvent is a message sent to the bot
+type MessageReceiveEvent = larklogger.MessageReceiveEvent
+
+// BotMembershipEvent is the bot being added to or removed from a chat
+type BotMembershipEvent = larklogger.BotMembershipEvent
+
+// Event types with typed handlers
+const (
+	EventTypeMessageReceive = larklogger.EventTypeMessageReceive
+	EventTypeBotAdded       = larklogger.EventTypeBotAdded
+	EventTypeBotDeleted     = larklogger.EventTypeBotDeleted
+)
+
+// Errors returned for unauthenticated callbacks and events
+var (
+	ErrInvalidSignature = larklogger.ErrInvalidSignature
+	ErrInvalidToken     = larklogger.ErrInvalidToken
+	ErrStaleRequest     = larklogger.ErrStaleRequest
+)
+
+// TemplateData references a published Card Builder template and its variables
+type TemplateData = larklogger.TemplateData
+
+// LogTemplate renders log cards from a Card Builder template
+type LogTemplate = larklogger.LogTemplate
+
+// LogEntry is a log message as rendered into template variables
+type LogEntry = larklogger.LogEntry
+
+// CardTypeTemplate is the card body type of template cards
+const CardTypeTemplate = larklogger.CardTypeTemplate
+
+// Instrumentation receives delivery events from a client
+type Instrumentation = larklogger.Instrumentation
+
+// SendEvent describes one delivery attempt
+type SendEvent = larklogger.SendEvent
+
+// SendObserver is optionally implemented by an Instrumentation to observe whole sends
+type SendObserver = larklogger.SendObserver
+
+// TraceExtractor returns the IDs of the span active in a context
+type TraceExtractor = larklogger.TraceExtractor
+
+// ErrorClass is a coarse classification of a failed send
+type ErrorClass = larklogger.ErrorClass
+
+// Error classes
+const (
+	ErrorClassNone        = larklogger.ErrorClassNone
+	ErrorClassCanceled    = larklogger.ErrorClassCanceled
+	ErrorClassRateLimited = larklogger.ErrorClassRateLimited
+	ErrorClassCircuitOpen = larklogger.ErrorClassCircuitOpen
+	ErrorClassTransport   = larklogger.ErrorClassTransport
+	ErrorClassServer      = larklogger.ErrorClassServer
+	ErrorClassRejected    = larklogger.ErrorClassRejected
+	ErrorClassOther       = larklogger.ErrorClassOther
+)
+
+// TrimReport describes how an oversized card was reduced to fit the payload limit
+type TrimReport = larklogger.TrimReport
+
+// DefaultMaxPayloadBytes approximates Lark's card payload limit
+const DefaultMaxPayloadBytes = larklogger.DefaultMaxPayloadBytes
+
+// Recorder captures messages in memory instead of sending them (test mode)
+type Recorder = larklogger.Recorder
+
+// RecorderOption configures a Recorder
+type RecorderOption = larklogger.RecorderOption
+
+// TestWebhookURL is the placeholder webhook GetConfig falls back to; clients never dial it
+const TestWebhookURL = larklogger.TestWebhookURL
+
+// Post represents a Lark rich-text post message
+type Post = larklogger.Post
+
+// PostBuilder helps build rich-text posts
+type PostBuilder = larklogger.PostBuilder
+
+// PostElement is one inline element of a post paragraph
+type PostElement = larklogger.PostElement
+
+// Post languages
+const (
+	LangZhCN = larklogger.LangZhCN
+	LangEnUS = larklogger.LangEnUS
+	LangJaJP = larklogger.LangJaJP
+)
+
+// Post text styles
+const (
+	PostStyleBold          = larklogger.PostStyleBold
+	PostStyleItalic        = larklogger.PostStyleItalic
+	PostStyleUnderline     = larklogger.PostStyleUnderline
+	PostStyleStrikethrough = larklogger.PostStyleStrikethrough
+)
+
+// Mention @mentions a user or everyone in a chat
+type Mention = larklogger.Mention
+
+// MentionKind tells how a Mention identifies its target
+type MentionKind = larklogger.MentionKind
+
+// MentionRule mentions users on messages of the given levels
+type MentionRule = larklogger.MentionRule
+
 // KVItem represents a prioritized key-value item
 type KVItem = larklogger.KVItem
 
@@ -36,9 +237,117 @@ type Button = larklogger.Button
 // Client options
 type ClientOption = larklogger.ClientOption
 
+// RetryPolicy decides whether and when failed sends are retried
+type RetryPolicy = larklogger.RetryPolicy
+
+// ExponentialBackoff is the default jittered exponential retry policy
+type ExponentialBackoff = larklogger.ExponentialBackoff
+
 // Logger options
 type LoggerOption = larklogger.LoggerOption
 
+// RateLimitPolicy controls blocking vs fail-fast behaviour of the client-side limiter
+type RateLimitPolicy = larklogger.RateLimitPolicy
+
+// RateLimitStats summarizes client-side limiter activity
+type RateLimitStats = larklogger.RateLimitStats
+
+// Rate limit policies
+const (
+	RateLimitBlock    = larklogger.RateLimitBlock
+	RateLimitFailFast = larklogger.RateLimitFailFast
+)
+
+// ErrRateLimitExceeded is returned when a fail-fast limiter has no token
+var ErrRateLimitExceeded = larklogger.ErrRateLimitExceeded
+
+// SpoolOptions configures the durable on-disk outbox
+type SpoolOptions = larklogger.SpoolOptions
+
+// SpoolStats summarizes spool backlog and activity
+type SpoolStats = larklogger.SpoolStats
+
+// ErrSpooled is returned when a message was written to the spool instead of sent
+var ErrSpooled = larklogger.ErrSpooled
+
+// ErrSpoolClosed is returned when spooling or replaying after the client was closed
+var ErrSpoolClosed = larklogger.ErrSpoolClosed
+
+// EndpointStatus is a snapshot of one webhook endpoint's health
+type EndpointStatus = larklogger.EndpointStatus
+
+// CircuitState is the state of the circuit breaker
+type CircuitState = larklogger.CircuitState
+
+// CircuitBreakerOptions configures the circuit breaker
+type CircuitBreakerOptions = larklogger.CircuitBreakerOptions
+
+// CircuitOpenError is returned when a send is short-circuited
+type CircuitOpenError = larklogger.CircuitOpenError
+
+// FallbackSink receives payloads diverted while the breaker is open
+type FallbackSink = larklogger.FallbackSink
+
+// FallbackFunc adapts a function to FallbackSink
+type FallbackFunc = larklogger.FallbackFunc
+
+// Circuit states
+const (
+	CircuitClosed   = larklogger.CircuitClosed
+	CircuitOpen     = larklogger.CircuitOpen
+	CircuitHalfOpen = larklogger.CircuitHalfOpen
+)
+
+// ErrCircuitOpen matches every CircuitOpenError via errors.Is
+var ErrCircuitOpen = larklogger.ErrCircuitOpen
+
+// APIError is returned when Lark rejects a message
+type APIError = larklogger.APIError
+
+// TransportError is returned when a request could not be completed
+type TransportError = larklogger.TransportError
+
+// RetryExhaustedError wraps the error of every failed attempt
+type RetryExhaustedError = larklogger.RetryExhaustedError
+
+// Lark API codes
+const (
+	CodeFrequencyLimited = larklogger.CodeFrequencyLimited
+	CodeSignMismatch     = larklogger.CodeSignMismatch
+	CodeBadRequest       = larklogger.CodeBadRequest
+	CodeTokenInvalid     = larklogger.CodeTokenInvalid
+	CodeTokenExpired     = larklogger.CodeTokenExpired
+	CodeTokenMissing     = larklogger.CodeTokenMissing
+)
+
+// OverflowPolicy controls what an async logger does when its queue is full
+type OverflowPolicy = larklogger.OverflowPolicy
+
+// AsyncStats summarizes async delivery activity
+type AsyncStats = larklogger.AsyncStats
+
+// Flusher is implemented by loggers that deliver messages asynchronously
+type Flusher = larklogger.Flusher
+
+// Overflow policies
+const (
+	OverflowDropNewest = larklogger.OverflowDropNewest
+	OverflowDropOldest = larklogger.OverflowDropOldest
+	OverflowBlock      = larklogger.OverflowBlock
+)
+
+// Router fans log messages out to named clients according to rules
+type Router = larklogger.Router
+
+// RouteRule sends matching messages to one or more named clients
+type RouteRule = larklogger.RouteRule
+
+// RouteMatch selects messages for a route
+type RouteMatch = larklogger.RouteMatch
+
+// RouterOption configures a Router
+type RouterOption = larklogger.RouterOption
+
 // Log levels
 const (
 	LevelInfo  = larklogger.LevelInfo
@@ -58,16 +367,138 @@ func NewClient(webhookURL string, opts ...ClientOption) *Client {
 	return larklogger.NewLarkClient(webhookURL, opts...)
 }
 
-// NewLogger creates a new Logger instance (context required)
-func NewLogger(ctx context.Context, client *Client, opts ...LoggerOption) Logger {
+// NewAppClient creates an Open API client authenticating with app_id and app_secret
+func NewAppClient(appID, appSecret string, opts ...ClientOption) *AppClient {
+	return larklogger.NewAppClient(appID, appSecret, opts...)
+}
+
+// NewAppLogger creates a Logger that sends through the Open API to one chat or user
+func NewAppLogger(ctx context.Context, app *AppClient, to Receiver, opts ...LoggerOption) Logger {
+	return larklogger.NewAppLogger(ctx, app, to, opts...)
+}
+
+// Receivers
+func ToChat(chatID string) Receiver {
+	return larklogger.ToChat(chatID)
+}
+
+func ToOpenID(openID string) Receiver {
+	return larklogger.ToOpenID(openID)
+}
+
+func ToUserID(userID string) Receiver {
+	return larklogger.ToUserID(userID)
+}
+
+func ToEmail(email string) Receiver {
+	return larklogger.ToEmail(email)
+}
+
+// NewLogger creates a new Logger instance (context required); client is usually a *Client
+func NewLogger(ctx context.Context, client Sender, opts ...LoggerOption) Logger {
 	return larklogger.NewLarkLogger(ctx, client, opts...)
 }
 
+// NewSlogHandler creates a slog.Handler logging through logger
+func NewSlogHandler(logger *LarkLogger, opts *SlogOptions) *SlogHandler {
+	return larklogger.NewSlogHandler(logger, opts)
+}
+
+// SlogLevel maps a slog level onto a LogLevel
+func SlogLevel(level slog.Level) LogLevel {
+	return larklogger.SlogLevel(level)
+}
+
+// Flush waits until an async logger has delivered every queued message
+func Flush(ctx context.Context, logger Logger) error {
+	if f, ok := logger.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Close drains and stops an async logger
+func Close(ctx context.Context, logger Logger) error {
+	if f, ok := logger.(Flusher); ok {
+		return f.Close(ctx)
+	}
+	return nil
+}
+
+// NewRouter creates a Router over named senders, e.g. clients or AppClient targets
+func NewRouter(ctx context.Context, clients map[string]Sender, opts ...RouterOption) (*Router, error) {
+	return larklogger.NewRouter(ctx, clients, opts...)
+}
+
+// Router options
+func WithRoute(rule RouteRule) RouterOption {
+	return larklogger.WithRoute(rule)
+}
+
+func WithDefaultRoute(clients []string, opts ...LoggerOption) RouterOption {
+	return larklogger.WithDefaultRoute(clients, opts...)
+}
+
+func WithRouterLoggerOptions(opts ...LoggerOption) RouterOption {
+	return larklogger.WithRouterLoggerOptions(opts...)
+}
+
 // NewCardBuilder creates a new card builder
 func NewCardBuilder() *CardBuilder {
 	return larklogger.NewCardBuilder()
 }
 
+// NewPostBuilder creates a new rich-text post builder
+func NewPostBuilder() *PostBuilder {
+	return larklogger.NewPostBuilder()
+}
+
+// Post elements
+func PostText(text string, styles ...string) PostElement {
+	return larklogger.PostText(text, styles...)
+}
+
+func PostLink(text, href string) PostElement {
+	return larklogger.PostLink(text, href)
+}
+
+func PostAt(userID string) PostElement {
+	return larklogger.PostAt(userID)
+}
+
+func PostAtAll() PostElement {
+	return larklogger.PostAtAll()
+}
+
+func PostImage(imageKey string) PostElement {
+	return larklogger.PostImage(imageKey)
+}
+
+func PostEmotion(emojiType string) PostElement {
+	return larklogger.PostEmotion(emojiType)
+}
+
+func PostCodeBlock(language, code string) PostElement {
+	return larklogger.PostCodeBlock(language, code)
+}
+
+// Mentions
+func MentionOpenID(openID string) Mention {
+	return larklogger.MentionOpenID(openID)
+}
+
+func MentionUserID(userID string) Mention {
+	return larklogger.MentionUserID(userID)
+}
+
+func MentionEmail(email string) Mention {
+	return larklogger.MentionEmail(email)
+}
+
+func MentionAll() Mention {
+	return larklogger.MentionAll()
+}
+
 // NewCardField creates a new card field
 func NewCardField(isShort bool, content string) *larklogger.CardField {
 	return larklogger.NewCardField(isShort, content)
@@ -78,6 +509,21 @@ func FormatTimestamp(t time.Time) string {
 	return larklogger.FormatTimestamp(t)
 }
 
+// IsRateLimited reports whether err signals Lark frequency limiting
+func IsRateLimited(err error) bool {
+	return larklogger.IsRateLimited(err)
+}
+
+// ClassifyError returns the ErrorClass of a send error
+func ClassifyError(err error) ErrorClass {
+	return larklogger.ClassifyError(err)
+}
+
+// IsRetryable reports whether err is transient
+func IsRetryable(err error) bool {
+	return larklogger.IsRetryable(err)
+}
+
 // Client options
 func WithTimeout(timeout time.Duration) ClientOption {
 	return larklogger.WithTimeout(timeout)
@@ -87,6 +533,103 @@ func WithRetry(count int, delay time.Duration) ClientOption {
 	return larklogger.WithRetry(count, delay)
 }
 
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return larklogger.WithRetryPolicy(policy)
+}
+
+// NewExponentialBackoff creates an exponential backoff retry policy
+func NewExponentialBackoff(maxRetries int, initialDelay time.Duration) *ExponentialBackoff {
+	return larklogger.NewExponentialBackoff(maxRetries, initialDelay)
+}
+
+func WithRateLimit(perSecond, perMinute int) ClientOption {
+	return larklogger.WithRateLimit(perSecond, perMinute)
+}
+
+func WithRateLimitPolicy(policy RateLimitPolicy) ClientOption {
+	return larklogger.WithRateLimitPolicy(policy)
+}
+
+func WithRateLimitObserver(observer func(wait time.Duration)) ClientOption {
+	return larklogger.WithRateLimitObserver(observer)
+}
+
+func WithSpool(opts SpoolOptions) ClientOption {
+	return larklogger.WithSpool(opts)
+}
+
+func WithFailoverURLs(urls ...string) ClientOption {
+	return larklogger.WithFailoverURLs(urls...)
+}
+
+func WithEndpointRetryInterval(interval time.Duration) ClientOption {
+	return larklogger.WithEndpointRetryInterval(interval)
+}
+
+func WithEndpointDeadHandler(handler func(url string, err error)) ClientOption {
+	return larklogger.WithEndpointDeadHandler(handler)
+}
+
+func WithCircuitBreaker(opts CircuitBreakerOptions) ClientOption {
+	return larklogger.WithCircuitBreaker(opts)
+}
+
+// NewWriterSink returns a FallbackSink writing one JSON payload per line to w
+func NewWriterSink(w io.Writer) FallbackSink {
+	return larklogger.NewWriterSink(w)
+}
+
+func WithInstrumentation(instrumentation Instrumentation) ClientOption {
+	return larklogger.WithInstrumentation(instrumentation)
+}
+
+// MultiInstrumentation combines instrumentations, e.g. larkprom metrics and larkotel tracing
+func MultiInstrumentation(instrumentations ...Instrumentation) Instrumentation {
+	return larklogger.MultiInstrumentation(instrumentations...)
+}
+
+func WithMaxPayloadBytes(maxBytes int) ClientOption {
+	return larklogger.WithMaxPayloadBytes(maxBytes)
+}
+
+func WithTrimObserver(observer func(TrimReport)) ClientOption {
+	return larklogger.WithTrimObserver(observer)
+}
+
+func WithHTTPClient(client *http.Client) ClientOption {
+	return larklogger.WithHTTPClient(client)
+}
+
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return larklogger.WithTransport(transport)
+}
+
+func WithProxy(proxyURL string) ClientOption {
+	return larklogger.WithProxy(proxyURL)
+}
+
+func WithRecorder(r *Recorder) ClientOption {
+	return larklogger.WithRecorder(r)
+}
+
+func WithTestMode(enabled bool) ClientOption {
+	return larklogger.WithTestMode(enabled)
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder(opts ...RecorderOption) *Recorder {
+	return larklogger.NewRecorder(opts...)
+}
+
+func WithRecorderLimit(limit int) RecorderOption {
+	return larklogger.WithRecorderLimit(limit)
+}
+
+// DefaultRecorder returns the recorder shared by clients in test mode
+func DefaultRecorder() *Recorder {
+	return larklogger.DefaultRecorder()
+}
+
 func WithUserAgent(userAgent string) ClientOption {
 	return larklogger.WithUserAgent(userAgent)
 }
@@ -95,6 +638,14 @@ func WithHeaders(headers map[string]string) ClientOption {
 	return larklogger.WithHeaders(headers)
 }
 
+func WithSecret(secret string) ClientOption {
+	return larklogger.WithSecret(secret)
+}
+
+func WithBaseURL(baseURL string) ClientOption {
+	return larklogger.WithBaseURL(baseURL)
+}
+
 // Logger options
 func WithService(service string) LoggerOption {
 	return larklogger.WithService(service)
@@ -120,11 +671,90 @@ func WithButtons(buttons []Button) LoggerOption {
 	return larklogger.WithButtons(buttons)
 }
 
+func WithMentions(mentions ...Mention) LoggerOption {
+	return larklogger.WithMentions(mentions...)
+}
+
+func WithLevelMentions(level LogLevel, mentions ...Mention) LoggerOption {
+	return larklogger.WithLevelMentions(level, mentions...)
+}
+
+func WithResolveMentions() LoggerOption {
+	return larklogger.WithResolveMentions()
+}
+
+func WithTraceExtractor(extractor TraceExtractor) LoggerOption {
+	return larklogger.WithTraceExtractor(extractor)
+}
+
+func WithTraceURL(template string) LoggerOption {
+	return larklogger.WithTraceURL(template)
+}
+
+func WithTemplate(template LogTemplate) LoggerOption {
+	return larklogger.WithTemplate(template)
+}
+
+// GetInboundOptions returns the callback/event credentials from environment
+func GetInboundOptions() InboundOptions {
+	return larklogger.GetInboundOptions()
+}
+
+// NewCallbackHandler creates an http.Handler for card action callbacks
+func NewCallbackHandler(opts InboundOptions) *CallbackHandler {
+	return larklogger.NewCallbackHandler(opts)
+}
+
+// CallbackButton creates a button that calls back into a CallbackHandler
+func CallbackButton(text, key string, data map[string]interface{}) Button {
+	return larklogger.CallbackButton(text, key, data)
+}
+
+// ToastResponse answers a card action with a toast only
+func ToastResponse(toastType, content string) *CallbackResponse {
+	return larklogger.ToastResponse(toastType, content)
+}
+
+// CardResponse replaces the clicked card
+func CardResponse(card *Card) *CallbackResponse {
+	return larklogger.CardResponse(card)
+}
+
+// NewEventReceiver creates an http.Handler for event subscriptions
+func NewEventReceiver(opts InboundOptions, options ...EventOption) *EventReceiver {
+	return larklogger.NewEventReceiver(opts, options...)
+}
+
+func WithEventDedupe(ttl time.Duration, capacity int) EventOption {
+	return larklogger.WithEventDedupe(ttl, capacity)
+}
+
+// NewTemplateCard creates a card rendered from a Card Builder template
+func NewTemplateCard(templateID, versionName string, variables map[string]interface{}) *Card {
+	return larklogger.NewTemplateCard(templateID, versionName, variables)
+}
+
+func WithAsync(queueSize, workers int) LoggerOption {
+	return larklogger.WithAsync(queueSize, workers)
+}
+
+func WithOverflowPolicy(policy OverflowPolicy) LoggerOption {
+	return larklogger.WithOverflowPolicy(policy)
+}
+
 // Environment configuration functions
 func GetWebhookURL() string {
 	return larklogger.GetWebhookURL()
 }
 
+func GetWebhookSecret() string {
+	return larklogger.GetWebhookSecret()
+}
+
+func GetAppCredentials() (appID, appSecret string) {
+	return larklogger.GetAppCredentials()
+}
+
 func IsTestEnvironment() bool {
 	return larklogger.IsTestEnvironment()
 }
@@ -14,7 +14,7 @@ fi
 
 # 运行测试并生成覆盖率报告
 echo "🧪 运行测试..."
-go test -v -race -coverprofile=coverage.out -covermode=atomic ./src/larklogger/...
+go test -v -race -coverprofile=coverage.out -covermode=atomic ./src/...
 
 if [ $? -ne 0 ]; then
     echo "❌ 测试失败"
@@ -0,0 +1,110 @@
+package fakelark
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const cardTypeTemplate = "template"
+
+// parseContent validates the content of a message of msg.MsgType and stores it in msg.
+// Card content is the card object, post content the map of languages.
+func parseContent(msg *Message, content json.RawMessage) error {
+	if len(content) == 0 {
+		return errors.New("content is required")
+	}
+
+	switch msg.MsgType {
+	case "text":
+		var text struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(content, &text); err != nil || text.Text == "" {
+			return errors.New("text content requires a non-empty text")
+		}
+		msg.Text = text.Text
+	case "post":
+		post, err := parsePost(content)
+		if err != nil {
+			return err
+		}
+		msg.Post = post
+	case "interactive":
+		card, err := parseCard(content)
+		if err != nil {
+			return err
+		}
+		msg.Card = card
+	case "":
+		return errors.New("msg_type is required")
+	default:
+		return fmt.Errorf("unsupported msg_type %q", msg.MsgType)
+	}
+	return nil
+}
+
+// parseCard validates a card object: every element needs a tag, a header needs a title,
+// and a template card needs a template_id
+func parseCard(content json.RawMessage) (json.RawMessage, error) {
+	var raw struct {
+		Type string `json:"type"`
+		Data *struct {
+			TemplateID string `json:"template_id"`
+		} `json:"data"`
+		Header *struct {
+			Title *struct {
+				Content string `json:"content"`
+			} `json:"title"`
+		} `json:"header"`
+		Elements []map[string]interface{} `json:"elements"`
+	}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("card is not a valid card object: %v", err)
+	}
+	if raw.Type == cardTypeTemplate {
+		if raw.Data == nil || raw.Data.TemplateID == "" {
+			return nil, errors.New("template card requires data.template_id")
+		}
+	} else if raw.Header == nil && len(raw.Elements) == 0 {
+		return nil, errors.New("card requires a header or elements")
+	}
+	if raw.Header != nil && (raw.Header.Title == nil || raw.Header.Title.Content == "") {
+		return nil, errors.New("card header requires a title")
+	}
+	for i, el := range raw.Elements {
+		if tag, _ := el["tag"].(string); tag == "" {
+			return nil, fmt.Errorf("card element %d has no tag", i)
+		}
+	}
+	return content, nil
+}
+
+// parsePost validates post content: at least one language, every element with a tag
+func parsePost(content json.RawMessage) (json.RawMessage, error) {
+	var post map[string]*struct {
+		Title   string `json:"title"`
+		Content [][]struct {
+			Tag string `json:"tag"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(content, &post); err != nil {
+		return nil, fmt.Errorf("post is not a map of languages: %v", err)
+	}
+	if len(post) == 0 {
+		return nil, errors.New("post requires at least one language")
+	}
+	for lang, body := range post {
+		if body == nil || (body.Title == "" && len(body.Content) == 0) {
+			return nil, fmt.Errorf("post %s is empty", lang)
+		}
+		for i, paragraph := range body.Content {
+			for _, el := range paragraph {
+				if el.Tag == "" {
+					return nil, fmt.Errorf("post %s paragraph %d has an element without tag", lang, i)
+				}
+			}
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,502 @@
+// Package fakelark is a fake Lark webhook and Open API server for tests.
+//
+// The server validates payload schema and webhook signatures the way Lark does, records every
+// accepted message, and can be scripted to answer with rate limits, 5xx, slow or malformed responses.
+// It does not import larklogger, so larklogger's own tests can use it; larktest wraps it with
+// typed cards and assertions for users of the library.
+package fakelark
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	webhookPath  = "/open-apis/bot/v2/hook/"
+	tokenPath    = "/open-apis/auth/v3/tenant_access_token/internal"
+	messagesPath = "/open-apis/im/v1/messages"
+
+	// WebhookToken is the token in the URL returned by Server.WebhookURL
+	WebhookToken = "test-token"
+
+	// Default app credentials accepted by the token endpoint
+	AppID     = "cli_test"
+	AppSecret = "test-secret"
+)
+
+// Lark codes the server answers with, mirroring larklogger's constants
+const (
+	codeBadRequest       = 9499
+	codeFrequencyLimited = 11232
+	codeSignMismatch     = 19021
+	codeTokenInvalid     = 99991663
+)
+
+// Message is a message accepted by the server
+type Message struct {
+	Path    string
+	MsgType string
+	Card    json.RawMessage // Card object of interactive messages and card updates
+	Text    string          // Text messages
+	Post    json.RawMessage // Post content of post messages, a map of languages
+	Signed  bool            // The webhook payload carried a valid signature
+	Raw     []byte          // Request body
+	Header  http.Header     // Request headers
+
+	// Open API only
+	ReceiveIDType string
+	ReceiveID     string
+	MessageID     string // Assigned message_id, or the message a card update targets
+	Update        bool   // A PATCH of an existing card
+}
+
+// Rejection is a request the server refused because of its schema, signature or credentials
+type Rejection struct {
+	Path   string
+	Reason string
+	Raw    []byte
+}
+
+// Response scripts the answer to one message request
+type Response struct {
+	Delay  time.Duration // Wait before answering; with no other field set the message is then accepted
+	Status int           // HTTP status (default 200)
+	Code   int           // Lark code of the reply; non-zero rejects the message
+	Msg    string
+	Body   string // Raw body sent as-is, e.g. malformed JSON
+}
+
+// OK accepts the message normally
+func OK() Response {
+	return Response{}
+}
+
+// Slow accepts the message after delay
+func Slow(delay time.Duration) Response {
+	return Response{Delay: delay}
+}
+
+// RateLimited answers with Lark's frequency limit code
+func RateLimited() Response {
+	return Response{Code: codeFrequencyLimited, Msg: "too many request"}
+}
+
+// ServerError answers with the given 5xx status
+func ServerError(status int) Response {
+	return Response{Status: status, Body: http.StatusText(status)}
+}
+
+// Malformed answers 200 with a body that is not JSON
+func Malformed() Response {
+	return Response{Body: "<html>502 Bad Gateway</html>"}
+}
+
+// Reject answers with a Lark error code
+func Reject(code int, msg string) Response {
+	return Response{Code: code, Msg: msg}
+}
+
+// accepts reports whether the response lets the message through after its delay
+func (r Response) accepts() bool {
+	return (r.Status == 0 || r.Status == http.StatusOK) && r.Code == 0 && r.Body == ""
+}
+
+// Option configures the server
+type Option func(*Server)
+
+// WithSecret requires webhook payloads to be signed with secret
+func WithSecret(secret string) Option {
+	return func(s *Server) {
+		s.secret = secret
+	}
+}
+
+// WithApp sets the app credentials accepted by the token endpoint (default AppID/AppSecret)
+func WithApp(appID, appSecret string) Option {
+	return func(s *Server) {
+		s.appID = appID
+		s.appSecret = appSecret
+	}
+}
+
+// WithTokenExpiry sets the lifetime reported for issued tenant_access_tokens (default 2h)
+func WithTokenExpiry(expiry time.Duration) Option {
+	return func(s *Server) {
+		s.tokenExpiry = expiry
+	}
+}
+
+// Server is a fake Lark webhook and Open API server
+type Server struct {
+	URL string
+
+	server      *httptest.Server
+	secret      string
+	appID       string
+	appSecret   string
+	tokenExpiry time.Duration
+
+	mu         sync.Mutex
+	script     []Response
+	requests   int
+	messages   []Message
+	rejections []Rejection
+	tokens     map[string]bool
+	tokenSeq   int
+	messageIDs map[string]bool
+}
+
+// NewServer starts a fake server that is closed when the test ends
+func NewServer(t testing.TB, opts ...Option) *Server {
+	s := &Server{
+		appID:       AppID,
+		appSecret:   AppSecret,
+		tokenExpiry: 2 * time.Hour,
+		tokens:      make(map[string]bool),
+		messageIDs:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(webhookPath, s.handleWebhook)
+	mux.HandleFunc(tokenPath, s.handleToken)
+	mux.HandleFunc(messagesPath, s.handleSend)
+	mux.HandleFunc(messagesPath+"/", s.handleUpdate)
+
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// WebhookURL returns the custom bot webhook URL served by the server
+func (s *Server) WebhookURL() string {
+	return s.URL + webhookPath + WebhookToken
+}
+
+// BaseURL returns the Open API base URL, for larklogger.WithBaseURL
+func (s *Server) BaseURL() string {
+	return s.URL + "/open-apis"
+}
+
+// Script queues responses for the next message requests, in order; once they are used up
+// messages are accepted normally
+func (s *Server) Script(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = append(s.script, responses...)
+}
+
+// Requests returns how many message requests were received, including rejected and scripted ones
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Messages returns the accepted messages, in order
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message{}, s.messages...)
+}
+
+// Rejections returns the requests refused for their schema, signature or credentials
+func (s *Server) Rejections() []Rejection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Rejection{}, s.rejections...)
+}
+
+// RevokeTokens invalidates every tenant_access_token issued so far, as when Lark expires one early
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]bool)
+}
+
+// Reset forgets recorded messages, rejections and any remaining script
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = nil
+	s.requests = 0
+	s.messages = nil
+	s.rejections = nil
+}
+
+// nextResponse counts a message request and pops its scripted response, if any
+func (s *Server) nextResponse() Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if len(s.script) == 0 {
+		return OK()
+	}
+	resp := s.script[0]
+	s.script = s.script[1:]
+	return resp
+}
+
+// scripted applies the scripted response; it reports whether the request was fully answered
+func (s *Server) scripted(w http.ResponseWriter, r *http.Request) bool {
+	resp := s.nextResponse()
+	if resp.Delay > 0 {
+		select {
+		case <-time.After(resp.Delay):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	if resp.accepts() {
+		return false
+	}
+
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if resp.Body != "" {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, resp.Body)
+		return true
+	}
+	writeJSON(w, status, map[string]interface{}{"code": resp.Code, "msg": resp.Msg})
+	return true
+}
+
+// reject records a refused request and answers it with a Lark error
+func (s *Server) reject(w http.ResponseWriter, r *http.Request, body []byte, status, code int, reason string) {
+	s.mu.Lock()
+	s.rejections = append(s.rejections, Rejection{Path: r.URL.Path, Reason: reason, Raw: body})
+	s.mu.Unlock()
+
+	writeJSON(w, status, map[string]interface{}{"code": code, "msg": reason})
+}
+
+func (s *Server) record(m Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, m)
+}
+
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if s.scripted(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.reject(w, r, body, http.StatusMethodNotAllowed, codeBadRequest, "method not allowed")
+		return
+	}
+	if strings.TrimPrefix(r.URL.Path, webhookPath) != WebhookToken {
+		s.reject(w, r, body, http.StatusNotFound, 19001, "param invalid: incoming webhook access token invalid")
+		return
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		s.reject(w, r, body, http.StatusBadRequest, codeBadRequest, "Bad Request: body is not a JSON object")
+		return
+	}
+
+	signed, err := s.verifySignature(payload)
+	if err != nil {
+		s.reject(w, r, body, http.StatusOK, codeSignMismatch, err.Error())
+		return
+	}
+
+	msg := Message{Path: r.URL.Path, Signed: signed, Raw: body, Header: r.Header.Clone()}
+	_ = json.Unmarshal(payload["msg_type"], &msg.MsgType)
+
+	content := payload["content"]
+	if msg.MsgType == "interactive" {
+		content = payload["card"]
+	} else if msg.MsgType == "post" {
+		var wrapper struct {
+			Post json.RawMessage `json:"post"`
+		}
+		_ = json.Unmarshal(content, &wrapper)
+		content = wrapper.Post
+	}
+	if err := parseContent(&msg, content); err != nil {
+		s.reject(w, r, body, http.StatusBadRequest, codeBadRequest, "Bad Request: "+err.Error())
+		return
+	}
+
+	s.record(msg)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"code": 0, "msg": "success", "data": map[string]interface{}{}})
+}
+
+// verifySignature checks the timestamp/sign pair when a secret is configured
+func (s *Server) verifySignature(payload map[string]json.RawMessage) (bool, error) {
+	if s.secret == "" {
+		return false, nil
+	}
+
+	var timestamp, sign string
+	_ = json.Unmarshal(payload["timestamp"], &timestamp)
+	_ = json.Unmarshal(payload["sign"], &sign)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || sign == "" {
+		return false, fmt.Errorf("sign match fail: missing timestamp or sign")
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > time.Hour || age < -time.Hour {
+		return false, fmt.Errorf("sign match fail or timestamp is not within one hour from current time")
+	}
+
+	h := hmac.New(sha256.New, []byte(fmt.Sprintf("%d\n%s", ts, s.secret)))
+	expected := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	if !hmac.Equal([]byte(sign), []byte(expected)) {
+		return false, fmt.Errorf("sign match fail or timestamp is not within one hour from current time")
+	}
+	return true, nil
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AppID     string `json:"app_id"`
+		AppSecret string `json:"app_secret"`
+	}
+	body, _ := io.ReadAll(r.Body)
+	if err := json.Unmarshal(body, &req); err != nil || req.AppID != s.appID || req.AppSecret != s.appSecret {
+		s.reject(w, r, body, http.StatusBadRequest, 10014, "app secret invalid")
+		return
+	}
+
+	s.mu.Lock()
+	s.tokenSeq++
+	token := fmt.Sprintf("t-test-%d", s.tokenSeq)
+	s.tokens[token] = true
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"code": 0, "msg": "ok", "tenant_access_token": token, "expire": int(s.tokenExpiry / time.Second),
+	})
+}
+
+// authorized checks the tenant_access_token, answering the request if it is invalid
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request, body []byte) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	ok := s.tokens[token]
+	s.mu.Unlock()
+
+	if !ok {
+		s.reject(w, r, body, http.StatusBadRequest, codeTokenInvalid, "Invalid access token for authorization")
+	}
+	return ok
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if s.scripted(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.reject(w, r, body, http.StatusMethodNotAllowed, codeBadRequest, "method not allowed")
+		return
+	}
+	if !s.authorized(w, r, body) {
+		return
+	}
+
+	var req struct {
+		ReceiveID string `json:"receive_id"`
+		MsgType   string `json:"msg_type"`
+		Content   string `json:"content"`
+	}
+	msg := Message{Path: r.URL.Path, Raw: body, Header: r.Header.Clone(), ReceiveIDType: r.URL.Query().Get("receive_id_type")}
+	err := json.Unmarshal(body, &req)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("body is not a JSON object")
+	case !validReceiveIDType(msg.ReceiveIDType):
+		err = fmt.Errorf("invalid receive_id_type %q", msg.ReceiveIDType)
+	case req.ReceiveID == "":
+		err = fmt.Errorf("receive_id is required")
+	default:
+		msg.ReceiveID = req.ReceiveID
+		msg.MsgType = req.MsgType
+		err = parseContent(&msg, json.RawMessage(req.Content))
+	}
+	if err != nil {
+		s.reject(w, r, body, http.StatusBadRequest, 230001, "Your request contains an invalid request parameter: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	msg.MessageID = fmt.Sprintf("om_test_%d", len(s.messageIDs)+1)
+	s.messageIDs[msg.MessageID] = true
+	s.mu.Unlock()
+
+	s.record(msg)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"code": 0, "msg": "success", "data": map[string]string{"message_id": msg.MessageID}})
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if s.scripted(w, r) {
+		return
+	}
+	if r.Method != http.MethodPatch {
+		s.reject(w, r, body, http.StatusMethodNotAllowed, codeBadRequest, "method not allowed")
+		return
+	}
+	if !s.authorized(w, r, body) {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, messagesPath+"/")
+	s.mu.Lock()
+	known := s.messageIDs[id]
+	s.mu.Unlock()
+	if !known {
+		s.reject(w, r, body, http.StatusBadRequest, 230011, "The message was not found")
+		return
+	}
+
+	var req struct {
+		Content string `json:"content"`
+	}
+	msg := Message{Path: r.URL.Path, Raw: body, Header: r.Header.Clone(), MsgType: "interactive", MessageID: id, Update: true}
+	err := json.Unmarshal(body, &req)
+	if err == nil {
+		err = parseContent(&msg, json.RawMessage(req.Content))
+	}
+	if err != nil {
+		s.reject(w, r, body, http.StatusBadRequest, 230001, "Your request contains an invalid request parameter: "+err.Error())
+		return
+	}
+
+	s.record(msg)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"code": 0, "msg": "success"})
+}
+
+func validReceiveIDType(t string) bool {
+	switch t {
+	case "chat_id", "open_id", "user_id", "union_id", "email":
+		return true
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,346 @@
+package larklogger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Open API base URLs
+const (
+	FeishuOpenAPIBaseURL = "https://open.feishu.cn/open-apis"
+	LarkOpenAPIBaseURL   = "https://open.larksuite.com/open-apis"
+)
+
+// Lark codes for a missing, malformed or expired tenant_access_token
+const (
+	CodeTokenInvalid = 99991663
+	CodeTokenExpired = 99991677
+	CodeTokenMissing = 99991661
+)
+
+// tokenRefreshMargin is how long before expiry a cached token is refreshed
+const tokenRefreshMargin = 5 * time.Minute
+
+// ReceiveIDType tells the Open API how to interpret a receive_id
+type ReceiveIDType string
+
+const (
+	ReceiveIDChatID ReceiveIDType = "chat_id"
+	ReceiveIDOpenID ReceiveIDType = "open_id"
+	ReceiveIDUserID ReceiveIDType = "user_id"
+	ReceiveIDEmail  ReceiveIDType = "email"
+)
+
+// Receiver identifies the chat or user a message is sent to
+type Receiver struct {
+	Type ReceiveIDType
+	ID   string
+}
+
+// ToChat addresses a group chat by chat_id
+func ToChat(chatID string) Receiver {
+	return Receiver{Type: ReceiveIDChatID, ID: chatID}
+}
+
+// ToOpenID addresses a user by open_id
+func ToOpenID(openID string) Receiver {
+	return Receiver{Type: ReceiveIDOpenID, ID: openID}
+}
+
+// ToUserID addresses a user by user_id
+func ToUserID(userID string) Receiver {
+	return Receiver{Type: ReceiveIDUserID, ID: userID}
+}
+
+// ToEmail addresses a user by email
+func ToEmail(email string) Receiver {
+	return Receiver{Type: ReceiveIDEmail, ID: email}
+}
+
+// WithBaseURL sets the Open API base URL used by AppClient (default FeishuOpenAPIBaseURL)
+func WithBaseURL(baseURL string) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.BaseURL = baseURL
+	}
+}
+
+// AppClient sends messages as an app bot through the Lark Open API
+type AppClient struct {
+	transport
+	appID     string
+	appSecret string
+	baseURL   string
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewAppClient creates a client authenticating with the app's credentials
+func NewAppClient(appID, appSecret string, opts ...ClientOption) *AppClient {
+	options := newClientOptions(opts...)
+
+	baseURL := options.BaseURL
+	if baseURL == "" {
+		baseURL = FeishuOpenAPIBaseURL
+	}
+
+	client := &AppClient{
+		transport: newTransport(options),
+		appID:     appID,
+		appSecret: appSecret,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+	}
+	client.recorder = recorderFor(options, "")
+	return client
+}
+
+// SendCard sends a card to the receiver
+func (c *AppClient) SendCard(to Receiver, card *Card) (MessageRef, error) {
+	return c.SendCardCtx(context.Background(), to, card)
+}
+
+// SendText sends a simple text message to the receiver, @mentioning any given users
+func (c *AppClient) SendText(to Receiver, text string, mentions ...Mention) (MessageRef, error) {
+	return c.SendTextCtx(context.Background(), to, text, mentions...)
+}
+
+// SendCardCtx sends a card with a context to control request lifecycle; oversized cards are
+// trimmed to the payload limit, or sent as text as a last resort
+func (c *AppClient) SendCardCtx(ctx context.Context, to Receiver, card *Card) (MessageRef, error) {
+	fitted, text := c.fitCard(card)
+	if fitted == nil {
+		return c.SendTextCtx(ctx, to, text)
+	}
+
+	content, err := json.Marshal(fitted.Card)
+	if err != nil {
+		return MessageRef{}, fmt.Errorf("failed to marshal card: %w", err)
+	}
+	return c.sendMessageCtx(ctx, to, "interactive", content)
+}
+
+// SendTextCtx sends a text message with a context
+func (c *AppClient) SendTextCtx(ctx context.Context, to Receiver, text string, mentions ...Mention) (MessageRef, error) {
+	content, err := json.Marshal(map[string]string{"text": withMentionText(text, mentions)})
+	if err != nil {
+		return MessageRef{}, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return c.sendMessageCtx(ctx, to, "text", content)
+}
+
+// SendPost sends a rich-text post to the receiver
+func (c *AppClient) SendPost(to Receiver, post *Post) (MessageRef, error) {
+	return c.SendPostCtx(context.Background(), to, post)
+}
+
+// SendPostCtx sends a rich-text post with a context
+func (c *AppClient) SendPostCtx(ctx context.Context, to Receiver, post *Post) (MessageRef, error) {
+	// The Open API takes the language map directly, without the webhook's "post" wrapper
+	content, err := json.Marshal(post.Content.Post)
+	if err != nil {
+		return MessageRef{}, fmt.Errorf("failed to marshal post: %w", err)
+	}
+	return c.sendMessageCtx(ctx, to, "post", content)
+}
+
+// UpdateCard replaces the content of a previously sent card; the card must have UpdateMulti set
+// for the change to reach every member of a group. Oversized cards are trimmed to the payload limit;
+// a card that cannot be trimmed enough fails with ErrCardTooLarge, as a card cannot become text.
+func (c *AppClient) UpdateCard(ctx context.Context, ref MessageRef, card *Card) error {
+	if ref.MessageID == "" {
+		return ErrNoMessageID
+	}
+
+	fitted, _ := c.fitCard(card)
+	if fitted == nil {
+		return ErrCardTooLarge
+	}
+	content, err := json.Marshal(fitted.Card)
+	if err != nil {
+		return fmt.Errorf("failed to marshal card: %w", err)
+	}
+	if c.recorder != nil {
+		return c.recorder.recordAppCard(content)
+	}
+	body, err := json.Marshal(map[string]string{"content": string(content)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	endpoint := c.baseURL + "/im/v1/messages/" + url.PathEscape(ref.MessageID)
+	return c.retryCtx(ctx, endpoint, func(ctx context.Context) error {
+		_, err := c.authorizedDoCtx(ctx, http.MethodPatch, endpoint, body)
+		return err
+	})
+}
+
+// AppTarget sends to one fixed receiver; it implements Sender and CardUpdater
+type AppTarget struct {
+	app *AppClient
+	to  Receiver
+}
+
+// To binds the client to a receiver, e.g. to back a LarkLogger
+func (c *AppClient) To(to Receiver) *AppTarget {
+	return &AppTarget{app: c, to: to}
+}
+
+// SendCardCtx implements Sender
+func (t *AppTarget) SendCardCtx(ctx context.Context, card *Card) error {
+	_, err := t.app.SendCardCtx(ctx, t.to, card)
+	return err
+}
+
+// SendCardRefCtx implements CardUpdater
+func (t *AppTarget) SendCardRefCtx(ctx context.Context, card *Card) (MessageRef, error) {
+	return t.app.SendCardCtx(ctx, t.to, card)
+}
+
+// UpdateCard implements CardUpdater
+func (t *AppTarget) UpdateCard(ctx context.Context, ref MessageRef, card *Card) error {
+	return t.app.UpdateCard(ctx, ref, card)
+}
+
+// RateLimitStats returns a snapshot of the client-side rate limiter activity
+func (c *AppClient) RateLimitStats() RateLimitStats {
+	return c.limiter.Stats()
+}
+
+// sendMessageCtx posts a message to /im/v1/messages and returns a reference to it
+func (c *AppClient) sendMessageCtx(ctx context.Context, to Receiver, msgType string, content []byte) (MessageRef, error) {
+	if to.ID == "" || to.Type == "" {
+		return MessageRef{}, errors.New("receiver type and id are required")
+	}
+	if c.recorder != nil {
+		return c.recorder.recordApp(msgType, content)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"receive_id": to.ID,
+		"msg_type":   msgType,
+		"content":    string(content),
+		// Lets Lark deduplicate the message if a retry follows a lost response
+		"uuid": newRequestUUID(),
+	})
+	if err != nil {
+		return MessageRef{}, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	endpoint := c.baseURL + "/im/v1/messages?receive_id_type=" + url.QueryEscape(string(to.Type))
+
+	var ref MessageRef
+	err = c.retryCtx(ctx, endpoint, func(ctx context.Context) error {
+		resp, err := c.authorizedDoCtx(ctx, http.MethodPost, endpoint, body)
+		if err != nil {
+			return err
+		}
+
+		var parsed struct {
+			Data struct {
+				MessageID string `json:"message_id"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(resp, &parsed); err != nil {
+			return &TransportError{Op: "parse response", Err: err}
+		}
+		ref = MessageRef{MessageID: parsed.Data.MessageID, SentAt: time.Now()}
+		return nil
+	})
+	return ref, err
+}
+
+// authorizedDoCtx sends a request with the tenant_access_token, refreshing it once if Lark rejects it
+func (c *AppClient) authorizedDoCtx(ctx context.Context, method, endpoint string, body []byte) ([]byte, error) {
+	for refreshed := false; ; refreshed = true {
+		token, err := c.tenantToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.doJSONCtx(ctx, method, endpoint, map[string]string{"Authorization": "Bearer " + token}, body)
+		if err == nil || refreshed || !isTokenInvalid(err) {
+			return resp, err
+		}
+		c.invalidateToken(token)
+	}
+}
+
+// tenantToken returns the cached tenant_access_token, fetching a new one when it is about to expire
+func (c *AppClient) tenantToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.token != "" && time.Until(c.tokenExpiry) > tokenRefreshMargin {
+		return c.token, nil
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"app_id":     c.appID,
+		"app_secret": c.appSecret,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	resp, err := c.postJSONCtx(ctx, c.baseURL+"/auth/v3/tenant_access_token/internal", nil, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tenant_access_token: %w", err)
+	}
+
+	var parsed struct {
+		TenantAccessToken string `json:"tenant_access_token"`
+		Expire            int    `json:"expire"` // Seconds
+	}
+	if err := json.Unmarshal(resp, &parsed); err != nil {
+		return "", &TransportError{Op: "parse token response", Err: err}
+	}
+	if parsed.TenantAccessToken == "" {
+		return "", errors.New("failed to get tenant_access_token: empty token in response")
+	}
+
+	c.token = parsed.TenantAccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(parsed.Expire) * time.Second)
+	return c.token, nil
+}
+
+// invalidateToken drops the cached token unless another request already replaced it
+func (c *AppClient) invalidateToken(token string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token == token {
+		c.token = ""
+		c.tokenExpiry = time.Time{}
+	}
+}
+
+// isTokenInvalid reports whether Lark rejected the request's tenant_access_token
+func isTokenInvalid(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Code {
+	case CodeTokenInvalid, CodeTokenExpired, CodeTokenMissing:
+		return true
+	}
+	return false
+}
+
+// newRequestUUID returns a random idempotency key for one logical send
+func newRequestUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
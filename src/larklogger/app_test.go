@@ -0,0 +1,219 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+// newFakeApp starts a fake Open API and an AppClient authenticated against it
+func newFakeApp(t *testing.T, opts ...ClientOption) (*fakelark.Server, *AppClient) {
+	srv := fakelark.NewServer(t)
+	opts = append([]ClientOption{WithBaseURL(srv.BaseURL())}, opts...)
+	return srv, NewAppClient(fakelark.AppID, fakelark.AppSecret, opts...)
+}
+
+// updatesFor returns the card updates the server accepted for messageID, in order
+func updatesFor(srv *fakelark.Server, messageID string) []fakelark.Message {
+	var updates []fakelark.Message
+	for _, msg := range srv.Messages() {
+		if msg.Update && msg.MessageID == messageID {
+			updates = append(updates, msg)
+		}
+	}
+	return updates
+}
+
+func TestAppClientSend(t *testing.T) {
+	t.Run("sends card to chat", func(t *testing.T) {
+		srv, client := newFakeApp(t)
+
+		card := NewCardBuilder().SetHeader("Deploy", "blue").AddSection("done").Build()
+		ref, err := client.SendCardCtx(context.Background(), ToChat("oc_123"), card)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if ref.MessageID != "om_test_1" {
+			t.Errorf("Expected message_id om_test_1, got %s", ref.MessageID)
+		}
+
+		messages := srv.Messages()
+		if len(messages) != 1 {
+			t.Fatalf("Expected 1 message, got %d", len(messages))
+		}
+		msg := messages[0]
+		if msg.ReceiveIDType != "chat_id" || msg.ReceiveID != "oc_123" {
+			t.Errorf("Expected chat_id oc_123, got %s %s", msg.ReceiveIDType, msg.ReceiveID)
+		}
+		if msg.MsgType != "interactive" {
+			t.Errorf("Expected msg_type interactive, got %s", msg.MsgType)
+		}
+
+		var content CardData
+		if err := json.Unmarshal(msg.Card, &content); err != nil {
+			t.Fatalf("Expected card JSON content, got error: %v", err)
+		}
+		if content.Header.Title.Content != "Deploy" {
+			t.Errorf("Expected card title Deploy, got %s", content.Header.Title.Content)
+		}
+
+		var body struct {
+			UUID string `json:"uuid"`
+		}
+		if err := json.Unmarshal(msg.Raw, &body); err != nil || body.UUID == "" {
+			t.Error("Expected an idempotency uuid")
+		}
+	})
+
+	t.Run("sends text by email", func(t *testing.T) {
+		srv, client := newFakeApp(t)
+
+		if _, err := client.SendText(ToEmail("oncall@example.com"), "wake up"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		msg := srv.Messages()[0]
+		if msg.ReceiveIDType != "email" || msg.MsgType != "text" {
+			t.Errorf("Expected email text message, got %s %s", msg.ReceiveIDType, msg.MsgType)
+		}
+		if msg.Text != "wake up" {
+			t.Errorf("Expected text content, got %s", msg.Text)
+		}
+	})
+
+	t.Run("rejects empty receiver", func(t *testing.T) {
+		client := NewAppClient(fakelark.AppID, fakelark.AppSecret)
+		if _, err := client.SendText(Receiver{}, "hello"); err == nil {
+			t.Error("Expected error for empty receiver")
+		}
+	})
+}
+
+func TestAppClientToken(t *testing.T) {
+	// tokens returns the Authorization header of every accepted message
+	tokens := func(srv *fakelark.Server) []string {
+		var got []string
+		for _, msg := range srv.Messages() {
+			got = append(got, msg.Header.Get("Authorization"))
+		}
+		return got
+	}
+
+	t.Run("caches token", func(t *testing.T) {
+		srv, client := newFakeApp(t, WithRateLimit(0, 0))
+
+		for i := 0; i < 3; i++ {
+			if _, err := client.SendText(ToOpenID("ou_1"), "hello"); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		for _, token := range tokens(srv) {
+			if token != "Bearer t-test-1" {
+				t.Errorf("Expected every message to reuse the first token, got %v", tokens(srv))
+				break
+			}
+		}
+	})
+
+	t.Run("refreshes token close to expiry", func(t *testing.T) {
+		// Tokens expire inside the refresh margin, so every send fetches a new one
+		srv := fakelark.NewServer(t, fakelark.WithTokenExpiry(time.Minute))
+		client := NewAppClient(fakelark.AppID, fakelark.AppSecret, WithBaseURL(srv.BaseURL()), WithRateLimit(0, 0))
+
+		for i := 0; i < 2; i++ {
+			if _, err := client.SendText(ToUserID("u_1"), "hello"); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if got := tokens(srv); len(got) != 2 || got[0] != "Bearer t-test-1" || got[1] != "Bearer t-test-2" {
+			t.Errorf("Expected a fresh token per send, got %v", got)
+		}
+	})
+
+	t.Run("refreshes rejected token once", func(t *testing.T) {
+		srv, client := newFakeApp(t, WithRetry(0, 0))
+
+		if _, err := client.SendText(ToChat("oc_1"), "hello"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		srv.RevokeTokens()
+
+		if _, err := client.SendText(ToChat("oc_1"), "hello"); err != nil {
+			t.Fatalf("Expected no error after refresh, got %v", err)
+		}
+		if got := tokens(srv); len(got) != 2 || got[1] != "Bearer t-test-2" {
+			t.Errorf("Expected the second message to carry a refreshed token, got %v", got)
+		}
+		if got := len(srv.Rejections()); got != 1 {
+			t.Errorf("Expected 1 rejected request, got %d", got)
+		}
+	})
+
+	t.Run("surfaces credential errors", func(t *testing.T) {
+		srv := fakelark.NewServer(t)
+		client := NewAppClient(fakelark.AppID, "wrong", WithBaseURL(srv.BaseURL()), WithRetry(0, 0))
+
+		_, err := client.SendText(ToChat("oc_1"), "hello")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != 10014 {
+			t.Errorf("Expected APIError with code 10014, got %v", err)
+		}
+	})
+}
+
+func TestAppClientRetry(t *testing.T) {
+	srv, client := newFakeApp(t, WithRetry(3, time.Millisecond), WithRateLimit(0, 0))
+	srv.Script(fakelark.ServerError(http.StatusInternalServerError), fakelark.ServerError(http.StatusInternalServerError))
+
+	if _, err := client.SendText(ToChat("oc_1"), "hello"); err != nil {
+		t.Fatalf("Expected no error after retries, got %v", err)
+	}
+
+	if got := srv.Requests(); got != 3 {
+		t.Errorf("Expected 3 requests, got %d", got)
+	}
+	if got := len(srv.Messages()); got != 1 {
+		t.Fatalf("Expected 1 message, got %d", got)
+	}
+}
+
+func TestAppClientUpdateCard(t *testing.T) {
+	t.Run("patches message content", func(t *testing.T) {
+		srv, client := newFakeApp(t)
+
+		ref, err := client.SendCard(ToChat("oc_1"), NewCardBuilder().SetHeader("Incident", ColorRed).Build())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		updated := NewCardBuilder().SetHeader("Incident", ColorGreen).Build()
+		if err := client.UpdateCard(context.Background(), ref, updated); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		updates := updatesFor(srv, ref.MessageID)
+		if len(updates) != 1 {
+			t.Fatalf("Expected 1 update, got %d", len(updates))
+		}
+		var content CardData
+		if err := json.Unmarshal(updates[0].Card, &content); err != nil {
+			t.Fatalf("Expected card JSON content, got error: %v", err)
+		}
+		if content.Header.Template != ColorGreen {
+			t.Errorf("Expected template %s, got %s", ColorGreen, content.Header.Template)
+		}
+	})
+
+	t.Run("requires message_id", func(t *testing.T) {
+		client := NewAppClient(fakelark.AppID, fakelark.AppSecret)
+		err := client.UpdateCard(context.Background(), MessageRef{}, NewCardBuilder().Build())
+		if !errors.Is(err, ErrNoMessageID) {
+			t.Errorf("Expected ErrNoMessageID, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,270 @@
+package larklogger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy controls what an async logger does when its queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest discards the message being logged
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued message to make room
+	OverflowDropOldest
+	// OverflowBlock waits for room (or until the log call's ctx is done)
+	OverflowBlock
+)
+
+// Flusher is implemented by loggers that deliver messages asynchronously
+type Flusher interface {
+	// Flush waits until every queued message has been delivered or ctx is done
+	Flush(ctx context.Context) error
+	// Close stops accepting messages, drains the queue and stops the workers
+	Close(ctx context.Context) error
+}
+
+// AsyncStats summarizes async delivery activity
+type AsyncStats struct {
+	Enqueued   uint64 // Messages accepted into the queue
+	Delivered  uint64 // Messages handed to the client (successfully or not)
+	Dropped    uint64 // Messages discarded due to overflow or shutdown
+	QueueDepth int    // Messages currently waiting in the queue
+}
+
+// asyncItem is a built card waiting for delivery
+type asyncItem struct {
+	ctx  context.Context
+	card *Card
+}
+
+// asyncQueue delivers cards with a fixed worker pool
+type asyncQueue struct {
+	items  chan asyncItem
+	policy OverflowPolicy
+	send   func(context.Context, *Card)
+
+	mu      sync.Mutex
+	closed  bool
+	pending int
+	idle    chan struct{} // Closed whenever pending drops to zero
+
+	sendMu  sync.RWMutex  // Held for reading by producers so Close can wait them out
+	quit    chan struct{} // Closed by Close to release blocked producers
+	drain   chan struct{} // Closed by Close to stop workers once the queue is empty
+	stopCtx context.Context
+	stop    context.CancelFunc // Aborts in-flight sends when Close times out
+	workers sync.WaitGroup
+
+	enqueued  atomic.Uint64
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+func newAsyncQueue(size, workers int, policy OverflowPolicy, send func(context.Context, *Card)) *asyncQueue {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	idle := make(chan struct{})
+	close(idle)
+
+	stopCtx, stop := context.WithCancel(context.Background())
+	q := &asyncQueue{
+		items:   make(chan asyncItem, size),
+		policy:  policy,
+		send:    send,
+		idle:    idle,
+		quit:    make(chan struct{}),
+		drain:   make(chan struct{}),
+		stopCtx: stopCtx,
+		stop:    stop,
+	}
+
+	for i := 0; i < workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+
+	return q
+}
+
+// enqueue queues a card according to the overflow policy
+func (q *asyncQueue) enqueue(ctx context.Context, card *Card) {
+	q.sendMu.RLock()
+	defer q.sendMu.RUnlock()
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		q.dropped.Add(1)
+		return
+	}
+	q.addPendingLocked()
+	q.mu.Unlock()
+
+	// Detach from the caller's cancellation: the request may end before delivery
+	item := asyncItem{ctx: context.WithoutCancel(ctx), card: card}
+
+	for {
+		select {
+		case q.items <- item:
+			q.enqueued.Add(1)
+			return
+		default:
+		}
+
+		switch q.policy {
+		case OverflowDropOldest:
+			select {
+			case <-q.items:
+				q.dropped.Add(1)
+				q.donePending()
+			default:
+			}
+		case OverflowBlock:
+			select {
+			case q.items <- item:
+				q.enqueued.Add(1)
+				return
+			case <-ctx.Done():
+			case <-q.quit:
+			}
+			q.dropped.Add(1)
+			q.donePending()
+			return
+		default:
+			q.dropped.Add(1)
+			q.donePending()
+			return
+		}
+	}
+}
+
+// work delivers queued cards until the queue is closed and drained
+func (q *asyncQueue) work() {
+	defer q.workers.Done()
+	for {
+		select {
+		case item := <-q.items:
+			q.deliver(item)
+		case <-q.drain:
+			for {
+				select {
+				case item := <-q.items:
+					q.deliver(item)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *asyncQueue) deliver(item asyncItem) {
+	// Close timed out: drop what is left instead of sending it
+	if q.stopCtx.Err() != nil {
+		q.dropped.Add(1)
+		q.donePending()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(item.ctx)
+	stopAbort := context.AfterFunc(q.stopCtx, cancel)
+	q.send(ctx, item.card)
+	stopAbort()
+	cancel()
+
+	q.delivered.Add(1)
+	q.donePending()
+}
+
+func (q *asyncQueue) addPendingLocked() {
+	if q.pending == 0 {
+		q.idle = make(chan struct{})
+	}
+	q.pending++
+}
+
+func (q *asyncQueue) donePending() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending--
+	if q.pending == 0 {
+		close(q.idle)
+	}
+}
+
+// flush waits until nothing is pending
+func (q *asyncQueue) flush(ctx context.Context) error {
+	q.mu.Lock()
+	idle := q.idle
+	q.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting messages and waits for the workers to drain the queue.
+// If ctx ends first, in-flight sends are aborted and remaining messages dropped.
+func (q *asyncQueue) close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.quit)
+	q.mu.Unlock()
+
+	// Wait for producers that raced with Close before letting the workers finish
+	q.sendMu.Lock()
+	close(q.drain)
+	q.sendMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.stop()
+		return nil
+	case <-ctx.Done():
+		q.stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (q *asyncQueue) stats() AsyncStats {
+	return AsyncStats{
+		Enqueued:   q.enqueued.Load(),
+		Delivered:  q.delivered.Load(),
+		Dropped:    q.dropped.Load(),
+		QueueDepth: len(q.items),
+	}
+}
+
+// WithAsync enables asynchronous delivery through a queue of queueSize cards served by workers goroutines
+func WithAsync(queueSize, workers int) LoggerOption {
+	return func(c *LoggerConfig) {
+		c.AsyncQueueSize = queueSize
+		c.AsyncWorkers = workers
+	}
+}
+
+// WithOverflowPolicy sets what an async logger does when its queue is full
+func WithOverflowPolicy(policy OverflowPolicy) LoggerOption {
+	return func(c *LoggerConfig) {
+		c.OverflowPolicy = policy
+	}
+}
@@ -0,0 +1,188 @@
+package larklogger
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+// blockingSender records cards and blocks until released
+type blockingSender struct {
+	mu      sync.Mutex
+	release chan struct{}
+	cards   []*Card
+}
+
+func (s *blockingSender) send(ctx context.Context, card *Card) {
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	s.cards = append(s.cards, card)
+	s.mu.Unlock()
+}
+
+func (s *blockingSender) titles() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var titles []string
+	for _, c := range s.cards {
+		titles = append(titles, c.Card.Header.Title.Content)
+	}
+	return titles
+}
+
+func titledCard(title string) *Card {
+	return NewCardBuilder().SetHeader(title, ColorBlue).Build()
+}
+
+func TestAsyncQueue(t *testing.T) {
+	t.Run("drop newest when full", func(t *testing.T) {
+		sender := &blockingSender{release: make(chan struct{})}
+		q := newAsyncQueue(1, 1, OverflowDropNewest, sender.send)
+
+		q.enqueue(context.Background(), titledCard("in-flight"))
+		waitForDepth(t, q, 0)
+		q.enqueue(context.Background(), titledCard("queued"))
+		q.enqueue(context.Background(), titledCard("dropped"))
+
+		if stats := q.stats(); stats.Dropped != 1 || stats.QueueDepth != 1 {
+			t.Errorf("Expected 1 dropped and 1 queued, got %+v", stats)
+		}
+
+		close(sender.release)
+		if err := q.close(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if titles := sender.titles(); len(titles) != 2 || titles[1] != "queued" {
+			t.Errorf("Expected in-flight and queued to be delivered, got %v", titles)
+		}
+	})
+
+	t.Run("drop oldest when full", func(t *testing.T) {
+		sender := &blockingSender{release: make(chan struct{})}
+		q := newAsyncQueue(1, 1, OverflowDropOldest, sender.send)
+
+		q.enqueue(context.Background(), titledCard("in-flight"))
+		waitForDepth(t, q, 0)
+		q.enqueue(context.Background(), titledCard("old"))
+		q.enqueue(context.Background(), titledCard("new"))
+
+		close(sender.release)
+		_ = q.close(context.Background())
+		if titles := sender.titles(); len(titles) != 2 || titles[1] != "new" {
+			t.Errorf("Expected the newest message to survive, got %v", titles)
+		}
+		if stats := q.stats(); stats.Dropped != 1 {
+			t.Errorf("Expected 1 dropped, got %d", stats.Dropped)
+		}
+	})
+
+	t.Run("block until ctx is done", func(t *testing.T) {
+		sender := &blockingSender{release: make(chan struct{})}
+		q := newAsyncQueue(1, 1, OverflowBlock, sender.send)
+
+		q.enqueue(context.Background(), titledCard("in-flight"))
+		waitForDepth(t, q, 0)
+		q.enqueue(context.Background(), titledCard("queued"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		q.enqueue(ctx, titledCard("blocked"))
+		if time.Since(start) < 20*time.Millisecond {
+			t.Error("Expected enqueue to block while the queue is full")
+		}
+		if stats := q.stats(); stats.Dropped != 1 {
+			t.Errorf("Expected the blocked message to be dropped, got %+v", stats)
+		}
+
+		close(sender.release)
+		_ = q.close(context.Background())
+	})
+
+	t.Run("flush waits for delivery", func(t *testing.T) {
+		sender := &blockingSender{release: make(chan struct{})}
+		q := newAsyncQueue(10, 2, OverflowDropNewest, sender.send)
+		for i := 0; i < 5; i++ {
+			q.enqueue(context.Background(), titledCard("msg"))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := q.flush(ctx); err == nil {
+			t.Error("Expected flush to time out while sends are blocked")
+		}
+
+		close(sender.release)
+		if err := q.flush(context.Background()); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if stats := q.stats(); stats.Delivered != 5 {
+			t.Errorf("Expected 5 delivered, got %d", stats.Delivered)
+		}
+		_ = q.close(context.Background())
+	})
+
+	t.Run("close aborts in-flight sends on timeout", func(t *testing.T) {
+		sender := &blockingSender{release: make(chan struct{})}
+		q := newAsyncQueue(10, 1, OverflowDropNewest, sender.send)
+		for i := 0; i < 3; i++ {
+			q.enqueue(context.Background(), titledCard("msg"))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := q.close(ctx); err == nil {
+			t.Error("Expected close to report the timeout")
+		}
+		if stats := q.stats(); stats.Delivered+stats.Dropped != 3 {
+			t.Errorf("Expected every message to be accounted for, got %+v", stats)
+		}
+
+		q.enqueue(context.Background(), titledCard("after close"))
+		if stats := q.stats(); stats.Dropped < 3 {
+			t.Errorf("Expected messages after close to be dropped, got %+v", stats)
+		}
+	})
+}
+
+func TestLarkLoggerAsync(t *testing.T) {
+	srv := fakelark.NewServer(t)
+	srv.Script(fakelark.Slow(50*time.Millisecond), fakelark.Slow(50*time.Millisecond))
+
+	client := NewLarkClient(srv.WebhookURL())
+	logger := NewLarkLogger(context.Background(), client, WithAsync(10, 2)).(*LarkLogger)
+
+	start := time.Now()
+	logger.Error("Database error", map[string]interface{}{"error": "timeout"})
+	logger.Warnf("High memory usage", "usage", "85%")
+	if time.Since(start) > 40*time.Millisecond {
+		t.Errorf("Expected log calls not to block on delivery, took %v", time.Since(start))
+	}
+
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := len(srv.Messages()); got != 2 {
+		t.Errorf("Expected 2 messages delivered, got %d", got)
+	}
+	if stats := logger.AsyncStats(); stats.Enqueued != 2 || stats.Delivered != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func waitForDepth(t *testing.T, q *asyncQueue, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for len(q.items) != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("Queue depth never reached %d", depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,258 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of the circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets every send through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen short-circuits every send until the cool-down has passed
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of trial sends through
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrCircuitOpen matches every CircuitOpenError via errors.Is
+var ErrCircuitOpen = errors.New("lark circuit breaker is open")
+
+// CircuitOpenError is returned when a send is short-circuited by the breaker
+type CircuitOpenError struct {
+	State   CircuitState // State that rejected the send
+	RetryAt time.Time    // When the breaker will next let a trial send through
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("lark circuit breaker is %s, retry after %s", e.State, e.RetryAt.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) true
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// FallbackSink receives payloads diverted while the breaker is open
+type FallbackSink interface {
+	Divert(ctx context.Context, payload []byte) error
+}
+
+// FallbackFunc adapts a function to FallbackSink
+type FallbackFunc func(ctx context.Context, payload []byte) error
+
+// Divert implements FallbackSink
+func (f FallbackFunc) Divert(ctx context.Context, payload []byte) error {
+	return f(ctx, payload)
+}
+
+// NewWriterSink returns a FallbackSink writing one JSON payload per line to w (e.g. os.Stderr)
+func NewWriterSink(w io.Writer) FallbackSink {
+	var mu sync.Mutex
+	return FallbackFunc(func(ctx context.Context, payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := w.Write(append(append([]byte{}, payload...), '\n'))
+		return err
+	})
+}
+
+// CircuitBreakerOptions configures the circuit breaker
+type CircuitBreakerOptions struct {
+	FailureRatio        float64                     // Open when failures/sends in the window reach this (default 0.5)
+	MinRequests         int                         // Sends needed in the window before the ratio is evaluated (default 10)
+	Window              time.Duration               // Length of the counting window while closed (default 1m)
+	CoolDown            time.Duration               // Time spent open before trial sends are allowed (default 30s)
+	HalfOpenMaxRequests int                         // Concurrent trial sends allowed while half-open (default 1)
+	Fallback            FallbackSink                // Optional sink for sends rejected while open; unused when the client has a spool
+	OnStateChange       func(from, to CircuitState) // Optional hook called on every transition
+}
+
+// circuitBreaker guards sends with closed/open/half-open states
+type circuitBreaker struct {
+	opts CircuitBreakerOptions
+
+	mu          sync.Mutex
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	trials      int    // In-flight trial sends while half-open
+	generation  uint64 // Bumped on every transition so late outcomes of earlier states are ignored
+}
+
+func newCircuitBreaker(opts CircuitBreakerOptions) *circuitBreaker {
+	if opts.FailureRatio <= 0 {
+		opts.FailureRatio = 0.5
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 10
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	if opts.CoolDown <= 0 {
+		opts.CoolDown = 30 * time.Second
+	}
+	if opts.HalfOpenMaxRequests <= 0 {
+		opts.HalfOpenMaxRequests = 1
+	}
+	return &circuitBreaker{opts: opts, windowStart: time.Now()}
+}
+
+// allow reports whether a send may proceed; the returned func must be called with its result.
+// The result only counts if the breaker has not changed state since the send was allowed.
+func (b *circuitBreaker) allow() (func(error), error) {
+	b.mu.Lock()
+	var transition func()
+
+	now := time.Now()
+	if b.state == CircuitOpen && now.Sub(b.openedAt) >= b.opts.CoolDown {
+		transition = b.setStateLocked(CircuitHalfOpen)
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		err := &CircuitOpenError{State: CircuitOpen, RetryAt: b.openedAt.Add(b.opts.CoolDown)}
+		b.mu.Unlock()
+		return nil, err
+	case CircuitHalfOpen:
+		if b.trials >= b.opts.HalfOpenMaxRequests {
+			err := &CircuitOpenError{State: CircuitHalfOpen, RetryAt: now}
+			b.mu.Unlock()
+			runTransition(transition)
+			return nil, err
+		}
+		b.trials++
+	}
+	generation := b.generation
+	b.mu.Unlock()
+	runTransition(transition)
+
+	return func(err error) { b.record(generation, err) }, nil
+}
+
+// record updates the breaker with the outcome of a send allowed in the given generation
+func (b *circuitBreaker) record(generation uint64, err error) {
+	// Only failures that signal Lark degradation count; rejected payloads, our own
+	// limiter and cancellations do not
+	failed := err != nil && !errors.Is(err, ErrRateLimitExceeded) && (IsRetryable(err) || IsRateLimited(err))
+
+	b.mu.Lock()
+	// A send started before the last transition says nothing about the current state,
+	// e.g. a slow send from the closed state must not decide a half-open trial
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+	if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		if b.state == CircuitHalfOpen && b.trials > 0 {
+			b.trials--
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	var transition func()
+
+	switch b.state {
+	case CircuitHalfOpen:
+		if b.trials > 0 {
+			b.trials--
+		}
+		if failed {
+			transition = b.setStateLocked(CircuitOpen)
+		} else {
+			transition = b.setStateLocked(CircuitClosed)
+		}
+	case CircuitClosed:
+		now := time.Now()
+		if now.Sub(b.windowStart) >= b.opts.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.opts.MinRequests && float64(b.failures)/float64(b.requests) >= b.opts.FailureRatio {
+			transition = b.setStateLocked(CircuitOpen)
+		}
+	}
+	b.mu.Unlock()
+	runTransition(transition)
+}
+
+// setStateLocked switches state and returns the hook call to run once unlocked
+func (b *circuitBreaker) setStateLocked(to CircuitState) func() {
+	from := b.state
+	if from == to {
+		return nil
+	}
+
+	b.state = to
+	b.generation++
+	switch to {
+	case CircuitOpen:
+		b.openedAt = time.Now()
+	case CircuitClosed:
+		b.windowStart, b.requests, b.failures = time.Now(), 0, 0
+	case CircuitHalfOpen:
+		b.trials = 0
+	}
+
+	if b.opts.OnStateChange == nil {
+		return nil
+	}
+	hook := b.opts.OnStateChange
+	return func() { hook(from, to) }
+}
+
+func runTransition(transition func()) {
+	if transition != nil {
+		transition()
+	}
+}
+
+// currentState returns the breaker state
+func (b *circuitBreaker) currentState() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// divert hands a rejected payload to the fallback sink, if any
+func (b *circuitBreaker) divert(ctx context.Context, payload []byte) {
+	if b.opts.Fallback == nil {
+		return
+	}
+	if err := b.opts.Fallback.Divert(ctx, payload); err != nil {
+		fmt.Printf("Failed to divert Lark message to fallback sink: %v\n", err)
+	}
+}
+
+// WithCircuitBreaker guards sends with a circuit breaker
+func WithCircuitBreaker(opts CircuitBreakerOptions) ClientOption {
+	return func(o *ClientOptions) {
+		o.CircuitBreaker = &opts
+	}
+}
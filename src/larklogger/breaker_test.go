@@ -0,0 +1,194 @@
+package larklogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var mu sync.Mutex
+	var transitions []string
+	breaker := newCircuitBreaker(CircuitBreakerOptions{
+		FailureRatio: 0.5,
+		MinRequests:  4,
+		CoolDown:     20 * time.Millisecond,
+		OnStateChange: func(from, to CircuitState) {
+			mu.Lock()
+			transitions = append(transitions, from.String()+"->"+to.String())
+			mu.Unlock()
+		},
+	})
+	transient := &APIError{HTTPStatus: http.StatusBadGateway}
+
+	t.Run("opens once the failure ratio is reached", func(t *testing.T) {
+		for _, err := range []error{nil, transient, nil, transient} {
+			record, allowErr := breaker.allow()
+			if allowErr != nil {
+				t.Fatalf("Expected send to be allowed, got %v", allowErr)
+			}
+			record(err)
+		}
+		if breaker.currentState() != CircuitOpen {
+			t.Fatalf("Expected breaker to be open, got %s", breaker.currentState())
+		}
+
+		_, err := breaker.allow()
+		var openErr *CircuitOpenError
+		if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected CircuitOpenError, got %v", err)
+		}
+	})
+
+	t.Run("half-open allows a single trial", func(t *testing.T) {
+		time.Sleep(25 * time.Millisecond)
+
+		record, err := breaker.allow()
+		if err != nil {
+			t.Fatalf("Expected trial send after cool-down, got %v", err)
+		}
+		if _, err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected concurrent trial to be rejected, got %v", err)
+		}
+
+		record(transient)
+		if breaker.currentState() != CircuitOpen {
+			t.Errorf("Expected failed trial to reopen the breaker, got %s", breaker.currentState())
+		}
+	})
+
+	t.Run("successful trial closes the breaker", func(t *testing.T) {
+		time.Sleep(25 * time.Millisecond)
+		record, err := breaker.allow()
+		if err != nil {
+			t.Fatalf("Expected trial send, got %v", err)
+		}
+		record(nil)
+		if breaker.currentState() != CircuitClosed {
+			t.Errorf("Expected breaker to close, got %s", breaker.currentState())
+		}
+	})
+
+	t.Run("payload errors do not trip the breaker", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			record, _ := breaker.allow()
+			record(&APIError{HTTPStatus: http.StatusOK, Code: CodeBadRequest})
+		}
+		if breaker.currentState() != CircuitClosed {
+			t.Errorf("Expected breaker to stay closed, got %s", breaker.currentState())
+		}
+	})
+
+	t.Run("outcomes of earlier states are ignored", func(t *testing.T) {
+		slow, _ := breaker.allow()
+		for i := 0; i < 20 && breaker.currentState() == CircuitClosed; i++ {
+			record, _ := breaker.allow()
+			record(transient)
+		}
+		time.Sleep(25 * time.Millisecond)
+		trial, err := breaker.allow()
+		if err != nil {
+			t.Fatalf("Expected trial send, got %v", err)
+		}
+
+		// A send started while closed finishing now must not close the breaker
+		slow(nil)
+		if breaker.currentState() != CircuitHalfOpen {
+			t.Errorf("Expected breaker to stay half-open, got %s", breaker.currentState())
+		}
+		trial(nil)
+		if breaker.currentState() != CircuitClosed {
+			t.Errorf("Expected the trial to close the breaker, got %s", breaker.currentState())
+		}
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed",
+		"closed->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, transitions)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Errorf("Expected transition %d to be %s, got %s", i, expected[i], transitions[i])
+		}
+	}
+}
+
+func TestLarkClientCircuitBreaker(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var diverted bytes.Buffer
+	client := NewLarkClient(server.URL,
+		WithRetry(0, 0),
+		WithCircuitBreaker(CircuitBreakerOptions{
+			MinRequests: 2,
+			CoolDown:    time.Hour,
+			Fallback:    NewWriterSink(&diverted),
+		}),
+	)
+
+	for i := 0; i < 2; i++ {
+		_ = client.SendText("failing")
+	}
+	if client.CircuitState() != CircuitOpen {
+		t.Fatalf("Expected breaker to be open, got %s", client.CircuitState())
+	}
+
+	err := client.SendTextCtx(context.Background(), "short-circuited")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected no request while open, got %d calls", calls)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(diverted.Bytes()), &payload); err != nil || payload["msg_type"] != "text" {
+		t.Errorf("Expected the rejected payload in the fallback sink, got %q", diverted.String())
+	}
+}
+
+func TestLarkClientCircuitBreakerWithSpool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var diverted bytes.Buffer
+	client := NewLarkClient(server.URL,
+		WithRetry(0, 0),
+		WithSpool(SpoolOptions{Dir: t.TempDir(), ReplayInterval: time.Hour}),
+		WithCircuitBreaker(CircuitBreakerOptions{
+			MinRequests: 1,
+			CoolDown:    time.Hour,
+			Fallback:    NewWriterSink(&diverted),
+		}),
+	)
+	defer client.Close()
+
+	for _, text := range []string{"trips the breaker", "short-circuited"} {
+		if err := client.SendText(text); !errors.Is(err, ErrSpooled) {
+			t.Fatalf("Expected ErrSpooled, got %v", err)
+		}
+	}
+	if client.CircuitState() != CircuitOpen {
+		t.Fatalf("Expected breaker to be open, got %s", client.CircuitState())
+	}
+	if diverted.Len() != 0 {
+		t.Errorf("Expected spooled messages not to be diverted too, got %q", diverted.String())
+	}
+}
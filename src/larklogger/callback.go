@@ -0,0 +1,273 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// EventTypeCardAction is the v2 event type of card action callbacks
+const EventTypeCardAction = "card.action.trigger"
+
+// Toast types shown to the user who clicked
+const (
+	ToastInfo    = "info"
+	ToastSuccess = "success"
+	ToastWarning = "warning"
+	ToastError   = "error"
+)
+
+// CallbackButton creates a button that calls back into a CallbackHandler instead of opening a URL
+func CallbackButton(text, key string, data map[string]interface{}) Button {
+	return Button{Text: text, Key: key, Data: data}
+}
+
+// CardAction is a click on a callback button
+type CardAction struct {
+	Key  string                 // ActionValue.Key of the button
+	Data map[string]interface{} // ActionValue.Data of the button
+	Tag  string                 // Component that triggered the action, e.g. "button"
+
+	Option    string                 // Selected option of select components
+	FormValue map[string]interface{} // Submitted form values
+
+	OpenID        string // Operator
+	UserID        string
+	UnionID       string
+	TenantKey     string
+	OpenMessageID string // Message the card belongs to
+	OpenChatID    string
+	Token         string // Callback token for delayed card updates (v2 callbacks only)
+
+	Raw json.RawMessage // Decrypted request body
+}
+
+// Toast is a short message shown to the user who clicked
+type Toast struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+// CallbackResponse answers a card action with a toast, a replacement card, or both
+type CallbackResponse struct {
+	Toast *Toast
+	Card  *Card
+}
+
+// ToastResponse answers with a toast only
+func ToastResponse(toastType, content string) *CallbackResponse {
+	return &CallbackResponse{Toast: &Toast{Type: toastType, Content: content}}
+}
+
+// CardResponse replaces the clicked card with card
+func CardResponse(card *Card) *CallbackResponse {
+	return &CallbackResponse{Card: card}
+}
+
+// CallbackFunc handles a card action. A returned error is shown to the user as an error toast.
+type CallbackFunc func(ctx context.Context, action *CardAction) (*CallbackResponse, error)
+
+// CallbackHandler is an http.Handler for the card callback URL configured in the Lark developer console.
+// It authenticates requests, answers the url_verification challenge and dispatches actions by key.
+type CallbackHandler struct {
+	opts InboundOptions
+
+	mu       sync.RWMutex
+	handlers map[string]CallbackFunc
+	fallback CallbackFunc
+}
+
+// NewCallbackHandler creates a callback handler authenticating requests with opts
+func NewCallbackHandler(opts InboundOptions) *CallbackHandler {
+	return &CallbackHandler{opts: opts, handlers: make(map[string]CallbackFunc)}
+}
+
+// Handle registers fn for buttons whose ActionValue.Key is key
+func (h *CallbackHandler) Handle(key string, fn CallbackFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[key] = fn
+}
+
+// HandleDefault registers fn for actions without a registered key
+func (h *CallbackHandler) HandleDefault(fn CallbackFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fallback = fn
+}
+
+// ServeHTTP implements http.Handler
+func (h *CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, env, err := h.opts.readInbound(r)
+	if err != nil {
+		http.Error(w, err.Error(), inboundStatus(err))
+		return
+	}
+
+	if env.Type == "url_verification" {
+		writeInboundJSON(w, map[string]string{"challenge": env.Challenge})
+		return
+	}
+
+	v2 := env.Schema == "2.0"
+	if v2 && env.Header.EventType != EventTypeCardAction {
+		http.Error(w, fmt.Sprintf("unexpected event type %q", env.Header.EventType), http.StatusBadRequest)
+		return
+	}
+
+	action, err := parseCardAction(body, v2)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.dispatch(r.Context(), action)
+	if err != nil {
+		resp = ToastResponse(ToastError, err.Error())
+	}
+	writeInboundJSON(w, resp.body(v2))
+}
+
+// dispatch runs the handler registered for the action key
+func (h *CallbackHandler) dispatch(ctx context.Context, action *CardAction) (*CallbackResponse, error) {
+	h.mu.RLock()
+	fn, ok := h.handlers[action.Key]
+	if !ok {
+		fn = h.fallback
+	}
+	h.mu.RUnlock()
+
+	if fn == nil {
+		return nil, fmt.Errorf("unknown action %q", action.Key)
+	}
+	resp, err := fn(ctx, action)
+	if resp == nil {
+		resp = &CallbackResponse{}
+	}
+	return resp, err
+}
+
+// body renders the response in the format of the callback version. v2 callbacks wrap the card
+// as {"type":"raw","data":...}; schema 1.0 callbacks take the card body itself.
+func (r *CallbackResponse) body(v2 bool) interface{} {
+	if !v2 {
+		if r.Card != nil {
+			return r.Card.Card
+		}
+		if r.Toast != nil {
+			return map[string]interface{}{"toast": r.Toast}
+		}
+		return map[string]interface{}{}
+	}
+
+	out := map[string]interface{}{}
+	if r.Toast != nil {
+		out["toast"] = r.Toast
+	}
+	if r.Card != nil {
+		if r.Card.IsTemplate() {
+			out["card"] = r.Card.Card
+		} else {
+			out["card"] = map[string]interface{}{"type": "raw", "data": r.Card.Card}
+		}
+	}
+	return out
+}
+
+// rawCardAction is the action object shared by both callback versions
+type rawCardAction struct {
+	Value     map[string]interface{} `json:"value"`
+	Tag       string                 `json:"tag"`
+	Option    string                 `json:"option"`
+	FormValue map[string]interface{} `json:"form_value"`
+}
+
+// parseCardAction reads a schema 1.0 or v2 card callback
+func parseCardAction(body []byte, v2 bool) (*CardAction, error) {
+	var action CardAction
+	var raw rawCardAction
+
+	if v2 {
+		var req struct {
+			Header struct {
+				TenantKey string `json:"tenant_key"`
+			} `json:"header"`
+			Event struct {
+				Operator struct {
+					OpenID  string `json:"open_id"`
+					UserID  string `json:"user_id"`
+					UnionID string `json:"union_id"`
+				} `json:"operator"`
+				Token   string        `json:"token"`
+				Action  rawCardAction `json:"action"`
+				Context struct {
+					OpenMessageID string `json:"open_message_id"`
+					OpenChatID    string `json:"open_chat_id"`
+				} `json:"context"`
+			} `json:"event"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, fmt.Errorf("failed to parse card action: %w", err)
+		}
+		raw = req.Event.Action
+		action = CardAction{
+			OpenID:        req.Event.Operator.OpenID,
+			UserID:        req.Event.Operator.UserID,
+			UnionID:       req.Event.Operator.UnionID,
+			TenantKey:     req.Header.TenantKey,
+			OpenMessageID: req.Event.Context.OpenMessageID,
+			OpenChatID:    req.Event.Context.OpenChatID,
+			Token:         req.Event.Token,
+		}
+	} else {
+		var req struct {
+			OpenID        string        `json:"open_id"`
+			UserID        string        `json:"user_id"`
+			TenantKey     string        `json:"tenant_key"`
+			OpenMessageID string        `json:"open_message_id"`
+			OpenChatID    string        `json:"open_chat_id"`
+			Action        rawCardAction `json:"action"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, fmt.Errorf("failed to parse card action: %w", err)
+		}
+		raw = req.Action
+		action = CardAction{
+			OpenID:        req.OpenID,
+			UserID:        req.UserID,
+			TenantKey:     req.TenantKey,
+			OpenMessageID: req.OpenMessageID,
+			OpenChatID:    req.OpenChatID,
+		}
+	}
+
+	action.Tag = raw.Tag
+	action.Option = raw.Option
+	action.FormValue = raw.FormValue
+	action.Raw = body
+	action.Key, action.Data = splitActionValue(raw.Value)
+	return &action, nil
+}
+
+// splitActionValue reads an ActionValue. Values of cards not built by this package, without a
+// "data" object, are passed through whole as Data.
+func splitActionValue(value map[string]interface{}) (string, map[string]interface{}) {
+	key, _ := value["key"].(string)
+	if data, ok := value["data"].(map[string]interface{}); ok {
+		return key, data
+	}
+
+	var data map[string]interface{}
+	for k, v := range value {
+		if k == "key" {
+			continue
+		}
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+		data[k] = v
+	}
+	return key, data
+}
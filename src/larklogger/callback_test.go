@@ -0,0 +1,230 @@
+package larklogger
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// encryptInbound encrypts body the way Lark does for apps with an encrypt key
+func encryptInbound(t *testing.T, encryptKey string, body []byte) []byte {
+	t.Helper()
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	pad := aes.BlockSize - len(body)%aes.BlockSize
+	plain := append(append([]byte{}, body...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	out := make([]byte, aes.BlockSize+len(plain))
+	copy(out, "0123456789abcdef") // IV
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], plain)
+
+	data, _ := json.Marshal(map[string]string{"encrypt": base64.StdEncoding.EncodeToString(out)})
+	return data
+}
+
+// postInbound sends body to handler, signed now with sig when sig is not nil
+func postInbound(handler http.Handler, body []byte, sig func(timestamp, nonce string, body []byte) string) *httptest.ResponseRecorder {
+	return postInboundAt(handler, body, sig, time.Now())
+}
+
+// postInboundAt sends body to handler, signed with sig at the given time when sig is not nil
+func postInboundAt(handler http.Handler, body []byte, sig func(timestamp, nonce string, body []byte) string, at time.Time) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/lark/callback", bytes.NewReader(body))
+	if sig != nil {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		req.Header.Set(HeaderRequestTimestamp, timestamp)
+		req.Header.Set(HeaderRequestNonce, "nonce-1")
+		req.Header.Set(HeaderSignature, sig(timestamp, "nonce-1", body))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func sha256Signer(key string) func(string, string, []byte) string {
+	return func(timestamp, nonce string, body []byte) string {
+		return inboundSignature(sha256.New(), timestamp, nonce, key, body)
+	}
+}
+
+func sha1Signer(token string) func(string, string, []byte) string {
+	return func(timestamp, nonce string, body []byte) string {
+		return inboundSignature(sha1.New(), timestamp, nonce, token, body)
+	}
+}
+
+func TestCallbackVerification(t *testing.T) {
+	handler := NewCallbackHandler(InboundOptions{VerificationToken: "vtoken", EncryptKey: "ekey"})
+
+	t.Run("url_verification challenge", func(t *testing.T) {
+		body := []byte(`{"type":"url_verification","challenge":"ch-1","token":"vtoken"}`)
+		rec := postInbound(handler, body, sha256Signer("ekey"))
+		if rec.Code != http.StatusOK || !contains(rec.Body.String(), `"challenge":"ch-1"`) {
+			t.Errorf("Expected challenge echoed, got %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("encrypted challenge", func(t *testing.T) {
+		body := encryptInbound(t, "ekey", []byte(`{"type":"url_verification","challenge":"ch-2","token":"vtoken"}`))
+		rec := postInbound(handler, body, sha256Signer("ekey"))
+		if rec.Code != http.StatusOK || !contains(rec.Body.String(), `"challenge":"ch-2"`) {
+			t.Errorf("Expected challenge echoed, got %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("wrong token", func(t *testing.T) {
+		body := []byte(`{"type":"url_verification","challenge":"ch-1","token":"forged"}`)
+		if rec := postInbound(handler, body, sha256Signer("ekey")); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("wrong signature", func(t *testing.T) {
+		body := []byte(`{"type":"url_verification","challenge":"ch-1","token":"vtoken"}`)
+		if rec := postInbound(handler, body, sha256Signer("other")); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("unsigned plaintext with encrypt key", func(t *testing.T) {
+		handler := NewCallbackHandler(InboundOptions{EncryptKey: "ekey"})
+		handler.Handle("silence", func(ctx context.Context, action *CardAction) (*CallbackResponse, error) {
+			t.Error("Expected forged action not to be dispatched")
+			return nil, nil
+		})
+		body := []byte(`{"open_id":"ou_x","action":{"tag":"button","value":{"key":"silence"}}}`)
+		if rec := postInbound(handler, body, nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("stale timestamp", func(t *testing.T) {
+		body := []byte(`{"type":"url_verification","challenge":"ch-1","token":"vtoken"}`)
+		if rec := postInboundAt(handler, body, sha256Signer("ekey"), time.Now().Add(-time.Hour)); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+		if rec := postInboundAt(handler, body, sha256Signer("ekey"), time.Now().Add(-time.Minute)); rec.Code != http.StatusOK {
+			t.Errorf("Expected 200 within tolerance, got %d", rec.Code)
+		}
+	})
+
+	t.Run("wrong encrypt key", func(t *testing.T) {
+		body := encryptInbound(t, "other", []byte(`{"type":"url_verification","challenge":"ch-2","token":"vtoken"}`))
+		if rec := postInbound(handler, body, sha256Signer("ekey")); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("GET is rejected", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/lark/callback", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected 405, got %d", rec.Code)
+		}
+	})
+}
+
+func TestCallbackDispatch(t *testing.T) {
+	handler := NewCallbackHandler(InboundOptions{VerificationToken: "vtoken", EncryptKey: "ekey"})
+
+	var got *CardAction
+	handler.Handle("ack", func(ctx context.Context, action *CardAction) (*CallbackResponse, error) {
+		got = action
+		card := NewCardBuilder().SetHeader("Acknowledged by "+action.OpenID, ColorGreen).Build()
+		return &CallbackResponse{Toast: &Toast{Type: ToastSuccess, Content: "Acknowledged"}, Card: card}, nil
+	})
+	handler.Handle("retry", func(ctx context.Context, action *CardAction) (*CallbackResponse, error) {
+		return nil, errors.New("job already running")
+	})
+
+	t.Run("v2 encrypted callback", func(t *testing.T) {
+		plain := []byte(`{"schema":"2.0","header":{"event_id":"ev1","token":"vtoken","event_type":"card.action.trigger","tenant_key":"tk"},
+			"event":{"operator":{"open_id":"ou_1","user_id":"u_1"},"token":"c-123",
+			"action":{"tag":"button","value":{"key":"ack","data":{"incident":"INC-7"}}},
+			"context":{"open_message_id":"om_1","open_chat_id":"oc_1"}}}`)
+		rec := postInbound(handler, encryptInbound(t, "ekey", plain), sha256Signer("ekey"))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d %s", rec.Code, rec.Body.String())
+		}
+		if got == nil || got.Key != "ack" || got.Data["incident"] != "INC-7" || got.OpenID != "ou_1" ||
+			got.OpenMessageID != "om_1" || got.Token != "c-123" || got.TenantKey != "tk" {
+			t.Fatalf("Expected parsed action, got %+v", got)
+		}
+
+		var resp struct {
+			Toast Toast `json:"toast"`
+			Card  struct {
+				Type string   `json:"type"`
+				Data CardData `json:"data"`
+			} `json:"card"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		if resp.Toast.Type != ToastSuccess || resp.Card.Type != "raw" || resp.Card.Data.Header.Title.Content != "Acknowledged by ou_1" {
+			t.Errorf("Expected toast and raw card, got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("schema 1.0 callback", func(t *testing.T) {
+		body := []byte(`{"open_id":"ou_2","open_message_id":"om_2","token":"vtoken","action":{"tag":"button","value":{"key":"ack","incident":"INC-8"}}}`)
+		rec := postInbound(handler, body, sha1Signer("vtoken"))
+
+		if rec.Code != http.StatusOK || got.OpenID != "ou_2" || got.Data["incident"] != "INC-8" {
+			t.Fatalf("Expected legacy action to dispatch, got %d %+v", rec.Code, got)
+		}
+		var card CardData
+		_ = json.Unmarshal(rec.Body.Bytes(), &card)
+		if card.Header.Title.Content != "Acknowledged by ou_2" {
+			t.Errorf("Expected card body, got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("handler error and unknown key", func(t *testing.T) {
+		for key, want := range map[string]string{"retry": "job already running", "nope": `unknown action`} {
+			body := []byte(`{"open_id":"ou_3","token":"vtoken","action":{"tag":"button","value":{"key":"` + key + `"}}}`)
+			rec := postInbound(handler, body, sha1Signer("vtoken"))
+			if !contains(rec.Body.String(), `"type":"error"`) || !contains(rec.Body.String(), want) {
+				t.Errorf("Expected error toast %q for %s, got %s", want, key, rec.Body.String())
+			}
+		}
+	})
+
+	t.Run("default handler", func(t *testing.T) {
+		handler.HandleDefault(func(ctx context.Context, action *CardAction) (*CallbackResponse, error) {
+			return ToastResponse(ToastInfo, "fallback "+action.Key), nil
+		})
+		body := []byte(`{"open_id":"ou_3","token":"vtoken","action":{"tag":"button","value":{"key":"nope"}}}`)
+		if rec := postInbound(handler, body, sha1Signer("vtoken")); !contains(rec.Body.String(), "fallback nope") {
+			t.Errorf("Expected default handler toast, got %s", rec.Body.String())
+		}
+	})
+}
+
+func TestCallbackButton(t *testing.T) {
+	card := NewCardBuilder().AddButtons([]Button{
+		CallbackButton("Silence 1h", "silence", map[string]interface{}{"duration": "1h"}),
+		{Text: "Dashboard", URL: "https://example.com"},
+	}).Build()
+
+	actions := card.Card.Elements[0].Actions
+	if actions[0].Value == nil || actions[0].Value.Key != "silence" || actions[0].Value.Data["duration"] != "1h" || actions[0].URL != "" {
+		t.Errorf("Expected callback value on the first button, got %+v", actions[0])
+	}
+	if actions[1].Value != nil {
+		t.Errorf("Expected URL button without value, got %+v", actions[1].Value)
+	}
+}
@@ -3,6 +3,7 @@ package larklogger
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -13,6 +14,11 @@ import (
 type Card struct {
 	MsgType string   `json:"msg_type"`
 	Card    CardData `json:"card"`
+
+	// Layout state kept so an oversized card can be trimmed after Build
+	kvSections []kvSection
+	isMobile   bool
+	trim       *TrimReport
 }
 
 // CardData represents the card structure
@@ -22,6 +28,10 @@ type CardData struct {
 	Elements     []Element `json:"elements"`
 	CardLink     *CardLink `json:"card_link,omitempty"`
 	CornerRadius int       `json:"corner_radius,omitempty"`
+
+	// Template cards set Type to CardTypeTemplate and Data instead of the fields above
+	Type string        `json:"type,omitempty"`
+	Data *TemplateData `json:"data,omitempty"`
 }
 
 // Config represents card configuration
@@ -74,9 +84,10 @@ type Action struct {
 	Confirm *Confirm     `json:"confirm,omitempty"`
 }
 
-// ActionValue represents action value
+// ActionValue is the payload a callback button sends to the CallbackHandler
 type ActionValue struct {
-	Key string `json:"key,omitempty"`
+	Key  string                 `json:"key,omitempty"`  // Selects the registered CallbackFunc
+	Data map[string]interface{} `json:"data,omitempty"` // Passed to the handler as CardAction.Data
 }
 
 // Confirm represents confirmation dialog
@@ -91,6 +102,10 @@ type Button struct {
 	URL     string `json:"url,omitempty"`
 	Style   string `json:"style,omitempty"`
 	Confirm bool   `json:"confirm,omitempty"`
+
+	// Callback buttons call back into a CallbackHandler instead of opening URL
+	Key  string                 `json:"key,omitempty"`
+	Data map[string]interface{} `json:"data,omitempty"`
 }
 
 // Padding represents padding structure
@@ -203,11 +218,12 @@ func getKeyEmoji(key string) string {
 
 // KVItem represents a key-value item
 type KVItem struct {
-	Key   string // Original key
-	Value string // Processed value
+	Key      string // Original key
+	Value    string // Processed value
+	Priority int    // Rows with the lowest priority are dropped first when a card is too large
 }
 
-// mapToKVItems converts map to KV items
+// mapToKVItems converts map to KV items, sorted by key
 func mapToKVItems(data map[string]interface{}) []KVItem {
 	var items []KVItem
 
@@ -216,10 +232,12 @@ func mapToKVItems(data map[string]interface{}) []KVItem {
 			continue
 		}
 		items = append(items, KVItem{
-			Key:   k,
-			Value: formatValue(v),
+			Key:      k,
+			Value:    formatValue(v),
+			Priority: fieldPriority(k),
 		})
 	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
 
 	return items
 }
@@ -245,6 +263,10 @@ func formatValue(v interface{}) string {
 		valueStr = fmt.Sprintf("%t", val)
 	case time.Time:
 		valueStr = val.Format("2006-01-02 15:04:05")
+	case error:
+		valueStr = val.Error()
+	case fmt.Stringer:
+		valueStr = val.String()
 	default:
 		// Other types (slices, structs): JSON serialization
 		jsonBytes, err := json.Marshal(val)
@@ -286,16 +308,25 @@ func formatLongString(value string) string {
 func formatJSONString(jsonStr string) string {
 	// For mobile, truncate very long JSON and add length hint, no code fences
 	if len(jsonStr) > 200 {
-		truncated := jsonStr[:150]
-		if lastComma := strings.LastIndex(truncated, ","); lastComma > 50 {
-			truncated = jsonStr[:lastComma]
-		}
-		return truncated + "\n... (" + fmt.Sprintf("%d", len(jsonStr)) + " chars)"
+		return truncateWithHint(jsonStr, 150)
 	}
 	// For shorter JSON, return as-is (plain text)
 	return jsonStr
 }
 
+// truncateWithHint cuts s to about limit bytes, preferring a comma boundary for JSON, and appends its original length
+func truncateWithHint(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	truncated := s[:limit]
+	if lastComma := strings.LastIndex(truncated, ","); lastComma > limit/3 {
+		truncated = s[:lastComma]
+	}
+	truncated = strings.ToValidUTF8(truncated, "")
+	return truncated + "\n... (" + fmt.Sprintf("%d", len(s)) + " chars)"
+}
+
 // formatLongTextString formats long text strings
 func formatLongTextString(text string) string {
 	// Break long strings into multiple lines for mobile readability
@@ -385,6 +416,7 @@ func escapeMarkdown(content string) string {
 type CardBuilder struct {
 	card     *Card
 	isMobile bool // Flag for mobile optimization
+	maxBytes int  // Payload limit enforced by Build (0 = DefaultMaxPayloadBytes)
 }
 
 // NewCardBuilder creates a new card builder
@@ -531,8 +563,23 @@ func (cb *CardBuilder) AddSubtitle(subtitle string) *CardBuilder {
 	return cb
 }
 
-// AddTimestamp adds timestamp (right-aligned)
+// AddTimestamp adds the current time (right-aligned)
 func (cb *CardBuilder) AddTimestamp() *CardBuilder {
+	return cb.AddTimestampAt(time.Now())
+}
+
+// AddTimestampAt adds the given time (right-aligned), e.g. when the logged event happened
+func (cb *CardBuilder) AddTimestampAt(t time.Time) *CardBuilder {
+	return cb.addTimeRow(fmt.Sprintf("%s %s", EmojiTime, FormatTimestamp(t)))
+}
+
+// AddResolvedAt adds the time an alert was resolved (right-aligned), below its original timestamp
+func (cb *CardBuilder) AddResolvedAt(t time.Time) *CardBuilder {
+	return cb.addTimeRow(fmt.Sprintf("%s Resolved %s", EmojiResolved, FormatTimestamp(t)))
+}
+
+// addTimeRow adds a grey, right-aligned line such as a timestamp
+func (cb *CardBuilder) addTimeRow(content string) *CardBuilder {
 	// Use mobile-optimized padding if mobile flag is set
 	padding := &Padding{
 		Top:    0,
@@ -559,7 +606,7 @@ func (cb *CardBuilder) AddTimestamp() *CardBuilder {
 		Tag: "div",
 		Text: &Text{
 			Tag:        "lark_md",
-			Content:    fmt.Sprintf("<font color=\"grey\">%s %s</font>", EmojiTime, time.Now().Format("2006-01-02 15:04:05")),
+			Content:    fmt.Sprintf("<font color=\"grey\">%s</font>", content),
 			LineHeight: lineHeight,
 		},
 		Padding:   padding,
@@ -576,6 +623,8 @@ func (cb *CardBuilder) AddDivider() *CardBuilder {
 
 // AddKVTable adds professional KV table with alternating colors
 func (cb *CardBuilder) AddKVTable(kvList []KVItem) *CardBuilder {
+	defer cb.trackKVSection(len(cb.card.Card.Elements), kvList, "")
+
 	// Add section title with emoji, bold formatting and center alignment
 	cb.card.Card.Elements = append(cb.card.Card.Elements, Element{
 		Tag: "div",
@@ -789,6 +838,8 @@ func (cb *CardBuilder) AddConfigGrid(configData map[string]string) *CardBuilder
 
 // AddKVTableWithStyle adds a key-value table with custom background style
 func (cb *CardBuilder) AddKVTableWithStyle(kvList []KVItem, bgStyle string) *CardBuilder {
+	defer cb.trackKVSection(len(cb.card.Card.Elements), kvList, bgStyle)
+
 	// Add table header
 	headerColumns := []Column{
 		{
@@ -981,6 +1032,10 @@ func (cb *CardBuilder) AddButtons(buttons []Button) *CardBuilder {
 			URL:  button.URL,
 		}
 
+		if button.Key != "" {
+			action.Value = &ActionValue{Key: button.Key, Data: button.Data}
+		}
+
 		// Set button style with enhanced colors for confirm actions
 		if button.Style != "" {
 			action.Type = button.Style
@@ -1026,8 +1081,20 @@ func (cb *CardBuilder) AddButton(text, url string, style ...string) *CardBuilder
 	return cb.AddButtons([]Button{button})
 }
 
-// Build builds the card
+// Build builds the card, trimming it to the payload limit if needed (see TrimReport)
 func (cb *CardBuilder) Build() *Card {
+	card := cb.build()
+	maxBytes := cb.maxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxPayloadBytes
+	}
+	card.trim, _ = card.fit(maxBytes)
+	return card
+}
+
+// build returns the card untrimmed, leaving the payload limit to the client that sends it
+func (cb *CardBuilder) build() *Card {
+	cb.card.isMobile = cb.isMobile
 	return cb.card
 }
 
@@ -1,29 +1,63 @@
 package larklogger
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
 // LarkClient handles communication with Lark webhook
 type LarkClient struct {
+	transport
 	webhookURL string
-	httpClient *http.Client
-	opts       *ClientOptions
+	endpoints  *endpointSet    // Primary webhook followed by failover URLs
+	spool      *spool          // nil unless WithSpool is used
+	breaker    *circuitBreaker // nil unless WithCircuitBreaker is used
+	done       chan struct{}   // Closed by Close to stop background replay
+	replayNow  chan struct{}   // Wakes the replay loop before its next tick
+	closeOnce  sync.Once
 }
 
 // ClientOptions holds client configuration options
 type ClientOptions struct {
-	Timeout    time.Duration
-	RetryCount int
-	RetryDelay time.Duration
-	UserAgent  string
-	Headers    map[string]string
+	Timeout     time.Duration
+	RetryCount  int
+	RetryDelay  time.Duration
+	RetryPolicy RetryPolicy // Overrides RetryCount/RetryDelay when set
+	UserAgent   string
+	Headers     map[string]string
+	Secret      string
+
+	RateLimitPerSecond int                      // Client-side quota per second (0 = unlimited)
+	RateLimitPerMinute int                      // Client-side quota per minute (0 = unlimited)
+	RateLimitPolicy    RateLimitPolicy          // Block or fail fast when the quota is used up
+	RateLimitObserver  func(wait time.Duration) // Called with the wait of every rate-limited send
+
+	Spool *SpoolOptions // Durable outbox for undeliverable messages (nil = disabled)
+
+	FailoverURLs          []string                    // Backup webhooks tried in order after the primary
+	EndpointRetryInterval time.Duration               // How long a dead endpoint is skipped before being retried
+	OnEndpointDead        func(url string, err error) // Called when an endpoint is marked dead
+
+	CircuitBreaker *CircuitBreakerOptions // Short-circuits sends while Lark is degraded (nil = disabled)
+
+	BaseURL string // Open API base URL used by AppClient
+
+	MaxPayloadBytes int              // Cards larger than this are trimmed (default DefaultMaxPayloadBytes)
+	TrimObserver    func(TrimReport) // Called whenever a card had to be trimmed to send it
+
+	Instrumentation Instrumentation // Hooks around every delivery attempt (nil = disabled)
+
+	HTTPClient *http.Client      // Used as-is when set; Timeout, Transport and ProxyURL are then ignored
+	Transport  http.RoundTripper // Custom RoundTripper (mTLS, custom DNS, instrumentation...)
+	ProxyURL   string            // Egress proxy, e.g. "http://proxy.corp:3128"
+
+	TestMode *bool     // Capture messages instead of sending them (nil = follow LARK_TEST_MODE)
+	Recorder *Recorder // Where test mode captures messages (nil = DefaultRecorder())
 }
 
 // ClientOption is a function that configures the client
@@ -58,45 +92,104 @@ func WithHeaders(headers map[string]string) ClientOption {
 	}
 }
 
+// WithSecret sets the signing secret for bots with signature verification enabled
+func WithSecret(secret string) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.Secret = secret
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for every request
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.HTTPClient = client
+	}
+}
+
+// WithTransport sets the RoundTripper of the HTTP client
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.Transport = transport
+	}
+}
+
+// WithProxy sends requests through an HTTP(S) proxy
+func WithProxy(proxyURL string) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.ProxyURL = proxyURL
+	}
+}
+
+// WithMaxPayloadBytes sets the size above which cards are trimmed before sending
+func WithMaxPayloadBytes(maxBytes int) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.MaxPayloadBytes = maxBytes
+	}
+}
+
+// WithTrimObserver registers a callback invoked with what was trimmed each time an oversized card is
+// sent; the caller's card is left as it was
+func WithTrimObserver(observer func(TrimReport)) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.TrimObserver = observer
+	}
+}
+
 // NewLarkClient creates a new Lark client
 func NewLarkClient(webhookURL string, opts ...ClientOption) *LarkClient {
-	options := &ClientOptions{
-		Timeout:    30 * time.Second,
-		RetryCount: 3,
-		RetryDelay: 1 * time.Second,
-		UserAgent:  "larklogger-go/1.0.0",
-		Headers:    make(map[string]string),
+	options := newClientOptions(opts...)
+
+	client := &LarkClient{
+		transport:  newTransport(options),
+		webhookURL: webhookURL,
+		endpoints: newEndpointSet(append([]string{webhookURL}, options.FailoverURLs...),
+			options.EndpointRetryInterval, options.OnEndpointDead),
+		done:      make(chan struct{}),
+		replayNow: make(chan struct{}, 1),
 	}
+	client.recorder = recorderFor(options, webhookURL)
 
-	for _, opt := range opts {
-		opt(options)
+	if options.CircuitBreaker != nil {
+		client.breaker = newCircuitBreaker(*options.CircuitBreaker)
 	}
 
-	return &LarkClient{
-		webhookURL: webhookURL,
-		httpClient: &http.Client{
-			Timeout: options.Timeout,
-		},
-		opts: options,
+	if options.Spool != nil {
+		sp, err := openSpool(*options.Spool)
+		if err != nil {
+			// Keep working without durability rather than failing construction
+			fmt.Printf("Failed to open Lark spool, continuing without it: %v\n", err)
+		} else {
+			client.spool = sp
+			go client.replayLoop(sp.opts.ReplayInterval)
+		}
 	}
+
+	return client
+}
+
+// Close stops background work and releases the spool; the client must not be used afterwards
+func (c *LarkClient) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.spool != nil {
+			err = c.spool.close()
+		}
+	})
+	return err
 }
 
 // SendCard sends a card to the Lark webhook
 func (c *LarkClient) SendCard(card *Card) error {
-	jsonData, err := json.Marshal(card)
-	if err != nil {
-		return fmt.Errorf("failed to marshal card: %w", err)
-	}
-
-	return c.sendWithRetryCtx(context.Background(), jsonData)
+	return c.SendCardCtx(context.Background(), card)
 }
 
-// SendText sends a simple text message to the Lark webhook
-func (c *LarkClient) SendText(text string) error {
+// SendText sends a simple text message to the Lark webhook, @mentioning any given users
+func (c *LarkClient) SendText(text string, mentions ...Mention) error {
 	payload := map[string]interface{}{
 		"msg_type": "text",
 		"content": map[string]string{
-			"text": text,
+			"text": withMentionText(text, mentions),
 		},
 	}
 
@@ -105,96 +198,243 @@ func (c *LarkClient) SendText(text string) error {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	return c.sendWithRetryCtx(context.Background(), jsonData)
+	return c.deliver(context.Background(), jsonData)
 }
 
-// SendCardCtx sends a card with a context to control request lifecycle
+// SendCardCtx sends a card with a context to control request lifecycle; oversized cards are
+// trimmed to the payload limit, or sent as text as a last resort
 func (c *LarkClient) SendCardCtx(ctx context.Context, card *Card) error {
-	jsonData, err := json.Marshal(card)
+	fitted, text := c.fitCard(card)
+	if fitted == nil {
+		return c.SendTextCtx(ctx, text)
+	}
+	jsonData, err := json.Marshal(fitted)
 	if err != nil {
 		return fmt.Errorf("failed to marshal card: %w", err)
 	}
-	return c.sendWithRetryCtx(ctx, jsonData)
+	return c.deliver(ctx, jsonData)
 }
 
 // SendTextCtx sends a text message with a context
-func (c *LarkClient) SendTextCtx(ctx context.Context, text string) error {
+func (c *LarkClient) SendTextCtx(ctx context.Context, text string, mentions ...Mention) error {
 	payload := map[string]interface{}{
 		"msg_type": "text",
 		"content": map[string]string{
-			"text": text,
+			"text": withMentionText(text, mentions),
 		},
 	}
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
-	return c.sendWithRetryCtx(ctx, jsonData)
+	return c.deliver(ctx, jsonData)
 }
 
-// sendWithRetry sends the request with retry logic
-func (c *LarkClient) sendWithRetryCtx(ctx context.Context, data []byte) error {
-	var lastErr error
+// SendPost sends a rich-text post to the Lark webhook
+func (c *LarkClient) SendPost(post *Post) error {
+	return c.SendPostCtx(context.Background(), post)
+}
 
-	for i := 0; i <= c.opts.RetryCount; i++ {
-		if i > 0 {
-			time.Sleep(c.opts.RetryDelay)
-		}
+// SendPostCtx sends a rich-text post with a context
+func (c *LarkClient) SendPostCtx(ctx context.Context, post *Post) error {
+	jsonData, err := json.Marshal(post)
+	if err != nil {
+		return fmt.Errorf("failed to marshal post: %w", err)
+	}
+	return c.deliver(ctx, jsonData)
+}
 
-		err := c.sendRequestCtx(ctx, data)
-		if err == nil {
-			return nil
+// RateLimitStats returns a snapshot of the client-side rate limiter activity
+func (c *LarkClient) RateLimitStats() RateLimitStats {
+	return c.limiter.Stats()
+}
+
+// deliver sends a payload, falling back to the spool when Lark cannot be reached
+func (c *LarkClient) deliver(ctx context.Context, data []byte) error {
+	if c.recorder != nil {
+		return c.recorder.recordWebhook(data)
+	}
+	if c.spool == nil {
+		return c.sendGuardedCtx(ctx, data, true)
+	}
+
+	// Queue behind the existing backlog so messages stay in order, and try to drain it right away
+	// instead of waiting for the next replay tick
+	if c.spool.pending() > 0 {
+		if err := c.spool.append(data); err != nil {
+			return err
 		}
+		c.kickReplay()
+		return ErrSpooled
+	}
 
-		lastErr = err
+	// The spool keeps messages rejected by an open breaker, so they are not diverted as well
+	err := c.sendGuardedCtx(ctx, data, false)
+	if err == nil {
+		// Lark is reachable: deliver anything spooled concurrently without waiting for the ticker
+		if c.spool.pending() > 0 {
+			c.kickReplay()
+		}
+		return nil
+	}
+	if !shouldSpool(err) {
+		return err
 	}
 
-	return fmt.Errorf("failed to send message after %d retries: %w", c.opts.RetryCount, lastErr)
+	if spoolErr := c.spool.append(data); spoolErr != nil {
+		if errors.Is(err, ErrCircuitOpen) {
+			c.breaker.divert(ctx, data)
+		}
+		return fmt.Errorf("%w (spool failed: %v)", err, spoolErr)
+	}
+	return fmt.Errorf("%w: %w", ErrSpooled, err)
 }
 
-// sendRequestCtx sends a single HTTP request with context
-func (c *LarkClient) sendRequestCtx(ctx context.Context, data []byte) error {
-	req, err := http.NewRequestWithContext(ctx, "POST", c.webhookURL, bytes.NewBuffer(data))
+// sendGuardedCtx sends through the circuit breaker; while it is open the payload is handed to the
+// fallback sink when divert is set
+func (c *LarkClient) sendGuardedCtx(ctx context.Context, data []byte, divert bool) error {
+	if c.breaker == nil {
+		return c.sendWithRetryCtx(ctx, data)
+	}
+
+	record, err := c.breaker.allow()
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		c.reportUnsent(ctx, c.webhookURL, err)
+		if divert {
+			c.breaker.divert(ctx, data)
+		}
+		return err
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", c.opts.UserAgent)
+	err = c.sendWithRetryCtx(ctx, data)
+	record(err)
+	return err
+}
 
-	for key, value := range c.opts.Headers {
-		req.Header.Set(key, value)
+// CircuitState returns the circuit breaker state; always closed when the breaker is disabled
+func (c *LarkClient) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
 	}
+	return c.breaker.currentState()
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+// ReplaySpool delivers spooled messages in order until the backlog is empty or a send fails
+func (c *LarkClient) ReplaySpool(ctx context.Context) error {
+	if c.spool == nil {
+		return nil
 	}
-	defer resp.Body.Close()
+	return c.spool.replay(ctx, c.sendOnceCtx)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+// SpoolStats returns backlog statistics of the spool; all zero when disabled
+func (c *LarkClient) SpoolStats() SpoolStats {
+	if c.spool == nil {
+		return SpoolStats{}
+	}
+	return c.spool.snapshot()
+}
+
+// kickReplay wakes the replay loop without blocking; pending wake-ups are coalesced
+func (c *LarkClient) kickReplay() {
+	select {
+	case c.replayNow <- struct{}{}:
+	default:
 	}
+}
+
+// replayLoop replays the spool on startup, whenever deliver wakes it and periodically while it has a backlog
+func (c *LarkClient) replayLoop(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-c.done
+		cancel()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if c.spool.pending() > 0 {
+			_ = c.ReplaySpool(ctx)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		case <-c.replayNow:
+		}
 	}
+}
 
-	// Parse response to check for errors
-	var response map[string]interface{}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+// sendOnceCtx makes a single rate-limited, signed attempt per endpoint; replay relies on the ticker instead of retries
+func (c *LarkClient) sendOnceCtx(ctx context.Context, data []byte) (err error) {
+	if c.breaker != nil {
+		record, allowErr := c.breaker.allow()
+		if allowErr != nil {
+			c.reportUnsent(ctx, c.webhookURL, allowErr)
+			return allowErr
+		}
+		defer func() { record(err) }()
 	}
 
-	if code, ok := response["code"].(float64); ok && code != 0 {
-		msg := "unknown error"
-		if message, ok := response["msg"].(string); ok {
-			msg = message
+	for _, candidate := range c.endpoints.candidates() {
+		if err = c.sendToEndpointCtx(ctx, candidate.url, data, true); err == nil {
+			c.endpoints.markHealthy(candidate.url)
+			return nil
+		}
+		if !shouldFailover(ctx, err) {
+			return err
 		}
-		return fmt.Errorf("lark API error (code: %.0f): %s", code, msg)
+		c.endpoints.markFailed(candidate.url, err)
 	}
+	return err
+}
 
-	return nil
+// Endpoints returns the health of the primary and failover webhooks
+func (c *LarkClient) Endpoints() []EndpointStatus {
+	return c.endpoints.status()
+}
+
+// sendWithRetryCtx sends the request to the first endpoint that accepts it, failing over
+// to the next one on non-retryable or persistent failures. Dead endpoints being probed get a
+// single attempt, so a send only pays the retry back-off on an endpoint believed healthy.
+func (c *LarkClient) sendWithRetryCtx(ctx context.Context, data []byte) error {
+	var err error
+	for _, candidate := range c.endpoints.candidates() {
+		if err = c.sendToEndpointCtx(ctx, candidate.url, data, candidate.probe); err == nil {
+			c.endpoints.markHealthy(candidate.url)
+			return nil
+		}
+		if !shouldFailover(ctx, err) {
+			return err
+		}
+		c.endpoints.markFailed(candidate.url, err)
+	}
+	return err
+}
+
+// sendToEndpointCtx sends the request to one endpoint, retrying according to the client's
+// RetryPolicy unless once is set
+func (c *LarkClient) sendToEndpointCtx(ctx context.Context, url string, data []byte, once bool) error {
+	attempt := func(ctx context.Context) error {
+		// Sign on every attempt so the timestamp never goes stale
+		payload, err := c.signPayload(data)
+		if err != nil {
+			return err
+		}
+		return c.sendRequestCtx(ctx, url, payload)
+	}
+	if once {
+		return c.attemptOnceCtx(ctx, url, attempt)
+	}
+	return c.retryCtx(ctx, url, attempt)
+}
+
+// sendRequestCtx sends a single HTTP request with context
+func (c *LarkClient) sendRequestCtx(ctx context.Context, url string, data []byte) error {
+	_, err := c.postJSONCtx(ctx, url, nil, data)
+	return err
 }
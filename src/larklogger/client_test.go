@@ -3,9 +3,10 @@ package larklogger
 import (
 	"encoding/json"
 	"net/http"
-	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
 )
 
 func TestNewLarkClient(t *testing.T) {
@@ -27,6 +28,11 @@ func TestNewLarkClient(t *testing.T) {
 		if client.opts.UserAgent != "larklogger-go/1.0.0" {
 			t.Errorf("Expected user agent %s, got %s", "larklogger-go/1.0.0", client.opts.UserAgent)
 		}
+
+		if client.opts.RateLimitPerSecond != DefaultRateLimitPerSecond || client.opts.RateLimitPerMinute != DefaultRateLimitPerMinute {
+			t.Errorf("Expected default rate limit %d/s %d/min, got %d/s %d/min",
+				DefaultRateLimitPerSecond, DefaultRateLimitPerMinute, client.opts.RateLimitPerSecond, client.opts.RateLimitPerMinute)
+		}
 	})
 
 	t.Run("with custom options", func(t *testing.T) {
@@ -60,131 +66,115 @@ func TestNewLarkClient(t *testing.T) {
 }
 
 func TestLarkClientSendText(t *testing.T) {
-	// Create a test server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify request method
-		if r.Method != "POST" {
-			t.Errorf("Expected POST method, got %s", r.Method)
-		}
-
-		// Verify content type
-		if r.Header.Get("Content-Type") != "application/json" {
-			t.Errorf("Expected Content-Type application/json, got %s", r.Header.Get("Content-Type"))
-		}
-
-		// Verify user agent
-		if r.Header.Get("User-Agent") != "larklogger-go/1.0.0" {
-			t.Errorf("Expected User-Agent larklogger-go/1.0.0, got %s", r.Header.Get("User-Agent"))
-		}
+	srv := fakelark.NewServer(t)
 
-		// Parse request body
-		var payload map[string]interface{}
-		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-			t.Errorf("Failed to decode request body: %v", err)
-			return
-		}
+	client := NewLarkClient(srv.WebhookURL())
+	if err := client.SendText("Hello, Lark!"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
 
-		// Verify payload structure
-		if payload["msg_type"] != "text" {
-			t.Errorf("Expected msg_type 'text', got %v", payload["msg_type"])
-		}
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("Expected 1 message, got %d (rejections: %+v)", len(msgs), srv.Rejections())
+	}
+	if msgs[0].MsgType != "text" || msgs[0].Text != "Hello, Lark!" {
+		t.Errorf("Expected text 'Hello, Lark!', got %s %q", msgs[0].MsgType, msgs[0].Text)
+	}
+	if got := msgs[0].Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", got)
+	}
+	if got := msgs[0].Header.Get("User-Agent"); got != "larklogger-go/1.0.0" {
+		t.Errorf("Expected User-Agent larklogger-go/1.0.0, got %s", got)
+	}
+}
 
-		content, ok := payload["content"].(map[string]interface{})
-		if !ok {
-			t.Errorf("Expected content to be a map")
-			return
-		}
+func TestLarkClientSendCard(t *testing.T) {
+	srv := fakelark.NewServer(t)
 
-		if content["text"] != "Hello, Lark!" {
-			t.Errorf("Expected text 'Hello, Lark!', got %v", content["text"])
-		}
+	client := NewLarkClient(srv.WebhookURL())
+	card := NewCardBuilder().
+		SetHeader("Test Card", "blue").
+		AddSection("Test content").
+		Build()
+	if err := client.SendCard(card); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
 
-		// Send success response
-		response := map[string]interface{}{
-			"code": 0,
-			"msg":  "success",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(response)
-	}))
-	defer server.Close()
+	msgs := srv.Messages()
+	if len(msgs) != 1 || msgs[0].MsgType != "interactive" {
+		t.Fatalf("Expected 1 interactive message, got %+v (rejections: %+v)", msgs, srv.Rejections())
+	}
+	var data CardData
+	if err := json.Unmarshal(msgs[0].Card, &data); err != nil {
+		t.Fatalf("Expected a card object, got error: %v", err)
+	}
+	if data.Header.Title.Content != "Test Card" || len(data.Elements) == 0 {
+		t.Errorf("Expected the card header and elements, got %+v", data)
+	}
+}
 
-	// Create client and send message
-	client := NewLarkClient(server.URL)
-	err := client.SendText("Hello, Lark!")
+func TestLarkClientSignedPayload(t *testing.T) {
+	secret := "test-secret"
+	srv := fakelark.NewServer(t, fakelark.WithSecret(secret))
 
-	if err != nil {
+	client := NewLarkClient(srv.WebhookURL(), WithSecret(secret))
+	if err := client.SendText("signed text"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	card := NewCardBuilder().SetHeader("Signed Card", "blue").Build()
+	if err := client.SendCard(card); err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-}
 
-func TestLarkClientSendCard(t *testing.T) {
-	// Create a test server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Parse request body
-		var payload map[string]interface{}
-		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-			t.Errorf("Failed to decode request body: %v", err)
-			return
-		}
+	msgs := srv.Messages()
+	if len(msgs) != 2 || !msgs[0].Signed || !msgs[1].Signed {
+		t.Errorf("Expected 2 signed messages, got %+v (rejections: %+v)", msgs, srv.Rejections())
+	}
 
-		// Verify payload structure
-		if payload["msg_type"] != "interactive" {
-			t.Errorf("Expected msg_type 'interactive', got %v", payload["msg_type"])
+	t.Run("wrong secret is rejected", func(t *testing.T) {
+		srv.Reset()
+		client := NewLarkClient(srv.WebhookURL(), WithSecret("wrong"), WithRetry(0, 0))
+		if err := client.SendText("forged"); err == nil {
+			t.Error("Expected error, got nil")
 		}
-
-		card, ok := payload["card"].(map[string]interface{})
-		if !ok {
-			t.Errorf("Expected card to be a map")
-			return
+		if len(srv.Rejections()) != 1 || len(srv.Messages()) != 0 {
+			t.Errorf("Expected the payload rejected, got %+v", srv.Rejections())
 		}
+	})
+}
 
-		// Verify card has required fields
-		if card["elements"] == nil {
-			t.Errorf("Expected card to have elements")
+func TestGenSign(t *testing.T) {
+	// Fixed signatures for known inputs, computed outside this package with
+	// base64(HmacSHA256(key = timestamp + "\n" + secret, message = "")) as in Lark's custom bot docs
+	cases := []struct {
+		secret    string
+		timestamp int64
+		want      string
+	}{
+		{"demo", 1599360473, "l1N0gAcBjdwBvGm1xMjOF0XSyaLRpR7tuO5dHfhAYc8="},
+		{"test-secret", 1700000000, "mbm4Y4oluIPQ00qlBIhX8vAZ0EKv3nw0LuTb91jPL84="},
+	}
+	for _, tc := range cases {
+		sign, err := genSign(tc.secret, tc.timestamp)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
 		}
-
-		// Send success response
-		response := map[string]interface{}{
-			"code": 0,
-			"msg":  "success",
+		if sign != tc.want {
+			t.Errorf("Expected sign %s for %d/%s, got %s", tc.want, tc.timestamp, tc.secret, sign)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(response)
-	}))
-	defer server.Close()
-
-	// Create client and send card
-	client := NewLarkClient(server.URL)
-	card := NewCardBuilder().
-		SetHeader("Test Card", "blue").
-		AddSection("Test content").
-		Build()
-
-	err := client.SendCard(card)
-
-	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
 	}
 }
 
 func TestLarkClientErrorHandling(t *testing.T) {
 	t.Run("server returns error", func(t *testing.T) {
-		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			response := map[string]interface{}{
-				"code": 1,
-				"msg":  "invalid webhook",
-			}
-			w.Header().Set("Content-Type", "application/json")
-			_ = json.NewEncoder(w).Encode(response)
-		}))
-		defer server.Close()
-
-		client := NewLarkClient(server.URL)
+		srv := fakelark.NewServer(t)
+		srv.Script(fakelark.Reject(1, "invalid webhook"))
+
+		client := NewLarkClient(srv.WebhookURL())
 		err := client.SendText("test")
 
 		if err == nil {
-			t.Errorf("Expected error, got nil")
+			t.Fatalf("Expected error, got nil")
 		}
 
 		if !contains(err.Error(), "lark API error") {
@@ -193,17 +183,14 @@ func TestLarkClientErrorHandling(t *testing.T) {
 	})
 
 	t.Run("server returns non-200 status", func(t *testing.T) {
-		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte("Bad Request"))
-		}))
-		defer server.Close()
+		srv := fakelark.NewServer(t)
+		srv.Script(fakelark.Response{Status: http.StatusBadRequest, Body: "Bad Request"})
 
-		client := NewLarkClient(server.URL)
+		client := NewLarkClient(srv.WebhookURL())
 		err := client.SendText("test")
 
 		if err == nil {
-			t.Errorf("Expected error, got nil")
+			t.Fatalf("Expected error, got nil")
 		}
 
 		if !contains(err.Error(), "status 400") {
@@ -248,4 +235,12 @@ func TestClientOptions(t *testing.T) {
 			t.Errorf("Expected header value 'value', got %s", opts.Headers["X-Test"])
 		}
 	})
+
+	t.Run("WithSecret", func(t *testing.T) {
+		opts := &ClientOptions{}
+		WithSecret("s3cr3t")(opts)
+		if opts.Secret != "s3cr3t" {
+			t.Errorf("Expected secret 's3cr3t', got %s", opts.Secret)
+		}
+	})
 }
@@ -7,34 +7,74 @@ import (
 
 // EnvConfig holds environment-based configuration
 type EnvConfig struct {
-	WebhookURL string
-	IsTestMode bool
+	WebhookURL    string
+	WebhookSecret string
+	AppID         string
+	AppSecret     string
+	IsTestMode    bool
+
+	VerificationToken string
+	EncryptKey        string
 }
 
 // GetConfig returns configuration based on environment variables
 func GetConfig() *EnvConfig {
 	webhookURL := os.Getenv("LARK_WEBHOOK_URL")
-	isTestMode := strings.ToLower(os.Getenv("LARK_TEST_MODE")) == "true"
+	webhookSecret := os.Getenv("LARK_WEBHOOK_SECRET")
+	appID := os.Getenv("LARK_APP_ID")
+	appSecret := os.Getenv("LARK_APP_SECRET")
+	verificationToken := os.Getenv("LARK_VERIFICATION_TOKEN")
+	encryptKey := os.Getenv("LARK_ENCRYPT_KEY")
+	isTestMode := testModeFromEnv()
 
 	// If no webhook URL is provided, use a test URL
 	if webhookURL == "" {
-		webhookURL = "https://test.webhook.url"
+		webhookURL = TestWebhookURL
 		isTestMode = true
 	}
 
 	return &EnvConfig{
-		WebhookURL: webhookURL,
-		IsTestMode: isTestMode,
+		WebhookURL:    webhookURL,
+		WebhookSecret: webhookSecret,
+		AppID:         appID,
+		AppSecret:     appSecret,
+		IsTestMode:    isTestMode,
+
+		VerificationToken: verificationToken,
+		EncryptKey:        encryptKey,
 	}
 }
 
+// testModeFromEnv reports whether LARK_TEST_MODE asks clients to capture messages instead of sending them
+func testModeFromEnv() bool {
+	return strings.ToLower(os.Getenv("LARK_TEST_MODE")) == "true"
+}
+
 // GetWebhookURL returns the webhook URL from environment or default test URL
 func GetWebhookURL() string {
 	config := GetConfig()
 	return config.WebhookURL
 }
 
-// IsTestEnvironment returns true if running in test mode
+// GetWebhookSecret returns the webhook signing secret from environment, if any
+func GetWebhookSecret() string {
+	config := GetConfig()
+	return config.WebhookSecret
+}
+
+// GetAppCredentials returns the app_id and app_secret for AppClient from environment, if any
+func GetAppCredentials() (appID, appSecret string) {
+	config := GetConfig()
+	return config.AppID, config.AppSecret
+}
+
+// GetInboundOptions returns the verification token and encrypt key for callbacks and events from environment
+func GetInboundOptions() InboundOptions {
+	config := GetConfig()
+	return InboundOptions{VerificationToken: config.VerificationToken, EncryptKey: config.EncryptKey}
+}
+
+// IsTestEnvironment returns true if LARK_TEST_MODE is set or LARK_WEBHOOK_URL is unset
 func IsTestEnvironment() bool {
 	config := GetConfig()
 	return config.IsTestMode
@@ -23,12 +23,13 @@ const (
 
 // Emoji constants
 const (
-	EmojiInfo    = "ℹ️"
-	EmojiWarn    = "⚠️"
-	EmojiError   = "❌"
-	EmojiDefault = "📋"
-	EmojiTime    = "⏰"
-	EmojiConfig  = "⚙️"
+	EmojiInfo     = "ℹ️"
+	EmojiWarn     = "⚠️"
+	EmojiError    = "❌"
+	EmojiDefault  = "📋"
+	EmojiTime     = "⏰"
+	EmojiConfig   = "⚙️"
+	EmojiResolved = "✅"
 )
 
 // Color constants
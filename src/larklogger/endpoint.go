@@ -0,0 +1,227 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CodeBadRequest is the Lark code for a malformed payload; failing over cannot fix it
+const CodeBadRequest = 9499
+
+// EndpointStatus is a snapshot of one webhook endpoint's health
+type EndpointStatus struct {
+	URL            string
+	Healthy        bool
+	Failures       int       // Consecutive failed sends
+	LastError      error     // Error that marked the endpoint dead, if any
+	DeadSince      time.Time // When the endpoint was marked dead (zero if healthy)
+	RetryAt        time.Time // When a dead endpoint becomes eligible for sends again
+	ThrottledUntil time.Time // When a rate-limited endpoint becomes eligible for sends again
+}
+
+// endpoint tracks the health of one webhook URL
+type endpoint struct {
+	url            string
+	healthy        bool
+	failures       int
+	lastError      error
+	deadSince      time.Time
+	retryAt        time.Time
+	throttledUntil time.Time
+}
+
+// endpointSet is the ordered list of webhook URLs used for failover
+type endpointSet struct {
+	mu            sync.Mutex
+	endpoints     []*endpoint
+	retryInterval time.Duration
+	onDead        func(url string, err error)
+}
+
+func newEndpointSet(urls []string, retryInterval time.Duration, onDead func(url string, err error)) *endpointSet {
+	if retryInterval <= 0 {
+		retryInterval = time.Minute
+	}
+	set := &endpointSet{retryInterval: retryInterval, onDead: onDead}
+	for _, url := range urls {
+		set.endpoints = append(set.endpoints, &endpoint{url: url, healthy: true})
+	}
+	return set
+}
+
+// candidate is an endpoint to try for one send
+type candidate struct {
+	url   string
+	probe bool // A dead endpoint given a single attempt, without the RetryPolicy
+}
+
+// candidates returns the endpoints to try, in order. Dead endpoints are only included once their
+// retry is due and throttled ones once their back-off has passed, unless that leaves nothing,
+// in which case all are tried. While a healthy endpoint remains to fall back on, a dead one is
+// probed with a single attempt, so its recovery never costs a send the retry back-off.
+func (s *endpointSet) candidates() []candidate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var picked []candidate
+	fallback := false
+	for _, ep := range s.endpoints {
+		if now.Before(ep.throttledUntil) {
+			continue
+		}
+		if ep.healthy {
+			fallback = true
+			picked = append(picked, candidate{url: ep.url})
+		} else if !now.Before(ep.retryAt) {
+			picked = append(picked, candidate{url: ep.url, probe: true})
+		}
+	}
+	if len(picked) == 0 {
+		for _, ep := range s.endpoints {
+			picked = append(picked, candidate{url: ep.url})
+		}
+	}
+	if !fallback {
+		// Nothing healthy to fall back on: give every endpoint the full RetryPolicy
+		for i := range picked {
+			picked[i].probe = false
+		}
+	}
+	return picked
+}
+
+// markHealthy records a successful send or probe, recovering a dead endpoint
+func (s *endpointSet) markHealthy(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ep := s.find(url); ep != nil {
+		ep.healthy = true
+		ep.failures = 0
+		ep.lastError = nil
+		ep.deadSince = time.Time{}
+		ep.retryAt = time.Time{}
+		ep.throttledUntil = time.Time{}
+	}
+}
+
+// markFailed records a failed send: rate limiting only backs the endpoint off, anything else marks it dead
+func (s *endpointSet) markFailed(url string, err error) {
+	if IsRateLimited(err) {
+		s.markThrottled(url, err)
+		return
+	}
+	s.markDead(url, err)
+}
+
+// markThrottled skips a rate-limited endpoint for the server's Retry-After, or the retry interval,
+// without counting it as dead
+func (s *endpointSet) markThrottled(url string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep := s.find(url)
+	if ep == nil {
+		return
+	}
+	backoff := s.retryInterval
+	var apiErr *APIError
+	if errors.As(lastAttemptError(err), &apiErr) && apiErr.RetryAfter > 0 {
+		backoff = apiErr.RetryAfter
+	}
+	ep.throttledUntil = time.Now().Add(backoff)
+}
+
+// markDead records a failed send and schedules when the endpoint is tried again
+func (s *endpointSet) markDead(url string, err error) {
+	s.mu.Lock()
+	ep := s.find(url)
+	if ep == nil {
+		s.mu.Unlock()
+		return
+	}
+	wasHealthy := ep.healthy
+	ep.healthy = false
+	ep.failures++
+	ep.lastError = err
+	ep.retryAt = time.Now().Add(s.retryInterval)
+	if wasHealthy {
+		ep.deadSince = time.Now()
+	}
+	onDead := s.onDead
+	s.mu.Unlock()
+
+	if wasHealthy && onDead != nil {
+		onDead(url, err)
+	}
+}
+
+func (s *endpointSet) find(url string) *endpoint {
+	for _, ep := range s.endpoints {
+		if ep.url == url {
+			return ep
+		}
+	}
+	return nil
+}
+
+// status returns a snapshot of every endpoint
+func (s *endpointSet) status() []EndpointStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]EndpointStatus, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		statuses = append(statuses, EndpointStatus{
+			URL:            ep.url,
+			Healthy:        ep.healthy,
+			Failures:       ep.failures,
+			LastError:      ep.lastError,
+			DeadSince:      ep.deadSince,
+			RetryAt:        ep.retryAt,
+			ThrottledUntil: ep.throttledUntil,
+		})
+	}
+	return statuses
+}
+
+// shouldFailover reports whether another endpoint might succeed where this one failed. Request
+// timeouts fail over; the caller's own cancellation or deadline does not.
+func shouldFailover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrRateLimitExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(lastAttemptError(err), &apiErr) {
+		// The payload itself is invalid: every endpoint would reject it
+		if apiErr.Code == CodeBadRequest || apiErr.HTTPStatus == http.StatusBadRequest ||
+			apiErr.HTTPStatus == http.StatusRequestEntityTooLarge {
+			return false
+		}
+	}
+	return true
+}
+
+// WithFailoverURLs adds backup webhook URLs tried in order when the primary fails
+func WithFailoverURLs(urls ...string) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.FailoverURLs = append(opts.FailoverURLs, urls...)
+	}
+}
+
+// WithEndpointRetryInterval sets how long a dead endpoint is skipped before the next send probes it again
+func WithEndpointRetryInterval(interval time.Duration) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.EndpointRetryInterval = interval
+	}
+}
+
+// WithEndpointDeadHandler registers a callback invoked when an endpoint is marked dead; rate limiting
+// (HTTP 429 or code 11232) does not count
+func WithEndpointDeadHandler(handler func(url string, err error)) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.OnEndpointDead = handler
+	}
+}
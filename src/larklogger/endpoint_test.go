@@ -0,0 +1,221 @@
+package larklogger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLarkClientFailover(t *testing.T) {
+	var primaryBroken atomic.Bool
+	primaryBroken.Store(true)
+	var primaryCalls, backupCalls int32
+
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryCalls, 1)
+		if primaryBroken.Load() {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 19001, "msg": "param invalid: incoming webhook access token invalid"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "msg": "success"})
+	}))
+	defer primary.Close()
+
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&backupCalls, 1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "msg": "success"})
+	}))
+	defer backup.Close()
+
+	var mu sync.Mutex
+	var dead []string
+	client := NewLarkClient(primary.URL,
+		WithFailoverURLs(backup.URL),
+		WithEndpointRetryInterval(50*time.Millisecond),
+		WithEndpointDeadHandler(func(url string, err error) {
+			mu.Lock()
+			dead = append(dead, url)
+			mu.Unlock()
+		}),
+	)
+
+	t.Run("fails over to the next endpoint", func(t *testing.T) {
+		if err := client.SendText("first"); err != nil {
+			t.Fatalf("Expected failover to succeed, got %v", err)
+		}
+		if primaryCalls != 1 || backupCalls != 1 {
+			t.Errorf("Expected one call to each endpoint, got primary=%d backup=%d", primaryCalls, backupCalls)
+		}
+
+		mu.Lock()
+		if len(dead) != 1 || dead[0] != primary.URL {
+			t.Errorf("Expected primary to be reported dead once, got %v", dead)
+		}
+		mu.Unlock()
+
+		status := client.Endpoints()
+		if status[0].Healthy || status[0].LastError == nil || !status[1].Healthy {
+			t.Errorf("Unexpected endpoint status: %+v", status)
+		}
+	})
+
+	t.Run("skips dead endpoints until the retry is due", func(t *testing.T) {
+		_ = client.SendText("second")
+		if primaryCalls != 1 {
+			t.Errorf("Expected dead primary to be skipped, got %d calls", primaryCalls)
+		}
+	})
+
+	t.Run("recovers when a later send succeeds", func(t *testing.T) {
+		primaryBroken.Store(false)
+		time.Sleep(60 * time.Millisecond)
+
+		if err := client.SendText("third"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if primaryCalls != 2 {
+			t.Errorf("Expected primary to be retried, got %d calls", primaryCalls)
+		}
+		if status := client.Endpoints(); !status[0].Healthy || status[0].Failures != 0 {
+			t.Errorf("Expected primary to be healthy again, got %+v", status[0])
+		}
+	})
+}
+
+func TestLarkClientRateLimitedEndpoint(t *testing.T) {
+	var primaryCalls, backupCalls int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryCalls, 1)
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer primary.Close()
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&backupCalls, 1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "msg": "success"})
+	}))
+	defer backup.Close()
+
+	var deadCalls int32
+	client := NewLarkClient(primary.URL,
+		WithFailoverURLs(backup.URL),
+		WithRetry(0, 0),
+		WithEndpointDeadHandler(func(url string, err error) { atomic.AddInt32(&deadCalls, 1) }),
+	)
+
+	if err := client.SendText("first"); err != nil {
+		t.Fatalf("Expected failover to succeed, got %v", err)
+	}
+	if err := client.SendText("second"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if deadCalls != 0 {
+		t.Errorf("Expected rate limiting not to report the endpoint dead, got %d calls", deadCalls)
+	}
+	if primaryCalls != 1 || backupCalls != 2 {
+		t.Errorf("Expected the throttled primary to be skipped, got primary=%d backup=%d", primaryCalls, backupCalls)
+	}
+	status := client.Endpoints()[0]
+	if !status.Healthy || status.Failures != 0 || status.ThrottledUntil.IsZero() {
+		t.Errorf("Expected primary healthy but throttled, got %+v", status)
+	}
+}
+
+func TestLarkClientNoFailoverOnBadPayload(t *testing.T) {
+	var backupCalls int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": CodeBadRequest, "msg": "Bad Request"})
+	}))
+	defer primary.Close()
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&backupCalls, 1)
+	}))
+	defer backup.Close()
+
+	client := NewLarkClient(primary.URL, WithFailoverURLs(backup.URL))
+	if err := client.SendText("test"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if backupCalls != 0 {
+		t.Errorf("Expected no failover for a malformed payload, got %d backup calls", backupCalls)
+	}
+	if status := client.Endpoints(); !status[0].Healthy {
+		t.Error("Expected primary to stay healthy after a payload error")
+	}
+}
+
+func TestLarkClientEndpointProbe(t *testing.T) {
+	var primaryState atomic.Int32 // 0 token invalid, 1 server error, 2 healthy
+	var primaryCalls int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryCalls, 1)
+		switch primaryState.Load() {
+		case 0:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 19001, "msg": "param invalid: incoming webhook access token invalid"})
+		case 1:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "msg": "success"})
+		}
+	}))
+	defer primary.Close()
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "msg": "success"})
+	}))
+	defer backup.Close()
+
+	const backoff = 500 * time.Millisecond
+	client := NewLarkClient(primary.URL,
+		WithFailoverURLs(backup.URL),
+		WithRetry(3, backoff),
+		WithEndpointRetryInterval(20*time.Millisecond),
+	)
+
+	if err := client.SendText("first"); err != nil {
+		t.Fatalf("Expected failover to succeed, got %v", err)
+	}
+	if client.Endpoints()[0].Healthy {
+		t.Fatal("Expected primary to be marked dead")
+	}
+
+	t.Run("a failed probe is a single attempt", func(t *testing.T) {
+		primaryState.Store(1)
+		time.Sleep(30 * time.Millisecond)
+
+		calls := atomic.LoadInt32(&primaryCalls)
+		start := time.Now()
+		if err := client.SendText("second"); err != nil {
+			t.Fatalf("Expected failover to succeed, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= backoff {
+			t.Errorf("Expected the probe not to pay the retry back-off, took %v", elapsed)
+		}
+		if got := atomic.LoadInt32(&primaryCalls) - calls; got != 1 {
+			t.Errorf("Expected one probe request, got %d", got)
+		}
+		if status := client.Endpoints()[0]; status.Healthy || status.Failures != 2 {
+			t.Errorf("Expected primary still dead after the failed probe, got %+v", status)
+		}
+	})
+
+	t.Run("a successful probe recovers the endpoint", func(t *testing.T) {
+		primaryState.Store(2)
+		time.Sleep(30 * time.Millisecond)
+
+		calls := atomic.LoadInt32(&primaryCalls)
+		if err := client.SendText("third"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := atomic.LoadInt32(&primaryCalls) - calls; got != 1 {
+			t.Errorf("Expected the probe to deliver the message, got %d requests", got)
+		}
+		if status := client.Endpoints()[0]; !status.Healthy || status.Failures != 0 {
+			t.Errorf("Expected primary to be healthy again, got %+v", status)
+		}
+	})
+}
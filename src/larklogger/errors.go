@@ -0,0 +1,139 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// APIError is returned when Lark answers with a non-200 status or a non-zero code
+type APIError struct {
+	Code       int           // Lark business code (0 when the HTTP status alone signals failure)
+	Msg        string        // Lark error message
+	HTTPStatus int           // HTTP status code of the response
+	Body       string        // Raw response body
+	RetryAfter time.Duration // Server-requested delay from the Retry-After header, if any
+}
+
+func (e *APIError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("lark API error (code: %d): %s", e.Code, e.Msg)
+	}
+	return fmt.Sprintf("request failed with status %d: %s", e.HTTPStatus, e.Body)
+}
+
+// TransportError is returned when the request could not be completed (network, I/O, malformed response)
+type TransportError struct {
+	Op  string // Operation that failed, e.g. "send request"
+	Err error  // Underlying error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("failed to %s: %v", e.Op, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// retryableOps are the TransportError operations that fail on network errors and timeouts.
+// Building the request or parsing a 200 response fails the same way every time.
+var retryableOps = map[string]bool{
+	"send request":       true,
+	"read response body": true,
+}
+
+// RetryExhaustedError is returned when every attempt to deliver a message failed
+type RetryExhaustedError struct {
+	Attempts int     // Number of attempts made
+	Errors   []error // Error of each attempt, in order
+	Aborted  error   // Context error that interrupted retrying, if any
+}
+
+func (e *RetryExhaustedError) Error() string {
+	if e.Aborted != nil {
+		return fmt.Sprintf("send aborted after %d attempt(s): %v (last error: %v)", e.Attempts, e.Aborted, e.Last())
+	}
+	return fmt.Sprintf("failed to send message after %d attempt(s): %v", e.Attempts, e.Last())
+}
+
+// Last returns the error of the final attempt
+func (e *RetryExhaustedError) Last() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors[len(e.Errors)-1]
+}
+
+// Unwrap exposes every attempt's error (and the abort reason) to errors.Is and errors.As
+func (e *RetryExhaustedError) Unwrap() []error {
+	errs := append([]error{}, e.Errors...)
+	if e.Aborted != nil {
+		errs = append(errs, e.Aborted)
+	}
+	return errs
+}
+
+// lastAttemptError unwraps a RetryExhaustedError to the error of its final attempt
+func lastAttemptError(err error) error {
+	var exhausted *RetryExhaustedError
+	if errors.As(err, &exhausted) {
+		return exhausted.Last()
+	}
+	return err
+}
+
+// IsRateLimited reports whether err signals Lark frequency limiting (HTTP 429 or code 11232)
+// or the client-side limiter refused the send
+func IsRateLimited(err error) bool {
+	if errors.Is(err, ErrRateLimitExceeded) {
+		return true
+	}
+
+	var apiErr *APIError
+	if !errors.As(lastAttemptError(err), &apiErr) {
+		return false
+	}
+	return apiErr.HTTPStatus == http.StatusTooManyRequests || apiErr.Code == CodeFrequencyLimited
+}
+
+// IsRetryable reports whether err is transient and the send is worth retrying. Network timeouts,
+// http.Client's Timeout included, are retryable even though they match context.DeadlineExceeded;
+// the retry loop itself stops once the caller's context is done.
+func IsRetryable(err error) bool {
+	err = lastAttemptError(err)
+	if err == nil {
+		return false
+	}
+
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		// A request timeout is transient; a cancellation only ever comes from the caller
+		if errors.Is(transportErr, context.Canceled) {
+			return false
+		}
+		return retryableOps[transportErr.Op]
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	if IsRateLimited(apiErr) {
+		return true
+	}
+
+	if apiErr.HTTPStatus >= http.StatusInternalServerError {
+		return true
+	}
+
+	// Non-zero Lark codes (bad request, signature mismatch, keyword filter...) are deterministic
+	return false
+}
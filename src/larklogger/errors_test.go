@@ -0,0 +1,187 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTypedErrors(t *testing.T) {
+	t.Run("API error carries code and body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 9499, "msg": "Bad Request"})
+		}))
+		defer server.Close()
+
+		client := NewLarkClient(server.URL, WithRetry(0, 0))
+		err := client.SendText("test")
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Expected *APIError, got %T: %v", err, err)
+		}
+		if apiErr.Code != 9499 || apiErr.Msg != "Bad Request" || apiErr.HTTPStatus != http.StatusOK {
+			t.Errorf("Unexpected APIError fields: %+v", apiErr)
+		}
+		if !contains(apiErr.Body, "9499") {
+			t.Errorf("Expected raw body to be kept, got %s", apiErr.Body)
+		}
+		if IsRetryable(err) {
+			t.Error("Expected API error to be non-retryable")
+		}
+	})
+
+	t.Run("transport error wraps network failure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		url := server.URL
+		server.Close()
+
+		client := NewLarkClient(url, WithRetry(0, 0))
+		err := client.SendText("test")
+
+		var transportErr *TransportError
+		if !errors.As(err, &transportErr) {
+			t.Fatalf("Expected *TransportError, got %T: %v", err, err)
+		}
+		if transportErr.Op != "send request" {
+			t.Errorf("Expected op 'send request', got %s", transportErr.Op)
+		}
+		if !IsRetryable(err) {
+			t.Error("Expected transport error to be retryable")
+		}
+	})
+
+	t.Run("request timeouts are retried", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				time.Sleep(200 * time.Millisecond)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "msg": "success"})
+		}))
+		defer server.Close()
+
+		client := NewLarkClient(server.URL, WithTimeout(50*time.Millisecond), WithRetry(2, time.Millisecond))
+		if err := client.SendText("test"); err != nil {
+			t.Fatalf("Expected the retry to succeed, got %v", err)
+		}
+		if n := atomic.LoadInt32(&attempts); n != 2 {
+			t.Errorf("Expected 2 attempts, got %d", n)
+		}
+
+		timeout := &TransportError{Op: "send request", Err: fmt.Errorf("Client.Timeout exceeded: %w", context.DeadlineExceeded)}
+		if !IsRetryable(timeout) {
+			t.Error("Expected a request timeout to be retryable")
+		}
+		if IsRetryable(&TransportError{Op: "send request", Err: context.Canceled}) {
+			t.Error("Expected a cancelled request not to be retryable")
+		}
+	})
+
+	t.Run("caller deadline stops retrying", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		client := NewLarkClient(server.URL, WithRetry(3, time.Millisecond))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := client.SendTextCtx(ctx, "test"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the caller's deadline, got %v", err)
+		}
+		if n := atomic.LoadInt32(&attempts); n != 1 {
+			t.Errorf("Expected 1 attempt, got %d", n)
+		}
+	})
+
+	t.Run("transport errors classified by operation", func(t *testing.T) {
+		tests := []struct {
+			op   string
+			want bool
+		}{
+			{"send request", true},
+			{"read response body", true},
+			{"create request", false},
+			{"parse response", false},
+			{"parse token response", false},
+		}
+		for _, tt := range tests {
+			err := &TransportError{Op: tt.op, Err: errors.New("boom")}
+			if got := IsRetryable(err); got != tt.want {
+				t.Errorf("Expected IsRetryable=%v for %q, got %v", tt.want, tt.op, got)
+			}
+		}
+	})
+
+	t.Run("malformed response is not retried", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			_, _ = w.Write([]byte("<html>proxy page</html>"))
+		}))
+		defer server.Close()
+
+		client := NewLarkClient(server.URL, WithRetry(2, time.Millisecond))
+		err := client.SendText("test")
+
+		var transportErr *TransportError
+		if !errors.As(err, &transportErr) || transportErr.Op != "parse response" {
+			t.Fatalf("Expected parse response error, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("Expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("retry exhausted keeps every attempt", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		client := NewLarkClient(server.URL, WithRetryPolicy(&ExponentialBackoff{MaxRetries: 2, InitialDelay: time.Millisecond}))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		start := time.Now()
+		err := client.SendTextCtx(ctx, "test")
+
+		var exhausted *RetryExhaustedError
+		if !errors.As(err, &exhausted) {
+			t.Fatalf("Expected *RetryExhaustedError, got %T: %v", err, err)
+		}
+		if exhausted.Attempts != 3 || len(exhausted.Errors) != 3 {
+			t.Errorf("Expected 3 attempts, got %d (%d errors)", exhausted.Attempts, len(exhausted.Errors))
+		}
+		if !IsRateLimited(err) {
+			t.Error("Expected IsRateLimited to be true")
+		}
+		if time.Since(start) < 2*time.Second {
+			t.Errorf("Expected Retry-After to be honoured, took %v", time.Since(start))
+		}
+	})
+
+	t.Run("aborted retries expose context error", func(t *testing.T) {
+		err := &RetryExhaustedError{
+			Attempts: 1,
+			Errors:   []error{&APIError{HTTPStatus: http.StatusBadGateway}},
+			Aborted:  context.Canceled,
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Error("Expected errors.Is to find context.Canceled")
+		}
+		if !IsRetryable(err) {
+			t.Error("Expected classification to follow the last attempt")
+		}
+	})
+}
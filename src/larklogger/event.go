@@ -0,0 +1,296 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types with typed handlers
+const (
+	EventTypeMessageReceive = "im.message.receive_v1"
+	EventTypeBotAdded       = "im.chat.member.bot.added_v1"
+	EventTypeBotDeleted     = "im.chat.member.bot.deleted_v1"
+)
+
+// Default event deduplication window; Lark retries undelivered events for several hours
+const (
+	DefaultEventDedupeTTL      = 12 * time.Hour
+	DefaultEventDedupeCapacity = 10000
+)
+
+// EventHeader is the header of a v2 event
+type EventHeader struct {
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	CreateTime string `json:"create_time"` // Milliseconds since epoch
+	Token      string `json:"token"`
+	AppID      string `json:"app_id"`
+	TenantKey  string `json:"tenant_key"`
+}
+
+// Event is a v2 event as received
+type Event struct {
+	Header EventHeader
+	Event  json.RawMessage // The "event" object, decoded by typed handlers
+	Body   []byte          // Decrypted request body
+}
+
+// EventUserID identifies a user in every ID space
+type EventUserID struct {
+	UnionID string `json:"union_id"`
+	UserID  string `json:"user_id"`
+	OpenID  string `json:"open_id"`
+}
+
+// EventMention is an @mention in a received message; Key is its placeholder in the text, e.g. "@_user_1"
+type EventMention struct {
+	Key       string      `json:"key"`
+	ID        EventUserID `json:"id"`
+	Name      string      `json:"name"`
+	TenantKey string      `json:"tenant_key"`
+}
+
+// MessageReceiveEvent is an im.message.receive_v1 event: a message sent to the bot or in a chat with it
+type MessageReceiveEvent struct {
+	Header EventHeader `json:"-"`
+	Sender struct {
+		SenderID   EventUserID `json:"sender_id"`
+		SenderType string      `json:"sender_type"`
+		TenantKey  string      `json:"tenant_key"`
+	} `json:"sender"`
+	Message struct {
+		MessageID   string         `json:"message_id"`
+		RootID      string         `json:"root_id"`
+		ParentID    string         `json:"parent_id"`
+		CreateTime  string         `json:"create_time"`
+		ChatID      string         `json:"chat_id"`
+		ChatType    string         `json:"chat_type"` // "p2p" or "group"
+		MessageType string         `json:"message_type"`
+		Content     string         `json:"content"` // JSON content, e.g. {"text":"@_user_1 hi"}
+		Mentions    []EventMention `json:"mentions"`
+	} `json:"message"`
+}
+
+var mentionKeyPattern = regexp.MustCompile(`@_user_\d+|@_all`)
+
+// Text returns the text of a text message with mention placeholders removed, e.g.
+// "silence payments 30m" for "@bot silence payments 30m"; it is empty for other message types
+func (e *MessageReceiveEvent) Text() string {
+	if e.Message.MessageType != "text" {
+		return ""
+	}
+	var content struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(e.Message.Content), &content); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(mentionKeyPattern.ReplaceAllString(content.Text, " ")), " ")
+}
+
+// Args splits Text into whitespace-separated words, e.g. a chat command and its arguments
+func (e *MessageReceiveEvent) Args() []string {
+	return strings.Fields(e.Text())
+}
+
+// Reply returns the receiver to answer the message in the chat it was sent in
+func (e *MessageReceiveEvent) Reply() Receiver {
+	return ToChat(e.Message.ChatID)
+}
+
+// BotMembershipEvent is an im.chat.member.bot.added_v1 or deleted_v1 event
+type BotMembershipEvent struct {
+	Header            EventHeader `json:"-"`
+	Added             bool        `json:"-"` // True when the bot joined the chat, false when it was removed
+	ChatID            string      `json:"chat_id"`
+	Name              string      `json:"name"`
+	Operator          EventUserID `json:"operator_id"`
+	OperatorTenantKey string      `json:"operator_tenant_key"`
+	External          bool        `json:"external"`
+}
+
+// EventFunc handles a raw event
+type EventFunc func(ctx context.Context, event *Event) error
+
+// EventOption configures an EventReceiver
+type EventOption func(*EventReceiver)
+
+// WithEventDedupe sets how long, and how many, event_ids are remembered to drop redelivered events
+func WithEventDedupe(ttl time.Duration, capacity int) EventOption {
+	return func(r *EventReceiver) {
+		r.dedupe = newEventDeduper(ttl, capacity)
+	}
+}
+
+// EventReceiver is an http.Handler for the event subscription URL configured in the Lark developer console.
+// It authenticates and decrypts requests, answers the url_verification challenge, drops redelivered events
+// and dispatches by event type. Lark expects an answer within 3 seconds, so long work should not block handlers.
+type EventReceiver struct {
+	opts   InboundOptions
+	dedupe *eventDeduper
+
+	mu       sync.RWMutex
+	handlers map[string][]EventFunc
+}
+
+// NewEventReceiver creates an event receiver authenticating requests with opts
+func NewEventReceiver(opts InboundOptions, options ...EventOption) *EventReceiver {
+	r := &EventReceiver{
+		opts:     opts,
+		dedupe:   newEventDeduper(DefaultEventDedupeTTL, DefaultEventDedupeCapacity),
+		handlers: make(map[string][]EventFunc),
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// Handle registers fn for events of eventType
+func (r *EventReceiver) Handle(eventType string, fn EventFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[eventType] = append(r.handlers[eventType], fn)
+}
+
+// OnMessageReceive registers fn for messages sent to the bot
+func (r *EventReceiver) OnMessageReceive(fn func(ctx context.Context, event *MessageReceiveEvent) error) {
+	r.Handle(EventTypeMessageReceive, func(ctx context.Context, event *Event) error {
+		msg := &MessageReceiveEvent{Header: event.Header}
+		if err := json.Unmarshal(event.Event, msg); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", EventTypeMessageReceive, err)
+		}
+		return fn(ctx, msg)
+	})
+}
+
+// OnBotAdded registers fn for the bot being added to a chat
+func (r *EventReceiver) OnBotAdded(fn func(ctx context.Context, event *BotMembershipEvent) error) {
+	r.Handle(EventTypeBotAdded, botMembershipHandler(true, fn))
+}
+
+// OnBotRemoved registers fn for the bot being removed from a chat
+func (r *EventReceiver) OnBotRemoved(fn func(ctx context.Context, event *BotMembershipEvent) error) {
+	r.Handle(EventTypeBotDeleted, botMembershipHandler(false, fn))
+}
+
+func botMembershipHandler(added bool, fn func(ctx context.Context, event *BotMembershipEvent) error) EventFunc {
+	return func(ctx context.Context, event *Event) error {
+		membership := &BotMembershipEvent{Header: event.Header, Added: added}
+		if err := json.Unmarshal(event.Event, membership); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", event.Header.EventType, err)
+		}
+		return fn(ctx, membership)
+	}
+}
+
+// ServeHTTP implements http.Handler. A handler error answers 500 so that Lark redelivers the event.
+func (r *EventReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, env, err := r.opts.readInbound(req)
+	if err != nil {
+		http.Error(w, err.Error(), inboundStatus(err))
+		return
+	}
+
+	if env.Type == "url_verification" {
+		writeInboundJSON(w, map[string]string{"challenge": env.Challenge})
+		return
+	}
+	if env.Schema != "2.0" {
+		http.Error(w, "only v2 events are supported", http.StatusBadRequest)
+		return
+	}
+
+	var raw struct {
+		Header EventHeader     `json:"header"`
+		Event  json.RawMessage `json:"event"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse event: %v", err), http.StatusBadRequest)
+		return
+	}
+	event := &Event{Header: raw.Header, Event: raw.Event, Body: body}
+
+	if !r.dedupe.claim(event.Header.EventID) {
+		writeInboundJSON(w, map[string]string{})
+		return
+	}
+	if err := r.dispatch(req.Context(), event); err != nil {
+		r.dedupe.release(event.Header.EventID)
+		fmt.Printf("Failed to handle Lark event %s (%s): %v\n", event.Header.EventType, event.Header.EventID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeInboundJSON(w, map[string]string{})
+}
+
+// dispatch runs every handler registered for the event type; events without handlers are acknowledged
+func (r *EventReceiver) dispatch(ctx context.Context, event *Event) error {
+	r.mu.RLock()
+	handlers := r.handlers[event.Header.EventType]
+	r.mu.RUnlock()
+
+	for _, fn := range handlers {
+		if err := fn(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventDeduper remembers recently seen event_ids
+type eventDeduper struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	seen     map[string]time.Time
+	order    []string // Insertion order, for evicting the oldest ids beyond capacity
+	now      func() time.Time
+}
+
+func newEventDeduper(ttl time.Duration, capacity int) *eventDeduper {
+	return &eventDeduper{ttl: ttl, capacity: capacity, seen: make(map[string]time.Time), now: time.Now}
+}
+
+// claim records id and reports whether it was not seen within the TTL; empty ids are never deduplicated
+func (d *eventDeduper) claim(id string) bool {
+	if id == "" || d.capacity <= 0 {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if at, ok := d.seen[id]; ok && now.Sub(at) < d.ttl {
+		return false
+	}
+	if _, ok := d.seen[id]; !ok {
+		d.order = append(d.order, id)
+	}
+	d.seen[id] = now
+
+	for len(d.order) > d.capacity {
+		delete(d.seen, d.order[0])
+		d.order = d.order[1:]
+	}
+	return true
+}
+
+// release forgets id so a redelivery is handled again
+func (d *eventDeduper) release(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, id)
+	for i, seen := range d.order {
+		if seen == id {
+			d.order = append(d.order[:i], d.order[i+1:]...)
+			break
+		}
+	}
+}
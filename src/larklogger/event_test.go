@@ -0,0 +1,293 @@
+package larklogger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const fixtureToken = "fixture-verification-token"
+
+// loadEventFixture reads a recorded event from testdata/events
+func loadEventFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "events", name+".json"))
+	if err != nil {
+		t.Fatalf("Expected fixture %s, got %v", name, err)
+	}
+	return data
+}
+
+func TestEventReceiverVerification(t *testing.T) {
+	receiver := NewEventReceiver(InboundOptions{VerificationToken: fixtureToken, EncryptKey: "ekey"})
+
+	t.Run("url_verification challenge", func(t *testing.T) {
+		rec := postInbound(receiver, encryptInbound(t, "ekey", loadEventFixture(t, "url_verification")), sha256Signer("ekey"))
+		if rec.Code != http.StatusOK || !contains(rec.Body.String(), `"challenge":"ajls384kdjx98XX"`) {
+			t.Errorf("Expected challenge echoed, got %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("encrypted and signed event", func(t *testing.T) {
+		body := encryptInbound(t, "ekey", loadEventFixture(t, EventTypeMessageReceive))
+		rec := postInbound(receiver, body, sha256Signer("ekey"))
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("replayed encrypted body without headers", func(t *testing.T) {
+		body := encryptInbound(t, "ekey", loadEventFixture(t, EventTypeMessageReceive))
+		req := httptest.NewRequest(http.MethodPost, "/lark/events", bytes.NewReader(body))
+		if _, _, err := receiver.opts.readInbound(req); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+		if rec := postInbound(receiver, body, nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("missing nonce", func(t *testing.T) {
+		body := encryptInbound(t, "ekey", loadEventFixture(t, EventTypeMessageReceive))
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req := httptest.NewRequest(http.MethodPost, "/lark/events", bytes.NewReader(body))
+		req.Header.Set(HeaderRequestTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, sha256Signer("ekey")(timestamp, "", body))
+		if _, _, err := receiver.opts.readInbound(req); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("stale encrypted event", func(t *testing.T) {
+		body := encryptInbound(t, "ekey", loadEventFixture(t, EventTypeMessageReceive))
+		if rec := postInboundAt(receiver, body, sha256Signer("ekey"), time.Now().Add(-time.Hour)); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("bad signature", func(t *testing.T) {
+		body := encryptInbound(t, "ekey", loadEventFixture(t, EventTypeBotAdded))
+		rec := postInbound(receiver, body, sha256Signer("other"))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("wrong token", func(t *testing.T) {
+		other := NewEventReceiver(InboundOptions{VerificationToken: "other"})
+		rec := postInbound(other, loadEventFixture(t, EventTypeBotAdded), nil)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("unsigned plaintext rejected", func(t *testing.T) {
+		rec := postInbound(receiver, loadEventFixture(t, EventTypeMessageReceive), nil)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("schema 1.0 event rejected", func(t *testing.T) {
+		rec := postInbound(receiver, loadEventFixture(t, "schema1_message"), sha256Signer("ekey"))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestEventReceiverMessageReceive(t *testing.T) {
+	receiver := NewEventReceiver(InboundOptions{VerificationToken: fixtureToken})
+
+	var got *MessageReceiveEvent
+	receiver.OnMessageReceive(func(ctx context.Context, event *MessageReceiveEvent) error {
+		got = event
+		return nil
+	})
+
+	rec := postInbound(receiver, loadEventFixture(t, EventTypeMessageReceive), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got == nil {
+		t.Fatal("Expected message handler to be called")
+	}
+
+	t.Run("header and message", func(t *testing.T) {
+		if got.Header.EventID != "5e3702a84e847582be8db7fb73283c02" {
+			t.Errorf("Expected event id, got %q", got.Header.EventID)
+		}
+		if got.Message.ChatID != "oc_5ce6d572455d361153b7cb51da133945" || got.Message.ChatType != "group" {
+			t.Errorf("Expected group chat, got %q %q", got.Message.ChatID, got.Message.ChatType)
+		}
+		if got.Sender.SenderID.OpenID != "ou_84aad35d084aa403a838cf73ee18467" {
+			t.Errorf("Expected sender open_id, got %q", got.Sender.SenderID.OpenID)
+		}
+		if len(got.Message.Mentions) != 1 || got.Message.Mentions[0].Name != "Alert Bot" {
+			t.Errorf("Expected bot mention, got %+v", got.Message.Mentions)
+		}
+	})
+
+	t.Run("command text", func(t *testing.T) {
+		if got.Text() != "silence payments 30m" {
+			t.Errorf("Expected mention stripped, got %q", got.Text())
+		}
+		if args := got.Args(); !reflect.DeepEqual(args, []string{"silence", "payments", "30m"}) {
+			t.Errorf("Expected command args, got %v", args)
+		}
+	})
+
+	t.Run("reply receiver", func(t *testing.T) {
+		if reply := got.Reply(); reply != ToChat(got.Message.ChatID) {
+			t.Errorf("Expected reply to the chat, got %+v", reply)
+		}
+	})
+
+	t.Run("non-text message", func(t *testing.T) {
+		image := &MessageReceiveEvent{}
+		image.Message.MessageType = "image"
+		image.Message.Content = `{"image_key":"img_1"}`
+		if image.Text() != "" {
+			t.Errorf("Expected empty text, got %q", image.Text())
+		}
+	})
+}
+
+func TestEventReceiverBotMembership(t *testing.T) {
+	receiver := NewEventReceiver(InboundOptions{VerificationToken: fixtureToken})
+
+	var events []*BotMembershipEvent
+	record := func(ctx context.Context, event *BotMembershipEvent) error {
+		events = append(events, event)
+		return nil
+	}
+	receiver.OnBotAdded(record)
+	receiver.OnBotRemoved(record)
+
+	for _, name := range []string{EventTypeBotAdded, EventTypeBotDeleted} {
+		if rec := postInbound(receiver, loadEventFixture(t, name), nil); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for %s, got %d", name, rec.Code)
+		}
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 membership events, got %d", len(events))
+	}
+	if !events[0].Added || events[1].Added {
+		t.Errorf("Expected added then removed, got %v %v", events[0].Added, events[1].Added)
+	}
+	if events[0].ChatID != "oc_413871369d1e2b9e0d6fd5a9e2b8f0c1" || events[0].Name != "payments-oncall" {
+		t.Errorf("Expected chat details, got %q %q", events[0].ChatID, events[0].Name)
+	}
+	if events[0].Operator.OpenID != "ou_84aad35d084aa403a838cf73ee18467" {
+		t.Errorf("Expected operator, got %+v", events[0].Operator)
+	}
+}
+
+func TestEventReceiverDispatch(t *testing.T) {
+	t.Run("duplicate event_id handled once", func(t *testing.T) {
+		receiver := NewEventReceiver(InboundOptions{})
+		calls := 0
+		receiver.Handle(EventTypeBotAdded, func(ctx context.Context, event *Event) error {
+			calls++
+			return nil
+		})
+
+		body := loadEventFixture(t, EventTypeBotAdded)
+		for i := 0; i < 3; i++ {
+			if rec := postInbound(receiver, body, nil); rec.Code != http.StatusOK {
+				t.Errorf("Expected 200, got %d", rec.Code)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("handler error allows redelivery", func(t *testing.T) {
+		receiver := NewEventReceiver(InboundOptions{})
+		calls := 0
+		receiver.Handle(EventTypeBotAdded, func(ctx context.Context, event *Event) error {
+			calls++
+			if calls == 1 {
+				return errors.New("chat store unavailable")
+			}
+			return nil
+		})
+
+		body := loadEventFixture(t, EventTypeBotAdded)
+		if rec := postInbound(receiver, body, nil); rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected 500, got %d", rec.Code)
+		}
+		if rec := postInbound(receiver, body, nil); rec.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d", rec.Code)
+		}
+		if calls != 2 {
+			t.Errorf("Expected 2 calls, got %d", calls)
+		}
+	})
+
+	t.Run("unhandled event type acknowledged", func(t *testing.T) {
+		receiver := NewEventReceiver(InboundOptions{})
+		if rec := postInbound(receiver, loadEventFixture(t, EventTypeMessageReceive), nil); rec.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d", rec.Code)
+		}
+	})
+
+	t.Run("raw event", func(t *testing.T) {
+		receiver := NewEventReceiver(InboundOptions{})
+		var got *Event
+		receiver.Handle(EventTypeMessageReceive, func(ctx context.Context, event *Event) error {
+			got = event
+			return nil
+		})
+		postInbound(receiver, loadEventFixture(t, EventTypeMessageReceive), nil)
+		if got == nil || got.Header.AppID != "cli_a1b2c3d4e5f60789" || !bytes.Contains(got.Event, []byte(`"message"`)) {
+			t.Errorf("Expected raw event, got %+v", got)
+		}
+	})
+}
+
+func TestEventDeduper(t *testing.T) {
+	t.Run("ttl expiry", func(t *testing.T) {
+		now := time.Unix(1700000000, 0)
+		d := newEventDeduper(time.Minute, 10)
+		d.now = func() time.Time { return now }
+
+		if !d.claim("a") || d.claim("a") {
+			t.Error("Expected first claim to succeed and second to fail")
+		}
+		now = now.Add(2 * time.Minute)
+		if !d.claim("a") {
+			t.Error("Expected claim after TTL to succeed")
+		}
+	})
+
+	t.Run("capacity eviction", func(t *testing.T) {
+		d := newEventDeduper(time.Hour, 2)
+		d.claim("a")
+		d.claim("b")
+		d.claim("c")
+		if !d.claim("a") {
+			t.Error("Expected oldest id to be evicted")
+		}
+		if d.claim("c") {
+			t.Error("Expected recent id to be remembered")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		d := newEventDeduper(time.Hour, 0)
+		if !d.claim("a") || !d.claim("a") {
+			t.Error("Expected no deduplication with zero capacity")
+		}
+	})
+}
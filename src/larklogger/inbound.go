@@ -0,0 +1,217 @@
+package larklogger
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers Lark signs callback and event requests with
+const (
+	HeaderRequestTimestamp = "X-Lark-Request-Timestamp"
+	HeaderRequestNonce     = "X-Lark-Request-Nonce"
+	HeaderSignature        = "X-Lark-Signature"
+)
+
+// maxInboundBody bounds the size of callback and event requests
+const maxInboundBody = 1 << 20
+
+// DefaultTimestampTolerance is how far X-Lark-Request-Timestamp may be from now before a signed request is rejected
+const DefaultTimestampTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned when a request's X-Lark-Signature does not match
+	ErrInvalidSignature = errors.New("invalid request signature")
+	// ErrInvalidToken is returned when a request carries the wrong verification token
+	ErrInvalidToken = errors.New("invalid verification token")
+	// ErrStaleRequest is returned when a signed request's timestamp is outside the tolerance, e.g. a replay
+	ErrStaleRequest = errors.New("request timestamp outside the allowed window")
+
+	errMethodNotAllowed = errors.New("method not allowed")
+)
+
+// InboundOptions holds the credentials from the app's "Events & Callbacks" settings used to
+// authenticate requests Lark sends to us
+type InboundOptions struct {
+	VerificationToken string // Checked against the token in every request (empty = not checked)
+	// EncryptKey decrypts {"encrypt": ...} bodies and verifies signatures (empty = plaintext only).
+	// When set, every request, encrypted or not, must carry a valid X-Lark-Signature, nonce and a fresh timestamp.
+	EncryptKey string
+	// TimestampTolerance bounds the age of signed requests (0 = DefaultTimestampTolerance)
+	TimestampTolerance time.Duration
+}
+
+// inboundEnvelope is the union of the plaintext request shapes we need to authenticate and route
+type inboundEnvelope struct {
+	Encrypt   string `json:"encrypt"`
+	Type      string `json:"type"`      // "url_verification" for the challenge
+	Challenge string `json:"challenge"` // url_verification only
+	Token     string `json:"token"`     // Schema 1.0 and url_verification
+	Schema    string `json:"schema"`    // "2.0" for v2 events and callbacks
+	Header    struct {
+		EventID   string `json:"event_id"`
+		EventType string `json:"event_type"`
+		Token     string `json:"token"`
+	} `json:"header"`
+}
+
+// token returns the verification token wherever the request shape put it
+func (e *inboundEnvelope) token() string {
+	if e.Header.Token != "" {
+		return e.Header.Token
+	}
+	return e.Token
+}
+
+// readInbound reads and authenticates a request from Lark, returning the decrypted body and its envelope
+func (o InboundOptions) readInbound(r *http.Request) ([]byte, *inboundEnvelope, error) {
+	if r.Method != http.MethodPost {
+		return nil, nil, errMethodNotAllowed
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxInboundBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	if err := o.verifySignature(r.Header, body); err != nil {
+		return nil, nil, err
+	}
+
+	var env inboundEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	if env.Encrypt != "" {
+		if o.EncryptKey == "" {
+			return nil, nil, errors.New("received an encrypted request but no encrypt key is configured")
+		}
+		if body, err = decryptInbound(o.EncryptKey, env.Encrypt); err != nil {
+			return nil, nil, err
+		}
+		env = inboundEnvelope{}
+		if err := json.Unmarshal(body, &env); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse decrypted request: %w", err)
+		}
+	}
+
+	if o.VerificationToken != "" &&
+		subtle.ConstantTimeCompare([]byte(env.token()), []byte(o.VerificationToken)) != 1 {
+		return nil, nil, ErrInvalidToken
+	}
+	return body, &env, nil
+}
+
+// verifySignature checks X-Lark-Signature. Event-style requests are signed with
+// sha256(timestamp + nonce + encrypt_key + body); card callbacks with sha1(timestamp + nonce + token + body).
+// With an encrypt key every request must be signed, since an encrypted body alone can be captured and
+// replayed; with only a verification token the signature is checked when Lark sent one. The timestamp
+// of a checked request must be within the tolerance so captured requests cannot be replayed later.
+func (o InboundOptions) verifySignature(header http.Header, body []byte) error {
+	signature := header.Get(HeaderSignature)
+	if signature == "" {
+		if o.EncryptKey != "" {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	if o.EncryptKey == "" && o.VerificationToken == "" {
+		return nil
+	}
+	timestamp, nonce := header.Get(HeaderRequestTimestamp), header.Get(HeaderRequestNonce)
+	if o.EncryptKey != "" && nonce == "" {
+		return ErrInvalidSignature
+	}
+	if err := o.checkTimestamp(timestamp); err != nil {
+		return err
+	}
+
+	if o.EncryptKey != "" && signatureMatches(signature, inboundSignature(sha256.New(), timestamp, nonce, o.EncryptKey, body)) {
+		return nil
+	}
+	if o.VerificationToken != "" && signatureMatches(signature, inboundSignature(sha1.New(), timestamp, nonce, o.VerificationToken, body)) {
+		return nil
+	}
+	return ErrInvalidSignature
+}
+
+// checkTimestamp rejects signed requests whose timestamp (seconds since epoch) is missing or too far from now
+func (o InboundOptions) checkTimestamp(timestamp string) error {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleRequest
+	}
+	tolerance := o.TimestampTolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTimestampTolerance
+	}
+	age := time.Since(time.Unix(sec, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrStaleRequest
+	}
+	return nil
+}
+
+// inboundSignature returns hex(hash(timestamp + nonce + key + body))
+func inboundSignature(h hash.Hash, timestamp, nonce, key string, body []byte) string {
+	_, _ = io.WriteString(h, timestamp+nonce+key)
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func signatureMatches(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// decryptInbound decrypts an "encrypt" field: base64(IV + AES-256-CBC(body)) keyed with sha256(encryptKey)
+func decryptInbound(encryptKey, encrypted string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted request: %w", err)
+	}
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("encrypted request has an invalid length")
+	}
+
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	iv, plain := data[:aes.BlockSize], make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data[aes.BlockSize:])
+
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(plain) {
+		return nil, errors.New("failed to decrypt request: wrong encrypt key")
+	}
+	return plain[:len(plain)-pad], nil
+}
+
+// writeInboundJSON answers a Lark request with a JSON body
+func writeInboundJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// inboundStatus maps a readInbound error to an HTTP status
+func inboundStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrInvalidToken), errors.Is(err, ErrStaleRequest):
+		return http.StatusUnauthorized
+	case errors.Is(err, errMethodNotAllowed):
+		return http.StatusMethodNotAllowed
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,236 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrorClass is a coarse, low-cardinality classification of a failed send
+type ErrorClass string
+
+const (
+	ErrorClassNone        ErrorClass = ""
+	ErrorClassCanceled    ErrorClass = "canceled"     // Context canceled or deadline exceeded
+	ErrorClassRateLimited ErrorClass = "rate_limited" // Client-side limiter or Lark frequency limit
+	ErrorClassCircuitOpen ErrorClass = "circuit_open" // Short-circuited by the breaker
+	ErrorClassTransport   ErrorClass = "transport"    // Network failure or unreadable response
+	ErrorClassServer      ErrorClass = "server"       // HTTP 5xx from Lark
+	ErrorClassRejected    ErrorClass = "rejected"     // Lark refused the message (bad payload, signature, permissions...)
+	ErrorClassOther       ErrorClass = "other"
+)
+
+// ClassifyError returns the ErrorClass of a send error
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassCanceled
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return ErrorClassCircuitOpen
+	}
+	if IsRateLimited(err) {
+		return ErrorClassRateLimited
+	}
+
+	err = lastAttemptError(err)
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		return ErrorClassTransport
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.HTTPStatus >= http.StatusInternalServerError {
+			return ErrorClassServer
+		}
+		return ErrorClassRejected
+	}
+	return ErrorClassOther
+}
+
+// SendEvent describes one HTTP attempt to deliver a message
+type SendEvent struct {
+	Endpoint string        // Request URL; webhook URLs embed their token, so do not use it as a metric label
+	Attempt  int           // 1 for the first attempt, 2+ for retries
+	Level    LogLevel      // Level of the log message, if sent by a logger
+	Route    string        // Router rule that selected the client, if any
+	Latency  time.Duration // Time Lark took to answer (OnSuccess/OnFailure only)
+	Err      error         // Attempt error (OnFailure only)
+	Class    ErrorClass    // Classification of Err (OnFailure only)
+
+	// ShortCircuited is set on OnSendEnd events of sends refused before any request was made,
+	// because the circuit breaker was open or the fail-fast limiter had no token (Attempt is 0)
+	ShortCircuited bool
+}
+
+// Instrumentation receives delivery events from a client, e.g. to export metrics or traces
+type Instrumentation interface {
+	// OnAttempt is called before each attempt; the returned context is used for the request
+	OnAttempt(ctx context.Context, event SendEvent) context.Context
+	OnSuccess(ctx context.Context, event SendEvent)
+	OnFailure(ctx context.Context, event SendEvent)
+}
+
+// SendObserver is optionally implemented by an Instrumentation to also observe whole sends.
+// A send covers every attempt against one endpoint, so tracers can parent attempt spans under it.
+// Short-circuited sends are only reported to SendObservers, with no attempt in between.
+type SendObserver interface {
+	// OnSendStart is called before the first attempt; the returned context is passed to OnAttempt
+	OnSendStart(ctx context.Context, event SendEvent) context.Context
+	// OnSendEnd is called once the send succeeded or gave up, with Attempt set to the attempts made
+	OnSendEnd(ctx context.Context, event SendEvent)
+}
+
+// WithInstrumentation registers hooks called around every delivery attempt; repeating the option
+// adds instrumentations (e.g. metrics and tracing) instead of replacing them
+func WithInstrumentation(instrumentation Instrumentation) ClientOption {
+	return func(opts *ClientOptions) {
+		if opts.Instrumentation != nil {
+			opts.Instrumentation = MultiInstrumentation(opts.Instrumentation, instrumentation)
+			return
+		}
+		opts.Instrumentation = instrumentation
+	}
+}
+
+// multiInstrumentation fans events out to several instrumentations in order
+type multiInstrumentation []Instrumentation
+
+// MultiInstrumentation combines instrumentations into one. Each hook is called in order and receives
+// the context returned by the previous one; SendObserver hooks go to the members implementing it.
+func MultiInstrumentation(instrumentations ...Instrumentation) Instrumentation {
+	var multi multiInstrumentation
+	for _, inst := range instrumentations {
+		switch inst := inst.(type) {
+		case nil:
+		case multiInstrumentation:
+			multi = append(multi, inst...)
+		default:
+			multi = append(multi, inst)
+		}
+	}
+	return multi
+}
+
+func (m multiInstrumentation) OnAttempt(ctx context.Context, event SendEvent) context.Context {
+	for _, inst := range m {
+		if attemptCtx := inst.OnAttempt(ctx, event); attemptCtx != nil {
+			ctx = attemptCtx
+		}
+	}
+	return ctx
+}
+
+func (m multiInstrumentation) OnSuccess(ctx context.Context, event SendEvent) {
+	for _, inst := range m {
+		inst.OnSuccess(ctx, event)
+	}
+}
+
+func (m multiInstrumentation) OnFailure(ctx context.Context, event SendEvent) {
+	for _, inst := range m {
+		inst.OnFailure(ctx, event)
+	}
+}
+
+func (m multiInstrumentation) OnSendStart(ctx context.Context, event SendEvent) context.Context {
+	for _, inst := range m {
+		if observer, ok := inst.(SendObserver); ok {
+			if sendCtx := observer.OnSendStart(ctx, event); sendCtx != nil {
+				ctx = sendCtx
+			}
+		}
+	}
+	return ctx
+}
+
+func (m multiInstrumentation) OnSendEnd(ctx context.Context, event SendEvent) {
+	for _, inst := range m {
+		if observer, ok := inst.(SendObserver); ok {
+			observer.OnSendEnd(ctx, event)
+		}
+	}
+}
+
+type sendLabelsKey struct{}
+
+// sendLabels are the logger-level labels attached to SendEvents
+type sendLabels struct {
+	level LogLevel
+	route string
+}
+
+// withSendLabels annotates ctx with the level and route of the message being sent
+func withSendLabels(ctx context.Context, level LogLevel, route string) context.Context {
+	return context.WithValue(ctx, sendLabelsKey{}, sendLabels{level: level, route: route})
+}
+
+// newSendEvent builds a SendEvent carrying the labels attached to ctx
+func newSendEvent(ctx context.Context, endpoint string, attempt int) SendEvent {
+	event := SendEvent{Endpoint: endpoint, Attempt: attempt}
+	if labels, ok := ctx.Value(sendLabelsKey{}).(sendLabels); ok {
+		event.Level = labels.level
+		event.Route = labels.route
+	}
+	return event
+}
+
+// instrumentSend runs a whole send, reporting it to the Instrumentation if it is a SendObserver
+func (t *transport) instrumentSend(ctx context.Context, endpoint string, fn func(context.Context) (int, error)) error {
+	observer, ok := t.opts.Instrumentation.(SendObserver)
+	if !ok {
+		_, err := fn(ctx)
+		return err
+	}
+
+	event := newSendEvent(ctx, endpoint, 0)
+	if sendCtx := observer.OnSendStart(ctx, event); sendCtx != nil {
+		ctx = sendCtx
+	}
+
+	start := time.Now()
+	attempts, err := fn(ctx)
+	event.Attempt = attempts
+	event.Latency = time.Since(start)
+	if err != nil {
+		event.Err = err
+		event.Class = ClassifyError(err)
+		event.ShortCircuited = attempts == 0 && (errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrRateLimitExceeded))
+	}
+	observer.OnSendEnd(ctx, event)
+	return err
+}
+
+// reportUnsent reports a send that failed before any request was made, e.g. refused by an open breaker
+func (t *transport) reportUnsent(ctx context.Context, endpoint string, err error) {
+	_ = t.instrumentSend(ctx, endpoint, func(context.Context) (int, error) { return 0, err })
+}
+
+// instrumentAttempt runs one attempt, reporting it to the configured Instrumentation
+func (t *transport) instrumentAttempt(ctx context.Context, endpoint string, attempt int, fn func(context.Context) error) error {
+	inst := t.opts.Instrumentation
+	if inst == nil {
+		return fn(ctx)
+	}
+
+	event := newSendEvent(ctx, endpoint, attempt)
+	if attemptCtx := inst.OnAttempt(ctx, event); attemptCtx != nil {
+		ctx = attemptCtx
+	}
+
+	start := time.Now()
+	err := fn(ctx)
+	event.Latency = time.Since(start)
+
+	if err == nil {
+		inst.OnSuccess(ctx, event)
+		return nil
+	}
+	event.Err = err
+	event.Class = ClassifyError(err)
+	inst.OnFailure(ctx, event)
+	return err
+}
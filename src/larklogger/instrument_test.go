@@ -0,0 +1,221 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+// recordingInstrumentation records every event it receives
+type recordingInstrumentation struct {
+	mu       sync.Mutex
+	attempts []SendEvent
+	success  []SendEvent
+	failures []SendEvent
+}
+
+func (r *recordingInstrumentation) OnAttempt(ctx context.Context, event SendEvent) context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, event)
+	return ctx
+}
+
+func (r *recordingInstrumentation) OnSuccess(ctx context.Context, event SendEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.success = append(r.success, event)
+}
+
+func (r *recordingInstrumentation) OnFailure(ctx context.Context, event SendEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, event)
+}
+
+func TestInstrumentation(t *testing.T) {
+	t.Run("reports attempts, retries and latency", func(t *testing.T) {
+		srv := fakelark.NewServer(t)
+		srv.Script(fakelark.ServerError(http.StatusBadGateway), fakelark.Slow(5*time.Millisecond))
+
+		inst := &recordingInstrumentation{}
+		client := NewLarkClient(srv.WebhookURL(), WithInstrumentation(inst), WithRetry(2, time.Millisecond))
+		logger := NewLarkLogger(context.Background(), client)
+		logger.Error("boom", nil)
+
+		if len(inst.attempts) != 2 || inst.attempts[1].Attempt != 2 {
+			t.Fatalf("Expected 2 attempts, got %+v", inst.attempts)
+		}
+		if len(inst.failures) != 1 || inst.failures[0].Class != ErrorClassServer {
+			t.Errorf("Expected one server failure, got %+v", inst.failures)
+		}
+		if len(inst.success) != 1 || inst.success[0].Latency < 5*time.Millisecond {
+			t.Errorf("Expected one success with latency, got %+v", inst.success)
+		}
+		if inst.success[0].Level != LevelError {
+			t.Errorf("Expected level %s, got %s", LevelError, inst.success[0].Level)
+		}
+	})
+
+	t.Run("reports route name", func(t *testing.T) {
+		rec := fakelark.NewServer(t)
+
+		inst := &recordingInstrumentation{}
+		client := NewLarkClient(rec.WebhookURL(), WithInstrumentation(inst))
+		router, err := NewRouter(context.Background(), map[string]Sender{"ops": client},
+			WithRoute(RouteRule{Name: "errors", Match: RouteMatch{Levels: []LogLevel{LevelError}}, Clients: []string{"ops"}}),
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		router.Error("boom", nil)
+
+		if len(inst.success) != 1 || inst.success[0].Route != "errors" {
+			t.Errorf("Expected success on route errors, got %+v", inst.success)
+		}
+	})
+}
+
+// recordingSendObserver additionally records whole sends
+type recordingSendObserver struct {
+	recordingInstrumentation
+	sends []SendEvent
+}
+
+func (r *recordingSendObserver) OnSendStart(ctx context.Context, event SendEvent) context.Context {
+	return ctx
+}
+
+func (r *recordingSendObserver) OnSendEnd(ctx context.Context, event SendEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sends = append(r.sends, event)
+}
+
+func TestSendObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	inst := &recordingSendObserver{}
+	client := NewLarkClient(server.URL, WithInstrumentation(inst), WithRetry(3, time.Millisecond))
+	logger := NewLarkLogger(context.Background(), client)
+	logger.Warn("flaky", nil)
+
+	if len(inst.sends) != 1 {
+		t.Fatalf("Expected one send, got %+v", inst.sends)
+	}
+	send := inst.sends[0]
+	if send.Attempt != len(inst.attempts) || send.Attempt < 2 {
+		t.Errorf("Expected send to cover %d attempts, got %d", len(inst.attempts), send.Attempt)
+	}
+	if send.Err == nil || send.Class != ErrorClassServer || send.Level != LevelWarn {
+		t.Errorf("Expected failed warn send classified as server, got %+v", send)
+	}
+}
+
+func TestShortCircuitedSends(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Run("open breaker", func(t *testing.T) {
+		inst := &recordingSendObserver{}
+		client := NewLarkClient(server.URL, WithInstrumentation(inst), WithRetry(0, 0),
+			WithCircuitBreaker(CircuitBreakerOptions{MinRequests: 1, CoolDown: time.Hour}))
+
+		_ = client.SendText("trips the breaker")
+		if err := client.SendText("short-circuited"); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+		}
+
+		if len(inst.sends) != 2 || len(inst.attempts) != 1 {
+			t.Fatalf("Expected 2 sends and 1 attempt, got %d sends and %d attempts", len(inst.sends), len(inst.attempts))
+		}
+		send := inst.sends[1]
+		if !send.ShortCircuited || send.Attempt != 0 || send.Class != ErrorClassCircuitOpen {
+			t.Errorf("Expected a short-circuited circuit_open send, got %+v", send)
+		}
+		if inst.sends[0].ShortCircuited {
+			t.Error("Expected the failed request not to be marked short-circuited")
+		}
+	})
+
+	t.Run("fail-fast limiter", func(t *testing.T) {
+		inst := &recordingSendObserver{}
+		client := NewLarkClient(server.URL, WithInstrumentation(inst), WithRetry(0, 0),
+			WithRateLimit(0, 1), WithRateLimitPolicy(RateLimitFailFast))
+
+		_ = client.SendText("uses the quota")
+		if err := client.SendText("throttled"); !errors.Is(err, ErrRateLimitExceeded) {
+			t.Fatalf("Expected ErrRateLimitExceeded, got %v", err)
+		}
+
+		if len(inst.sends) != 2 {
+			t.Fatalf("Expected 2 sends, got %+v", inst.sends)
+		}
+		if send := inst.sends[1]; !send.ShortCircuited || send.Class != ErrorClassRateLimited {
+			t.Errorf("Expected a short-circuited rate_limited send, got %+v", send)
+		}
+	})
+}
+
+func TestMultiInstrumentation(t *testing.T) {
+	rec := fakelark.NewServer(t)
+
+	plain := &recordingInstrumentation{}
+	observer := &recordingSendObserver{}
+	client := NewLarkClient(rec.WebhookURL(), WithInstrumentation(plain), WithInstrumentation(observer))
+	if err := client.SendText("hello"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(plain.success) != 1 || len(observer.success) != 1 {
+		t.Errorf("Expected both instrumentations to see the attempt, got %d and %d", len(plain.success), len(observer.success))
+	}
+	if len(observer.sends) != 1 {
+		t.Errorf("Expected the send observer to see the send, got %d", len(observer.sends))
+	}
+
+	t.Run("flattens and skips nil", func(t *testing.T) {
+		multi := MultiInstrumentation(nil, MultiInstrumentation(plain, observer), plain)
+		if n := len(multi.(multiInstrumentation)); n != 3 {
+			t.Errorf("Expected 3 members, got %d", n)
+		}
+	})
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{nil, ErrorClassNone},
+		{context.Canceled, ErrorClassCanceled},
+		{ErrRateLimitExceeded, ErrorClassRateLimited},
+		{&APIError{Code: CodeFrequencyLimited}, ErrorClassRateLimited},
+		{&CircuitOpenError{State: CircuitOpen}, ErrorClassCircuitOpen},
+		{&TransportError{Op: "send request", Err: errors.New("refused")}, ErrorClassTransport},
+		{&APIError{HTTPStatus: http.StatusServiceUnavailable}, ErrorClassServer},
+		{&APIError{Code: CodeSignMismatch, HTTPStatus: http.StatusOK}, ErrorClassRejected},
+		{&RetryExhaustedError{Attempts: 1, Errors: []error{&APIError{HTTPStatus: 500}}}, ErrorClassServer},
+		{errors.New("boom"), ErrorClassOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.err), func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
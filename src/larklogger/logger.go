@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // LogLevel represents the log level
@@ -25,11 +26,24 @@ type Logger interface {
 	Errorf(title string, args ...interface{})
 }
 
+// Sender delivers log cards; *LarkClient and the target returned by AppClient.To implement it
+type Sender interface {
+	SendCardCtx(ctx context.Context, card *Card) error
+}
+
+// CardUpdater is implemented by senders that can update the cards they sent, enabling LarkLogger.Resolve
+type CardUpdater interface {
+	SendCardRefCtx(ctx context.Context, card *Card) (MessageRef, error)
+	UpdateCard(ctx context.Context, ref MessageRef, card *Card) error
+}
+
 // LarkLogger implements the Logger interface
 type LarkLogger struct {
-	client  *LarkClient
+	sender  Sender
 	opts    *LoggerConfig
 	baseCtx context.Context
+	queue   *asyncQueue // nil for synchronous delivery
+	route   string      // Router rule this logger serves, reported to Instrumentation
 }
 
 // LoggerConfig holds logger configuration
@@ -40,13 +54,48 @@ type LoggerConfig struct {
 	Title      string
 	ShowConfig bool     // Whether to show configuration section in logs
 	Buttons    []Button // Optional buttons to add to log cards
+
+	MentionRules    []MentionRule // Users to @mention, optionally per level
+	ResolveMentions bool          // Keep @mentions when Resolve re-renders a card (default: drop them so resolving does not page again)
+
+	TraceExtractor   TraceExtractor // Reads trace/span IDs from the log context
+	TraceURLTemplate string         // URL for the "Open trace" button, with {trace_id}/{span_id} placeholders
+
+	Template *LogTemplate // Render log cards from a Card Builder template (nil = built-in layout)
+
+	AsyncQueueSize int            // Queue size for async delivery (0 = synchronous)
+	AsyncWorkers   int            // Number of delivery goroutines in async mode
+	OverflowPolicy OverflowPolicy // What to do when the async queue is full
 }
 
 // LoggerOption is a function that configures the logger
 type LoggerOption func(*LoggerConfig)
 
-// NewLarkLogger creates a new LarkLogger instance
-func NewLarkLogger(ctx context.Context, client *LarkClient, opts ...LoggerOption) Logger {
+// NewLarkLogger creates a new LarkLogger instance delivering through sender (usually a *LarkClient)
+func NewLarkLogger(ctx context.Context, sender Sender, opts ...LoggerOption) Logger {
+	config := newLoggerConfig(opts...)
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := &LarkLogger{
+		sender:  sender,
+		opts:    config,
+		baseCtx: ctx,
+	}
+	if config.AsyncQueueSize > 0 {
+		logger.queue = newAsyncQueue(config.AsyncQueueSize, config.AsyncWorkers, config.OverflowPolicy, logger.deliver)
+	}
+	return logger
+}
+
+// NewAppLogger creates a LarkLogger that sends through the Open API to one chat or user
+func NewAppLogger(ctx context.Context, app *AppClient, to Receiver, opts ...LoggerOption) Logger {
+	return NewLarkLogger(ctx, app.To(to), opts...)
+}
+
+// newLoggerConfig applies options on top of the default logger configuration
+func newLoggerConfig(opts ...LoggerOption) *LoggerConfig {
 	config := &LoggerConfig{
 		Service:    "default-service",
 		Env:        "development",
@@ -59,15 +108,7 @@ func NewLarkLogger(ctx context.Context, client *LarkClient, opts ...LoggerOption
 	for _, opt := range opts {
 		opt(config)
 	}
-
-	if ctx == nil {
-		ctx = context.Background()
-	}
-	return &LarkLogger{
-		client:  client,
-		opts:    config,
-		baseCtx: ctx,
-	}
+	return config
 }
 
 // Info logs an info level message
@@ -98,6 +139,34 @@ func (l *LarkLogger) ErrorCtx(ctx context.Context, message string, fields map[st
 	l.logCtx(ctx, LevelError, message, fields)
 }
 
+// Log logs at level with the logger's base context
+func (l *LarkLogger) Log(level LogLevel, message string, fields map[string]interface{}) {
+	l.log(level, message, fields)
+}
+
+// LogCtx logs at level; adapters for other logging libraries use it to map their levels.
+// A nil ctx falls back to the logger's base context.
+func (l *LarkLogger) LogCtx(ctx context.Context, level LogLevel, message string, fields map[string]interface{}) {
+	if ctx == nil {
+		ctx = l.baseCtx
+	}
+	l.logCtx(ctx, level, message, fields)
+}
+
+// LogAt logs an event that happened at t with the logger's base context
+func (l *LarkLogger) LogAt(t time.Time, level LogLevel, message string, fields map[string]interface{}) {
+	l.logAtCtx(l.baseCtx, t, level, message, fields)
+}
+
+// LogAtCtx logs an event that happened at t, so the card shows when it was logged rather than
+// when it was delivered; adapters pass the time of the original record. A zero t means now.
+func (l *LarkLogger) LogAtCtx(ctx context.Context, t time.Time, level LogLevel, message string, fields map[string]interface{}) {
+	if ctx == nil {
+		ctx = l.baseCtx
+	}
+	l.logAtCtx(ctx, t, level, message, fields)
+}
+
 // Infof logs an info level message with formatted title and key-value pairs
 func (l *LarkLogger) Infof(title string, args ...interface{}) {
 	fields := l.parseKeyValuePairs(args...)
@@ -134,6 +203,11 @@ func (l *LarkLogger) ErrorfCtx(ctx context.Context, title string, args ...interf
 
 // parseKeyValuePairs parses alternating key-value pairs from args
 func (l *LarkLogger) parseKeyValuePairs(args ...interface{}) map[string]interface{} {
+	return parseKeyValuePairs(args...)
+}
+
+// parseKeyValuePairs parses alternating key-value pairs from args
+func parseKeyValuePairs(args ...interface{}) map[string]interface{} {
 	fields := make(map[string]interface{})
 
 	for i := 0; i < len(args); i += 2 {
@@ -159,20 +233,96 @@ func (l *LarkLogger) log(level LogLevel, message string, fields map[string]inter
 }
 
 func (l *LarkLogger) logCtx(ctx context.Context, level LogLevel, message string, fields map[string]interface{}) {
-	card := l.buildLogCard(level, message, fields)
-	if err := l.client.SendCardCtx(ctx, card); err != nil {
+	l.logAtCtx(ctx, time.Time{}, level, message, fields)
+}
+
+func (l *LarkLogger) logAtCtx(ctx context.Context, t time.Time, level LogLevel, message string, fields map[string]interface{}) {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	ctx = withSendLabels(ctx, level, l.route)
+	card := l.renderLogCardAt(level, message, l.opts.withTraceFields(ctx, fields), t, time.Time{})
+	if l.queue != nil {
+		l.queue.enqueue(ctx, card)
+		return
+	}
+	l.deliver(ctx, card)
+}
+
+// deliver sends a built card, reporting failures on stdout
+func (l *LarkLogger) deliver(ctx context.Context, card *Card) {
+	if _, err := l.send(ctx, card); err != nil {
 		// In a real implementation, you might want to fallback to console logging
 		fmt.Printf("Failed to send log to Lark: %v\n", err)
 	}
 }
 
+// send delivers a card through the sender; only a CardUpdater reports the message_id
+func (l *LarkLogger) send(ctx context.Context, card *Card) (MessageRef, error) {
+	if updater, ok := l.sender.(CardUpdater); ok {
+		return updater.SendCardRefCtx(ctx, card)
+	}
+	return MessageRef{SentAt: time.Now()}, l.sender.SendCardCtx(ctx, card)
+}
+
+// Flush waits until every queued message has been delivered; it is a no-op for synchronous loggers
+func (l *LarkLogger) Flush(ctx context.Context) error {
+	if l.queue == nil {
+		return nil
+	}
+	return l.queue.flush(ctx)
+}
+
+// Close drains the async queue and stops its workers; it is a no-op for synchronous loggers
+func (l *LarkLogger) Close(ctx context.Context) error {
+	if l.queue == nil {
+		return nil
+	}
+	return l.queue.close(ctx)
+}
+
+// AsyncStats returns async delivery counters; all zero for synchronous loggers
+func (l *LarkLogger) AsyncStats() AsyncStats {
+	if l.queue == nil {
+		return AsyncStats{}
+	}
+	return l.queue.stats()
+}
+
 // buildLogCard builds a Lark card for the log message using enhanced design
 func (l *LarkLogger) buildLogCard(level LogLevel, message string, fields map[string]interface{}) *Card {
+	return l.renderLogCardAt(level, message, fields, time.Now(), time.Time{})
+}
+
+// renderLogCardAt builds a log card timestamped at t; a non-zero resolvedAt renders it as resolved,
+// with a green header in place of the level colour
+func (l *LarkLogger) renderLogCardAt(level LogLevel, message string, fields map[string]interface{}, t, resolvedAt time.Time) *Card {
+	resolved := !resolvedAt.IsZero()
+	mentions, fields := l.opts.mentionsFor(level, fields)
+	if resolved && !l.opts.ResolveMentions {
+		mentions = nil
+	}
+	if l.opts.Template != nil {
+		return l.renderTemplateCard(LogEntry{
+			Level:      level,
+			Message:    message,
+			Fields:     fields,
+			Mentions:   mentions,
+			Resolved:   resolved,
+			ResolvedAt: resolvedAt,
+			Time:       t,
+		})
+	}
+
 	emoji := GetLogLevelEmoji(level)
 	template := getVisualConfig(level)
 
 	// Build main title with custom title and emoji
 	mainTitle := fmt.Sprintf("%s %s", emoji, l.opts.Title)
+	if resolved {
+		template = ColorGreen
+		mainTitle = fmt.Sprintf("%s %s · Resolved", EmojiResolved, l.opts.Title)
+	}
 
 	// Create enhanced card builder
 	builder := NewCardBuilder().SetHeader(mainTitle, template)
@@ -187,11 +337,18 @@ func (l *LarkLogger) buildLogCard(level LogLevel, message string, fields map[str
 	case LevelError:
 		subtitleEmoji = "🚨"
 	}
+	if resolved {
+		subtitleEmoji = EmojiResolved
+	}
 	subtitle := fmt.Sprintf("%s %s", subtitleEmoji, message)
 	builder.AddSubtitle(subtitle)
+	builder.AddMentions(mentions)
 
 	// Add timestamp
-	builder.AddTimestamp()
+	builder.AddTimestampAt(t)
+	if resolved {
+		builder.AddResolvedAt(resolvedAt)
+	}
 
 	// Add configuration section only if ShowConfig is enabled
 	if l.opts.ShowConfig {
@@ -221,12 +378,17 @@ func (l *LarkLogger) buildLogCard(level LogLevel, message string, fields map[str
 	}
 
 	// Add buttons if configured
-	if len(l.opts.Buttons) > 0 {
+	buttons := l.opts.Buttons
+	if button, ok := l.opts.traceButton(fields); ok {
+		buttons = append(append([]Button{}, buttons...), button)
+	}
+	if len(buttons) > 0 {
 		builder.AddDivider()
-		builder.AddButtons(l.opts.Buttons)
+		builder.AddButtons(buttons)
 	}
 
-	return builder.Build()
+	// The client trims the card to its own payload limit and reports what it dropped
+	return builder.build()
 }
 
 // Logger option functions
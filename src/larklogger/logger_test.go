@@ -2,9 +2,9 @@ package larklogger
 
 import (
 	"context"
-	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewLarkLogger(t *testing.T) {
@@ -88,6 +88,20 @@ func TestLarkLoggerBuildLogCard(t *testing.T) {
 		}
 	})
 
+	t.Run("card timestamped at the event time", func(t *testing.T) {
+		var card *Card
+		sender := senderFunc(func(ctx context.Context, c *Card) error {
+			card = c
+			return nil
+		})
+		at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.Local)
+		NewLarkLogger(context.Background(), sender).(*LarkLogger).LogAtCtx(context.Background(), at, LevelInfo, "Backfilled", nil)
+
+		if card == nil || !contains(cardMarkdown(card), "2024-03-01 12:30:00") {
+			t.Errorf("Expected the event time on the card, got %+v", card)
+		}
+	})
+
 	t.Run("error level card", func(t *testing.T) {
 		larkLogger := logger.(*LarkLogger)
 		card := larkLogger.buildLogCard(LevelError, "Database error", nil)
@@ -170,17 +184,11 @@ func TestLoggerOptions(t *testing.T) {
 }
 
 func TestLoggerInfof(t *testing.T) {
-	// Skip tests that would send webhook (default local: GetConfig sets test URL)
-	if os.Getenv("LARK_TEST_MODE") == "true" || IsTestEnvironment() || strings.Contains(GetWebhookURL(), "test.webhook.url") {
-		t.Skip("Skipping mock webhook tests")
-	}
-
-	client := NewLarkClient("https://test.webhook.url")
+	recorder := NewRecorder()
+	client := NewLarkClient(TestWebhookURL, WithRecorder(recorder))
 	logger := NewLarkLogger(context.Background(), client, WithTitle("Test Logger"))
 
 	t.Run("infof with key-value pairs", func(t *testing.T) {
-		// This will test the Infof method
-		// Note: In a real test, you might want to mock the client
 		logger.Infof("Service started", "port", 8080, "version", "1.0.0")
 	})
 
@@ -191,4 +199,12 @@ func TestLoggerInfof(t *testing.T) {
 	t.Run("errorf with key-value pairs", func(t *testing.T) {
 		logger.Errorf("Database error", "error", "connection timeout", "retry_count", 3)
 	})
+
+	cards := recorder.Recorded()
+	if len(cards) != 3 {
+		t.Fatalf("Expected 3 recorded cards, got %d", len(cards))
+	}
+	if md := cardMarkdown(cards[2]); !strings.Contains(md, "Database error") {
+		t.Errorf("Expected last card to be the error, got %s", md)
+	}
 }
@@ -0,0 +1,188 @@
+package larklogger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MentionKind tells how a Mention identifies its target
+type MentionKind string
+
+const (
+	MentionKindOpenID MentionKind = "open_id"
+	MentionKindUserID MentionKind = "user_id"
+	MentionKindEmail  MentionKind = "email"
+	MentionKindAll    MentionKind = "all"
+)
+
+// Mention @mentions a user, or everyone in the chat, so they get notified.
+// A Mention (or []Mention) passed as a field value is rendered as a mention rather than a row.
+type Mention struct {
+	Kind MentionKind
+	ID   string
+	Name string // Optional display name for text messages
+}
+
+// MentionOpenID mentions a user by open_id
+func MentionOpenID(openID string) Mention {
+	return Mention{Kind: MentionKindOpenID, ID: openID}
+}
+
+// MentionUserID mentions a user by user_id
+func MentionUserID(userID string) Mention {
+	return Mention{Kind: MentionKindUserID, ID: userID}
+}
+
+// MentionEmail mentions a user by email
+func MentionEmail(email string) Mention {
+	return Mention{Kind: MentionKindEmail, ID: email}
+}
+
+// MentionAll mentions everyone in the chat
+func MentionAll() Mention {
+	return Mention{Kind: MentionKindAll, ID: "all"}
+}
+
+// MentionRule mentions users on messages of the given levels
+type MentionRule struct {
+	Levels   []LogLevel // Levels the rule applies to (empty = every level)
+	Mentions []Mention
+}
+
+// WithMentions mentions users on every log card
+func WithMentions(mentions ...Mention) LoggerOption {
+	return func(c *LoggerConfig) {
+		c.MentionRules = append(c.MentionRules, MentionRule{Mentions: mentions})
+	}
+}
+
+// WithLevelMentions mentions users only on log cards of the given level, e.g. on-call for LevelError
+func WithLevelMentions(level LogLevel, mentions ...Mention) LoggerOption {
+	return func(c *LoggerConfig) {
+		c.MentionRules = append(c.MentionRules, MentionRule{Levels: []LogLevel{level}, Mentions: mentions})
+	}
+}
+
+// WithResolveMentions keeps @mentions on cards re-rendered by Resolve; by default they are dropped
+// so that resolving an incident does not notify on-call again
+func WithResolveMentions() LoggerOption {
+	return func(c *LoggerConfig) {
+		c.ResolveMentions = true
+	}
+}
+
+// markdown renders the mention for lark_md card content
+func (m Mention) markdown() string {
+	switch m.Kind {
+	case MentionKindEmail:
+		return fmt.Sprintf("<at email=%s></at>", m.ID)
+	case MentionKindAll:
+		return "<at id=all></at>"
+	default:
+		return fmt.Sprintf("<at id=%s></at>", m.ID)
+	}
+}
+
+// text renders the mention for text messages
+func (m Mention) text() string {
+	switch m.Kind {
+	case MentionKindEmail:
+		return fmt.Sprintf(`<at email="%s">%s</at>`, m.ID, m.Name)
+	case MentionKindAll:
+		name := m.Name
+		if name == "" {
+			name = "all"
+		}
+		return fmt.Sprintf(`<at user_id="all">%s</at>`, name)
+	default:
+		return fmt.Sprintf(`<at user_id="%s">%s</at>`, m.ID, m.Name)
+	}
+}
+
+// withMentionText appends text-message mention tags to text
+func withMentionText(text string, mentions []Mention) string {
+	mentions = dedupeMentions(mentions)
+	if len(mentions) == 0 {
+		return text
+	}
+	tags := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		tags = append(tags, m.text())
+	}
+	return text + " " + strings.Join(tags, " ")
+}
+
+// dedupeMentions drops empty and repeated mentions, keeping the first occurrence
+func dedupeMentions(mentions []Mention) []Mention {
+	var out []Mention
+	seen := make(map[Mention]bool)
+	for _, m := range mentions {
+		key := Mention{Kind: m.Kind, ID: m.ID}
+		if m.ID == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, m)
+	}
+	return out
+}
+
+// mentionsFor returns the configured mentions for level followed by any passed as field values;
+// the returned fields no longer contain mention values
+func (c *LoggerConfig) mentionsFor(level LogLevel, fields map[string]interface{}) ([]Mention, map[string]interface{}) {
+	var mentions []Mention
+	for _, rule := range c.MentionRules {
+		if len(rule.Levels) == 0 || containsValue(rule.Levels, level) {
+			mentions = append(mentions, rule.Mentions...)
+		}
+	}
+
+	rest := fields
+	for key, value := range fields {
+		var found []Mention
+		switch v := value.(type) {
+		case Mention:
+			found = []Mention{v}
+		case []Mention:
+			found = v
+		default:
+			continue
+		}
+		if len(rest) == len(fields) {
+			// Copy on first mention so the caller's map is left untouched
+			rest = make(map[string]interface{}, len(fields))
+			for k, v := range fields {
+				rest[k] = v
+			}
+		}
+		delete(rest, key)
+		mentions = append(mentions, found...)
+	}
+
+	return dedupeMentions(mentions), rest
+}
+
+// AddMentions adds a line of @mentions to the card
+func (cb *CardBuilder) AddMentions(mentions []Mention) *CardBuilder {
+	mentions = dedupeMentions(mentions)
+	if len(mentions) == 0 {
+		return cb
+	}
+
+	tags := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		tags = append(tags, m.markdown())
+	}
+
+	cb.card.Card.Elements = append(cb.card.Card.Elements, Element{
+		Tag: "div",
+		Text: &Text{
+			Tag:        "lark_md",
+			Content:    strings.Join(tags, " "),
+			LineHeight: cb.getLineHeight(),
+		},
+		Padding:   cb.getPadding(),
+		TextAlign: "left",
+	})
+	return cb
+}
@@ -0,0 +1,113 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+// cardMarkdown concatenates every lark_md text of a card
+func cardMarkdown(card *Card) string {
+	var parts []string
+	for _, el := range card.Card.Elements {
+		if el.Text != nil {
+			parts = append(parts, el.Text.Content)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func TestMentionRendering(t *testing.T) {
+	tests := []struct {
+		mention  Mention
+		markdown string
+		text     string
+	}{
+		{MentionOpenID("ou_1"), "<at id=ou_1></at>", `<at user_id="ou_1"></at>`},
+		{MentionUserID("u_1"), "<at id=u_1></at>", `<at user_id="u_1"></at>`},
+		{MentionEmail("a@example.com"), "<at email=a@example.com></at>", `<at email="a@example.com"></at>`},
+		{MentionAll(), "<at id=all></at>", `<at user_id="all">all</at>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mention.Kind), func(t *testing.T) {
+			if got := tt.mention.markdown(); got != tt.markdown {
+				t.Errorf("Expected markdown %s, got %s", tt.markdown, got)
+			}
+			if got := tt.mention.text(); got != tt.text {
+				t.Errorf("Expected text %s, got %s", tt.text, got)
+			}
+		})
+	}
+}
+
+func TestLoggerMentions(t *testing.T) {
+	t.Run("level rules", func(t *testing.T) {
+		logger := NewLarkLogger(context.Background(), nil,
+			WithMentions(MentionOpenID("ou_team")),
+			WithLevelMentions(LevelError, MentionEmail("oncall@example.com")),
+		).(*LarkLogger)
+
+		info := cardMarkdown(logger.buildLogCard(LevelInfo, "ok", nil))
+		if !contains(info, "<at id=ou_team></at>") {
+			t.Error("Expected info card to mention the team")
+		}
+		if contains(info, "oncall@example.com") {
+			t.Error("Expected info card not to mention on-call")
+		}
+
+		errCard := cardMarkdown(logger.buildLogCard(LevelError, "down", nil))
+		if !contains(errCard, "<at email=oncall@example.com></at>") {
+			t.Error("Expected error card to mention on-call")
+		}
+	})
+
+	t.Run("per-call mentions from fields", func(t *testing.T) {
+		logger := NewLarkLogger(context.Background(), nil, WithLevelMentions(LevelError, MentionOpenID("ou_1"))).(*LarkLogger)
+
+		fields := map[string]interface{}{
+			"db": "primary",
+			"cc": []Mention{MentionUserID("u_2"), MentionOpenID("ou_1")},
+		}
+		card := logger.buildLogCard(LevelError, "down", fields)
+		md := cardMarkdown(card)
+
+		if !contains(md, "<at id=u_2></at>") {
+			t.Error("Expected per-call mention to be rendered")
+		}
+		if strings.Count(md, "<at id=ou_1></at>") != 1 {
+			t.Error("Expected duplicate mention to be rendered once")
+		}
+
+		data, _ := json.Marshal(card)
+		if contains(string(data), `"cc"`) {
+			t.Error("Expected mention field not to be rendered as a row")
+		}
+		if _, ok := fields["cc"]; !ok {
+			t.Error("Expected caller's fields to be left untouched")
+		}
+	})
+
+	t.Run("no mentions adds no element", func(t *testing.T) {
+		logger := NewLarkLogger(context.Background(), nil).(*LarkLogger)
+		if contains(cardMarkdown(logger.buildLogCard(LevelError, "down", nil)), "<at") {
+			t.Error("Expected no mention tags")
+		}
+	})
+}
+
+func TestSendTextMentions(t *testing.T) {
+	srv := fakelark.NewServer(t)
+	client := NewLarkClient(srv.WebhookURL())
+	if err := client.SendText("disk full", MentionAll(), MentionOpenID("ou_1")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := `disk full <at user_id="all">all</at> <at user_id="ou_1"></at>`
+	if got := srv.Messages()[0].Text; got != expected {
+		t.Errorf("Expected text %s, got %s", expected, got)
+	}
+}
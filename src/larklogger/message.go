@@ -0,0 +1,74 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoMessageID is returned when updating a message whose message_id is unknown,
+// e.g. one sent through a webhook, which does not report it
+var ErrNoMessageID = errors.New("message reference has no message_id")
+
+// MessageRef identifies a sent message so it can be updated later; it is safe to persist as JSON
+type MessageRef struct {
+	MessageID string    `json:"message_id"`
+	SentAt    time.Time `json:"sent_at"`
+
+	// Original log message, set by LarkLogger.Notify so Resolve can re-render the card
+	Level   LogLevel               `json:"level,omitempty"`
+	Message string                 `json:"message,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Time    time.Time              `json:"time,omitempty"` // Timestamp of the original card
+}
+
+// Notify sends a log card synchronously, bypassing the async queue, and returns a reference to it
+func (l *LarkLogger) Notify(ctx context.Context, level LogLevel, message string, fields map[string]interface{}) (MessageRef, error) {
+	fields = l.opts.withTraceFields(ctx, fields)
+	now := time.Now()
+	ref, err := l.send(withSendLabels(ctx, level, l.route), l.renderLogCardAt(level, message, fields, now, time.Time{}))
+	if err != nil {
+		return MessageRef{}, err
+	}
+	ref.Level = level
+	ref.Message = message
+	ref.Fields = fields
+	ref.Time = now
+	return ref, nil
+}
+
+// Resolve re-renders the card behind ref with a green "resolved" header; the card keeps its original
+// timestamp and gains a row with the resolution time, plus the alert's duration as a field
+func (l *LarkLogger) Resolve(ref MessageRef, fields map[string]interface{}) error {
+	return l.ResolveCtx(l.baseCtx, ref, fields)
+}
+
+// ResolveCtx is Resolve with a context; the logger's Sender must implement CardUpdater
+func (l *LarkLogger) ResolveCtx(ctx context.Context, ref MessageRef, fields map[string]interface{}) error {
+	updater, ok := l.sender.(CardUpdater)
+	if !ok || ref.MessageID == "" {
+		return ErrNoMessageID
+	}
+
+	resolvedAt := time.Now()
+	loggedAt := ref.Time
+	if loggedAt.IsZero() {
+		// Refs persisted before Time was recorded
+		loggedAt = ref.SentAt
+	}
+
+	merged := make(map[string]interface{}, len(ref.Fields)+len(fields)+1)
+	for k, v := range ref.Fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	if loggedAt.IsZero() {
+		loggedAt = resolvedAt
+	} else {
+		merged["duration"] = resolvedAt.Sub(loggedAt).Round(time.Second).String()
+	}
+
+	return updater.UpdateCard(ctx, ref, l.renderLogCardAt(ref.Level, ref.Message, merged, loggedAt, resolvedAt))
+}
@@ -0,0 +1,156 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+func TestLoggerResolve(t *testing.T) {
+	t.Run("re-renders card as resolved", func(t *testing.T) {
+		srv, app := newFakeApp(t)
+		logger := NewAppLogger(context.Background(), app, ToChat("oc_1"), WithTitle("Payments")).(*LarkLogger)
+
+		ref, err := logger.Notify(context.Background(), LevelError, "DB down", map[string]interface{}{"db": "primary"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if ref.MessageID == "" || ref.Level != LevelError || ref.Message != "DB down" || ref.Time.IsZero() {
+			t.Errorf("Expected ref with message_id and original message, got %+v", ref)
+		}
+
+		// The alert was raised a while ago; the resolved card keeps that time
+		ref.Time = time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+		ref.SentAt = ref.Time
+		before := time.Now()
+		if err := logger.Resolve(ref, map[string]interface{}{"fix": "failover"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		updates := updatesFor(srv, ref.MessageID)
+		if len(updates) != 1 {
+			t.Fatalf("Expected 1 update, got %d", len(updates))
+		}
+		var content CardData
+		if err := json.Unmarshal(updates[0].Card, &content); err != nil {
+			t.Fatalf("Expected card JSON content, got error: %v", err)
+		}
+		if content.Header.Template != ColorGreen {
+			t.Errorf("Expected template %s, got %s", ColorGreen, content.Header.Template)
+		}
+		if !contains(content.Header.Title.Content, "Resolved") {
+			t.Errorf("Expected resolved title, got %s", content.Header.Title.Content)
+		}
+		card := string(updates[0].Card)
+		for _, want := range []string{"DB down", "primary", "failover", FormatTimestamp(ref.Time), "duration"} {
+			if !contains(card, want) {
+				t.Errorf("Expected resolved card to contain %q", want)
+			}
+		}
+
+		var timeRows []string
+		for _, el := range content.Elements {
+			if el.Text != nil && el.TextAlign == "right" {
+				timeRows = append(timeRows, el.Text.Content)
+			}
+		}
+		if len(timeRows) != 2 || !contains(timeRows[0], FormatTimestamp(ref.Time)) || !contains(timeRows[1], "Resolved") {
+			t.Fatalf("Expected the original timestamp followed by a resolved row, got %q", timeRows)
+		}
+		if !contains(timeRows[1], FormatTimestamp(before)) && !contains(timeRows[1], FormatTimestamp(time.Now())) {
+			t.Errorf("Expected the resolved row to show the resolution time, got %q", timeRows[1])
+		}
+		if contains(card, "resolved_at") {
+			t.Error("Expected the resolution time as its own row, not a field")
+		}
+	})
+
+	t.Run("refs without time fall back to sent_at", func(t *testing.T) {
+		srv, app := newFakeApp(t)
+		logger := NewAppLogger(context.Background(), app, ToChat("oc_1")).(*LarkLogger)
+
+		ref, err := logger.Notify(context.Background(), LevelWarn, "Disk 90%", nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		ref.Time = time.Time{}
+		ref.SentAt = time.Now().Add(-90 * time.Second)
+		if err := logger.Resolve(ref, nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		card := string(updatesFor(srv, ref.MessageID)[0].Card)
+		if !contains(card, FormatTimestamp(ref.SentAt)) || !contains(card, "1m30s") {
+			t.Errorf("Expected the card timestamped at sent_at with its duration, got %s", card)
+		}
+	})
+
+	t.Run("resolved card drops mentions unless kept", func(t *testing.T) {
+		for _, keep := range []bool{false, true} {
+			srv, app := newFakeApp(t)
+			opts := []LoggerOption{WithLevelMentions(LevelError, MentionUserID("ou_oncall"))}
+			if keep {
+				opts = append(opts, WithResolveMentions())
+			}
+			logger := NewAppLogger(context.Background(), app, ToChat("oc_1"), opts...).(*LarkLogger)
+
+			ref, err := logger.Notify(context.Background(), LevelError, "DB down",
+				map[string]interface{}{"owner": MentionUserID("ou_owner")})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := logger.Resolve(ref, nil); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			updates := updatesFor(srv, ref.MessageID)
+			if len(updates) != 1 {
+				t.Fatalf("Expected 1 update, got %d", len(updates))
+			}
+			for _, id := range []string{"ou_oncall", "ou_owner"} {
+				if contains(string(updates[0].Card), id) != keep {
+					t.Errorf("Expected mention %s kept=%v on the resolved card, got %s", id, keep, updates[0].Card)
+				}
+			}
+		}
+	})
+
+	t.Run("ref survives JSON round trip", func(t *testing.T) {
+		srv, app := newFakeApp(t)
+		logger := NewAppLogger(context.Background(), app, ToChat("oc_1")).(*LarkLogger)
+
+		ref, err := logger.Notify(context.Background(), LevelWarn, "Disk 90%", nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		data, _ := json.Marshal(ref)
+		var restored MessageRef
+		if err := json.Unmarshal(data, &restored); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := logger.Resolve(restored, nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(updatesFor(srv, ref.MessageID)) != 1 {
+			t.Error("Expected restored ref to be updatable")
+		}
+	})
+
+	t.Run("webhook logger cannot resolve", func(t *testing.T) {
+		rec := fakelark.NewServer(t)
+		logger := NewLarkLogger(context.Background(), NewLarkClient(rec.WebhookURL())).(*LarkLogger)
+
+		ref, err := logger.Notify(context.Background(), LevelError, "boom", nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := logger.Resolve(ref, nil); !errors.Is(err, ErrNoMessageID) {
+			t.Errorf("Expected ErrNoMessageID, got %v", err)
+		}
+	})
+}
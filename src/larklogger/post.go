@@ -0,0 +1,146 @@
+package larklogger
+
+// Post languages
+const (
+	LangZhCN = "zh_cn"
+	LangEnUS = "en_us"
+	LangJaJP = "ja_jp"
+)
+
+// Post text styles
+const (
+	PostStyleBold          = "bold"
+	PostStyleItalic        = "italic"
+	PostStyleUnderline     = "underline"
+	PostStyleStrikethrough = "lineThrough"
+)
+
+// Post represents a Lark rich-text "post" message
+type Post struct {
+	MsgType string      `json:"msg_type"`
+	Content PostMessage `json:"content"`
+}
+
+// PostMessage holds the post in every language it was written in
+type PostMessage struct {
+	Post map[string]*PostContent `json:"post"`
+}
+
+// PostContent is the post body for one language
+type PostContent struct {
+	Title   string          `json:"title,omitempty"`
+	Content [][]PostElement `json:"content"` // Paragraphs of inline elements
+}
+
+// PostElement is one inline element of a post paragraph
+type PostElement struct {
+	Tag       string   `json:"tag"`
+	Text      string   `json:"text,omitempty"`
+	Href      string   `json:"href,omitempty"`
+	UserID    string   `json:"user_id,omitempty"`
+	UserName  string   `json:"user_name,omitempty"`
+	ImageKey  string   `json:"image_key,omitempty"`
+	EmojiType string   `json:"emoji_type,omitempty"`
+	Language  string   `json:"language,omitempty"`
+	Style     []string `json:"style,omitempty"`
+}
+
+// PostText creates a text element with optional styles
+func PostText(text string, styles ...string) PostElement {
+	return PostElement{Tag: "text", Text: text, Style: styles}
+}
+
+// PostLink creates a hyperlink element
+func PostLink(text, href string) PostElement {
+	return PostElement{Tag: "a", Text: text, Href: href}
+}
+
+// PostAt creates an @mention of a user by open_id or user_id
+func PostAt(userID string) PostElement {
+	return PostElement{Tag: "at", UserID: userID}
+}
+
+// PostAtAll creates an @all mention
+func PostAtAll() PostElement {
+	return PostElement{Tag: "at", UserID: "all"}
+}
+
+// PostImage creates an image element from an uploaded image_key
+func PostImage(imageKey string) PostElement {
+	return PostElement{Tag: "img", ImageKey: imageKey}
+}
+
+// PostEmotion creates an emoji element, e.g. "SMILE" or "THUMBSUP"
+func PostEmotion(emojiType string) PostElement {
+	return PostElement{Tag: "emotion", EmojiType: emojiType}
+}
+
+// PostCodeBlock creates a code block element; language is e.g. "GO" or "JSON"
+func PostCodeBlock(language, code string) PostElement {
+	return PostElement{Tag: "code_block", Language: language, Text: code}
+}
+
+// PostBuilder helps build rich-text posts, optionally in several languages
+type PostBuilder struct {
+	post    *Post
+	current *PostContent
+}
+
+// NewPostBuilder creates a new post builder writing in LangZhCN
+func NewPostBuilder() *PostBuilder {
+	pb := &PostBuilder{
+		post: &Post{
+			MsgType: "post",
+			Content: PostMessage{Post: make(map[string]*PostContent)},
+		},
+	}
+	return pb.SetLanguage(LangZhCN)
+}
+
+// SetLanguage switches the language subsequent calls write to
+func (pb *PostBuilder) SetLanguage(lang string) *PostBuilder {
+	content, ok := pb.post.Content.Post[lang]
+	if !ok {
+		content = &PostContent{Content: [][]PostElement{}}
+		pb.post.Content.Post[lang] = content
+	}
+	pb.current = content
+	return pb
+}
+
+// SetTitle sets the title for the current language
+func (pb *PostBuilder) SetTitle(title string) *PostBuilder {
+	pb.current.Title = title
+	return pb
+}
+
+// AddParagraph adds a paragraph of inline elements
+func (pb *PostBuilder) AddParagraph(elements ...PostElement) *PostBuilder {
+	pb.current.Content = append(pb.current.Content, elements)
+	return pb
+}
+
+// AddText adds a paragraph of plain text
+func (pb *PostBuilder) AddText(text string, styles ...string) *PostBuilder {
+	return pb.AddParagraph(PostText(text, styles...))
+}
+
+// AddCodeBlock adds a code block in its own paragraph
+func (pb *PostBuilder) AddCodeBlock(language, code string) *PostBuilder {
+	return pb.AddParagraph(PostCodeBlock(language, code))
+}
+
+// AddDivider adds a horizontal rule
+func (pb *PostBuilder) AddDivider() *PostBuilder {
+	return pb.AddParagraph(PostElement{Tag: "hr"})
+}
+
+// Build returns the final post; languages left empty are omitted
+func (pb *PostBuilder) Build() *Post {
+	for lang, content := range pb.post.Content.Post {
+		if content.Title == "" && len(content.Content) == 0 && len(pb.post.Content.Post) > 1 {
+			delete(pb.post.Content.Post, lang)
+		}
+	}
+	return pb.post
+}
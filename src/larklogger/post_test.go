@@ -0,0 +1,135 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+func TestPostBuilder(t *testing.T) {
+	t.Run("builds documented schema", func(t *testing.T) {
+		post := NewPostBuilder().
+			SetTitle("Deploy finished").
+			AddParagraph(
+				PostText("Service ", PostStyleBold),
+				PostLink("payments", "https://example.com/payments"),
+				PostText(" is live "),
+				PostEmotion("THUMBSUP"),
+				PostAt("ou_123"),
+			).
+			AddCodeBlock("GO", "fmt.Println(\"ok\")").
+			AddParagraph(PostImage("img_v2_abc")).
+			Build()
+
+		data, err := json.Marshal(post)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if decoded["msg_type"] != "post" {
+			t.Errorf("Expected msg_type post, got %v", decoded["msg_type"])
+		}
+
+		zh := decoded["content"].(map[string]interface{})["post"].(map[string]interface{})[LangZhCN].(map[string]interface{})
+		if zh["title"] != "Deploy finished" {
+			t.Errorf("Expected title, got %v", zh["title"])
+		}
+
+		paragraphs := zh["content"].([]interface{})
+		if len(paragraphs) != 3 {
+			t.Fatalf("Expected 3 paragraphs, got %d", len(paragraphs))
+		}
+
+		first := paragraphs[0].([]interface{})
+		expectedTags := []string{"text", "a", "text", "emotion", "at"}
+		for i, tag := range expectedTags {
+			if got := first[i].(map[string]interface{})["tag"]; got != tag {
+				t.Errorf("Expected element %d tag %s, got %v", i, tag, got)
+			}
+		}
+		if style := first[0].(map[string]interface{})["style"].([]interface{}); style[0] != "bold" {
+			t.Errorf("Expected bold style, got %v", style)
+		}
+		if href := first[1].(map[string]interface{})["href"]; href != "https://example.com/payments" {
+			t.Errorf("Expected href, got %v", href)
+		}
+		if user := first[4].(map[string]interface{})["user_id"]; user != "ou_123" {
+			t.Errorf("Expected user_id ou_123, got %v", user)
+		}
+
+		code := paragraphs[1].([]interface{})[0].(map[string]interface{})
+		if code["tag"] != "code_block" || code["language"] != "GO" {
+			t.Errorf("Expected GO code_block, got %v", code)
+		}
+	})
+
+	t.Run("supports multiple languages", func(t *testing.T) {
+		post := NewPostBuilder().
+			SetTitle("发布完成").AddText("支付服务已上线").
+			SetLanguage(LangEnUS).
+			SetTitle("Deploy finished").AddParagraph(PostText("payments is live"), PostAtAll()).
+			Build()
+
+		if len(post.Content.Post) != 2 {
+			t.Fatalf("Expected 2 languages, got %d", len(post.Content.Post))
+		}
+		if post.Content.Post[LangEnUS].Content[0][1].UserID != "all" {
+			t.Errorf("Expected @all in en_us, got %+v", post.Content.Post[LangEnUS].Content[0][1])
+		}
+	})
+
+	t.Run("omits empty default language", func(t *testing.T) {
+		post := NewPostBuilder().SetLanguage(LangEnUS).AddText("hello").Build()
+
+		if _, ok := post.Content.Post[LangZhCN]; ok {
+			t.Error("Expected empty zh_cn to be omitted")
+		}
+		if _, ok := post.Content.Post[LangEnUS]; !ok {
+			t.Error("Expected en_us to be present")
+		}
+	})
+}
+
+func TestLarkClientSendPost(t *testing.T) {
+	srv := fakelark.NewServer(t)
+	client := NewLarkClient(srv.WebhookURL())
+	post := NewPostBuilder().SetTitle("Hello").AddText("world").Build()
+
+	if err := client.SendPostCtx(context.Background(), post); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	msg := srv.Messages()[0]
+	var content map[string]*PostContent
+	_ = json.Unmarshal(msg.Post, &content)
+	if msg.MsgType != "post" || content[LangZhCN] == nil || content[LangZhCN].Title != "Hello" {
+		t.Errorf("Expected post with title Hello, got %s", msg.Raw)
+	}
+}
+
+func TestAppClientSendPost(t *testing.T) {
+	srv, client := newFakeApp(t)
+
+	post := NewPostBuilder().SetTitle("Hello").AddText("world").Build()
+	if _, err := client.SendPost(ToChat("oc_1"), post); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	msg := srv.Messages()[0]
+	if msg.MsgType != "post" {
+		t.Errorf("Expected msg_type post, got %s", msg.MsgType)
+	}
+
+	var content map[string]*PostContent
+	if err := json.Unmarshal(msg.Post, &content); err != nil {
+		t.Fatalf("Expected language map content, got error: %v", err)
+	}
+	if content[LangZhCN] == nil || content[LangZhCN].Title != "Hello" {
+		t.Errorf("Expected zh_cn title Hello, got %s", msg.Post)
+	}
+}
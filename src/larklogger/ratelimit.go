@@ -0,0 +1,172 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Default Lark custom bot quotas
+const (
+	DefaultRateLimitPerSecond = 5
+	DefaultRateLimitPerMinute = 100
+)
+
+// RateLimitPolicy controls what a send does when the client-side quota is used up
+type RateLimitPolicy int
+
+const (
+	// RateLimitBlock waits until a token is available (or ctx is done)
+	RateLimitBlock RateLimitPolicy = iota
+	// RateLimitFailFast returns ErrRateLimitExceeded immediately
+	RateLimitFailFast
+)
+
+// ErrRateLimitExceeded is returned by fail-fast limiters when no token is available
+var ErrRateLimitExceeded = errors.New("client-side rate limit exceeded")
+
+// RateLimitStats summarizes limiter activity
+type RateLimitStats struct {
+	Waits     int64         // Sends that had to wait for a token
+	TotalWait time.Duration // Cumulative time spent waiting
+	Rejected  int64         // Sends rejected by a fail-fast policy or ctx expiry
+}
+
+// tokenBucket is a single refilling bucket; tokens may go negative to express reservations
+type tokenBucket struct {
+	capacity float64
+	tokens   float64
+	perSec   float64
+}
+
+func newTokenBucket(capacity int, interval time.Duration) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		perSec:   float64(capacity) / interval.Seconds(),
+	}
+}
+
+func (b *tokenBucket) refill(elapsed time.Duration) {
+	b.tokens += elapsed.Seconds() * b.perSec
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// waitFor returns how long until one token is available
+func (b *tokenBucket) waitFor() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.perSec * float64(time.Second))
+}
+
+// RateLimiter is a token-bucket limiter enforcing per-second and per-minute quotas
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets []*tokenBucket
+	policy  RateLimitPolicy
+	last    time.Time
+	stats   RateLimitStats
+}
+
+// NewRateLimiter creates a limiter; a non-positive quota disables that bucket
+func NewRateLimiter(perSecond, perMinute int, policy RateLimitPolicy) *RateLimiter {
+	l := &RateLimiter{policy: policy, last: time.Now()}
+	if perSecond > 0 {
+		l.buckets = append(l.buckets, newTokenBucket(perSecond, time.Second))
+	}
+	if perMinute > 0 {
+		l.buckets = append(l.buckets, newTokenBucket(perMinute, time.Minute))
+	}
+	return l
+}
+
+// Wait takes a token, blocking or failing according to the policy. It returns how long it waited.
+func (l *RateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	if l == nil || len(l.buckets) == 0 {
+		return 0, nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	for _, b := range l.buckets {
+		b.refill(now.Sub(l.last))
+	}
+	l.last = now
+
+	var wait time.Duration
+	for _, b := range l.buckets {
+		if w := b.waitFor(); w > wait {
+			wait = w
+		}
+	}
+
+	if wait > 0 {
+		deadline, hasDeadline := ctx.Deadline()
+		if l.policy == RateLimitFailFast || (hasDeadline && now.Add(wait).After(deadline)) {
+			l.stats.Rejected++
+			l.mu.Unlock()
+			return 0, ErrRateLimitExceeded
+		}
+		l.stats.Waits++
+		l.stats.TotalWait += wait
+	}
+
+	// Reserve the token now so concurrent callers queue up behind us
+	for _, b := range l.buckets {
+		b.tokens--
+	}
+	l.mu.Unlock()
+
+	if err := sleepCtx(ctx, wait); err != nil {
+		l.release()
+		return 0, err
+	}
+
+	return wait, nil
+}
+
+// release returns a reserved token after a cancelled wait
+func (l *RateLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, b := range l.buckets {
+		b.tokens++
+	}
+	l.stats.Rejected++
+}
+
+// Stats returns a snapshot of limiter activity
+func (l *RateLimiter) Stats() RateLimitStats {
+	if l == nil {
+		return RateLimitStats{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// WithRateLimit sets the client-side quotas; pass zeros to disable limiting
+func WithRateLimit(perSecond, perMinute int) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.RateLimitPerSecond = perSecond
+		opts.RateLimitPerMinute = perMinute
+	}
+}
+
+// WithRateLimitPolicy sets whether sends block or fail fast when the quota is used up
+func WithRateLimitPolicy(policy RateLimitPolicy) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.RateLimitPolicy = policy
+	}
+}
+
+// WithRateLimitObserver registers a callback receiving how long each send waited for a token
+func WithRateLimitObserver(observer func(wait time.Duration)) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.RateLimitObserver = observer
+	}
+}
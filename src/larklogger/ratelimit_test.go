@@ -0,0 +1,118 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("allows a burst up to capacity", func(t *testing.T) {
+		limiter := NewRateLimiter(5, 100, RateLimitFailFast)
+		for i := 0; i < 5; i++ {
+			if _, err := limiter.Wait(context.Background()); err != nil {
+				t.Fatalf("Expected token %d to be available, got %v", i+1, err)
+			}
+		}
+		if _, err := limiter.Wait(context.Background()); !errors.Is(err, ErrRateLimitExceeded) {
+			t.Errorf("Expected ErrRateLimitExceeded, got %v", err)
+		}
+		if stats := limiter.Stats(); stats.Rejected != 1 {
+			t.Errorf("Expected 1 rejected send, got %d", stats.Rejected)
+		}
+	})
+
+	t.Run("blocks until a token is refilled", func(t *testing.T) {
+		limiter := NewRateLimiter(10, 0, RateLimitBlock)
+		for i := 0; i < 10; i++ {
+			_, _ = limiter.Wait(context.Background())
+		}
+
+		wait, err := limiter.Wait(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if wait <= 0 || wait > 200*time.Millisecond {
+			t.Errorf("Expected a wait of about 100ms, got %v", wait)
+		}
+		if stats := limiter.Stats(); stats.Waits != 1 || stats.TotalWait != wait {
+			t.Errorf("Unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("per-minute quota caps bursts", func(t *testing.T) {
+		limiter := NewRateLimiter(100, 3, RateLimitFailFast)
+		for i := 0; i < 3; i++ {
+			_, _ = limiter.Wait(context.Background())
+		}
+		if _, err := limiter.Wait(context.Background()); !errors.Is(err, ErrRateLimitExceeded) {
+			t.Errorf("Expected per-minute quota to reject, got %v", err)
+		}
+	})
+
+	t.Run("fails early when ctx deadline is too close", func(t *testing.T) {
+		limiter := NewRateLimiter(1, 0, RateLimitBlock)
+		_, _ = limiter.Wait(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := limiter.Wait(ctx); !IsRateLimited(err) {
+			t.Errorf("Expected rate limit error, got %v", err)
+		}
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		limiter := NewRateLimiter(1000, 0, RateLimitBlock)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = limiter.Wait(context.Background())
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("disabled limiter never waits", func(t *testing.T) {
+		limiter := NewRateLimiter(0, 0, RateLimitFailFast)
+		for i := 0; i < 100; i++ {
+			if _, err := limiter.Wait(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+	})
+}
+
+func TestLarkClientRateLimit(t *testing.T) {
+	srv := fakelark.NewServer(t)
+
+	var observed []time.Duration
+	client := NewLarkClient(srv.WebhookURL(),
+		WithRateLimit(2, 0),
+		WithRateLimitObserver(func(wait time.Duration) { observed = append(observed, wait) }),
+	)
+
+	for i := 0; i < 3; i++ {
+		if err := client.SendText("test"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if len(observed) != 1 || observed[0] <= 0 {
+		t.Errorf("Expected exactly one observed wait, got %v", observed)
+	}
+	if stats := client.RateLimitStats(); stats.Waits != 1 {
+		t.Errorf("Expected 1 wait in stats, got %d", stats.Waits)
+	}
+
+	failFast := NewLarkClient(srv.WebhookURL(), WithRateLimit(1, 0), WithRateLimitPolicy(RateLimitFailFast))
+	_ = failFast.SendText("first")
+	if err := failFast.SendText("second"); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded, got %v", err)
+	}
+}
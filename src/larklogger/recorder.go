@@ -0,0 +1,211 @@
+package larklogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TestWebhookURL is the placeholder webhook GetConfig falls back to when LARK_WEBHOOK_URL is unset;
+// clients never dial it and always capture its messages
+const TestWebhookURL = "https://test.webhook.url"
+
+// DefaultRecorderLimit is how many messages of each kind a recorder keeps
+const DefaultRecorderLimit = 1000
+
+// Recorder captures messages in memory instead of sending them. Clients in test mode (LARK_TEST_MODE=true,
+// WithRecorder or WithTestMode) deliver to it, so test suites can assert on exactly what would have been sent.
+// Only the newest messages up to the limit are kept; older ones are released as the slices grow.
+type Recorder struct {
+	mu    sync.Mutex
+	limit int
+	cards []*Card
+	texts []string
+	posts []*Post
+	seq   int
+}
+
+// RecorderOption configures a Recorder
+type RecorderOption func(*Recorder)
+
+// WithRecorderLimit sets how many messages of each kind are kept (0 or less = unlimited)
+func WithRecorderLimit(limit int) RecorderOption {
+	return func(r *Recorder) {
+		r.limit = limit
+	}
+}
+
+// NewRecorder creates an empty recorder keeping up to DefaultRecorderLimit messages of each kind
+func NewRecorder(opts ...RecorderOption) *Recorder {
+	r := &Recorder{limit: DefaultRecorderLimit}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+var defaultRecorder = NewRecorder()
+
+// DefaultRecorder returns the recorder shared by clients in test mode without their own WithRecorder
+func DefaultRecorder() *Recorder {
+	return defaultRecorder
+}
+
+// Recorded returns the cards captured so far, in send order; card updates are recorded too
+func (r *Recorder) Recorded() []*Card {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Card{}, r.cards...)
+}
+
+// Texts returns the text messages captured so far, mentions included
+func (r *Recorder) Texts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.texts...)
+}
+
+// Posts returns the rich-text posts captured so far
+func (r *Recorder) Posts() []*Post {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Post{}, r.posts...)
+}
+
+// Reset discards everything captured so far
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cards, r.texts, r.posts = nil, nil, nil
+}
+
+// WithRecorder puts the client in test mode, capturing messages in r instead of sending them
+func WithRecorder(r *Recorder) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.Recorder = r
+		enabled := r != nil
+		opts.TestMode = &enabled
+	}
+}
+
+// WithTestMode turns test mode on, capturing into DefaultRecorder unless WithRecorder is given, or off.
+// Without it clients follow LARK_TEST_MODE; WithTestMode(false) sends for real even when it is set.
+func WithTestMode(enabled bool) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.TestMode = &enabled
+	}
+}
+
+// recorderFor returns the recorder a client should deliver to, or nil if it sends for real
+func recorderFor(opts *ClientOptions, webhookURL string) *Recorder {
+	enabled := testModeFromEnv()
+	if opts.TestMode != nil {
+		enabled = *opts.TestMode
+	}
+	if !enabled && webhookURL != TestWebhookURL {
+		return nil
+	}
+	if opts.Recorder != nil {
+		return opts.Recorder
+	}
+	return defaultRecorder
+}
+
+// recordWebhook captures a webhook payload
+func (r *Recorder) recordWebhook(data []byte) error {
+	var envelope struct {
+		MsgType string          `json:"msg_type"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to parse payload: %w", err)
+	}
+
+	switch envelope.MsgType {
+	case "interactive":
+		var card Card
+		if err := json.Unmarshal(data, &card); err != nil {
+			return fmt.Errorf("failed to parse card: %w", err)
+		}
+		r.addCard(&card)
+	case "post":
+		var post Post
+		if err := json.Unmarshal(data, &post); err != nil {
+			return fmt.Errorf("failed to parse post: %w", err)
+		}
+		r.addPost(&post)
+	default:
+		return r.recordText(envelope.Content)
+	}
+	return nil
+}
+
+// recordApp captures an Open API message and returns a fake reference to it
+func (r *Recorder) recordApp(msgType string, content []byte) (MessageRef, error) {
+	var err error
+	switch msgType {
+	case "interactive":
+		err = r.recordAppCard(content)
+	case "post":
+		post := &Post{MsgType: "post"}
+		if err = json.Unmarshal(content, &post.Content.Post); err == nil {
+			r.addPost(post)
+		}
+	default:
+		err = r.recordText(content)
+	}
+	if err != nil {
+		return MessageRef{}, err
+	}
+
+	r.mu.Lock()
+	r.seq++
+	id := fmt.Sprintf("om_test_%d", r.seq)
+	r.mu.Unlock()
+	return MessageRef{MessageID: id, SentAt: time.Now()}, nil
+}
+
+// recordAppCard captures card content as sent to, or patched through, the Open API
+func (r *Recorder) recordAppCard(content []byte) error {
+	card := &Card{MsgType: "interactive"}
+	if err := json.Unmarshal(content, &card.Card); err != nil {
+		return fmt.Errorf("failed to parse card: %w", err)
+	}
+	r.addCard(card)
+	return nil
+}
+
+func (r *Recorder) recordText(content []byte) error {
+	var text struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(content, &text); err != nil {
+		return fmt.Errorf("failed to parse text: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.texts = append(r.texts, text.Text)
+	if r.limit > 0 && len(r.texts) > r.limit {
+		r.texts = r.texts[len(r.texts)-r.limit:]
+	}
+	return nil
+}
+
+func (r *Recorder) addCard(card *Card) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cards = append(r.cards, card)
+	if r.limit > 0 && len(r.cards) > r.limit {
+		r.cards = r.cards[len(r.cards)-r.limit:]
+	}
+}
+
+func (r *Recorder) addPost(post *Post) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.posts = append(r.posts, post)
+	if r.limit > 0 && len(r.posts) > r.limit {
+		r.posts = r.posts[len(r.posts)-r.limit:]
+	}
+}
@@ -0,0 +1,129 @@
+package larklogger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Run("webhook client records instead of sending", func(t *testing.T) {
+		recorder := NewRecorder()
+		client := NewLarkClient("http://127.0.0.1:1/unreachable", WithRecorder(recorder))
+
+		if err := client.SendCard(NewCardBuilder().SetHeader("Deploy", ColorBlue).Build()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := client.SendText("hello", MentionAll()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := client.SendPost(NewPostBuilder().SetTitle("Notes").AddText("body").Build()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		cards := recorder.Recorded()
+		if len(cards) != 1 || cards[0].Card.Header.Title.Content != "Deploy" {
+			t.Errorf("Expected the Deploy card, got %+v", cards)
+		}
+		if texts := recorder.Texts(); len(texts) != 1 || texts[0] != `hello <at user_id="all">all</at>` {
+			t.Errorf("Expected one text with mention, got %v", texts)
+		}
+		if posts := recorder.Posts(); len(posts) != 1 || posts[0].Content.Post[LangZhCN].Title != "Notes" {
+			t.Errorf("Expected one post, got %+v", posts)
+		}
+
+		recorder.Reset()
+		if len(recorder.Recorded()) != 0 || len(recorder.Texts()) != 0 || len(recorder.Posts()) != 0 {
+			t.Error("Expected recorder to be empty after Reset")
+		}
+	})
+
+	t.Run("WithTestMode uses the default recorder", func(t *testing.T) {
+		DefaultRecorder().Reset()
+		defer DefaultRecorder().Reset()
+
+		logger := NewLarkLogger(context.Background(), NewLarkClient("http://127.0.0.1:1/unreachable", WithTestMode(true)))
+		logger.Error("boom", map[string]interface{}{"db": "primary"})
+
+		if cards := DefaultRecorder().Recorded(); len(cards) != 1 || !contains(cardMarkdown(cards[0]), "boom") {
+			t.Errorf("Expected the log card in the test recorder, got %d cards", len(cards))
+		}
+	})
+
+	t.Run("LARK_TEST_MODE keeps clients off the network", func(t *testing.T) {
+		DefaultRecorder().Reset()
+		defer DefaultRecorder().Reset()
+		t.Setenv("LARK_TEST_MODE", "true")
+
+		srv := fakelark.NewServer(t)
+		client := NewLarkClient(srv.WebhookURL())
+		if err := client.SendText("hello"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		app := NewAppClient(fakelark.AppID, fakelark.AppSecret, WithBaseURL(srv.BaseURL()))
+		if _, err := app.SendText(ToChat("oc_1"), "hello"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if n := srv.Requests(); n != 0 {
+			t.Errorf("Expected no request to reach the server, got %d", n)
+		}
+		if texts := DefaultRecorder().Texts(); len(texts) != 2 {
+			t.Errorf("Expected both texts in the default recorder, got %v", texts)
+		}
+
+		if client := NewLarkClient(srv.WebhookURL(), WithTestMode(false)); client.recorder != nil {
+			t.Error("Expected WithTestMode(false) to override LARK_TEST_MODE")
+		}
+	})
+
+	t.Run("placeholder webhook is never dialled", func(t *testing.T) {
+		t.Setenv("LARK_TEST_MODE", "")
+		if client := NewLarkClient(TestWebhookURL); client.recorder == nil {
+			t.Error("Expected the placeholder webhook to capture messages")
+		}
+		if client := NewLarkClient("http://127.0.0.1:1/unreachable"); client.recorder != nil {
+			t.Error("Expected a real webhook to send without LARK_TEST_MODE")
+		}
+	})
+
+	t.Run("limit keeps the newest messages", func(t *testing.T) {
+		recorder := NewRecorder(WithRecorderLimit(2))
+		client := NewLarkClient(TestWebhookURL, WithRecorder(recorder))
+		for _, title := range []string{"one", "two", "three"} {
+			_ = client.SendCard(NewCardBuilder().SetHeader(title, ColorBlue).Build())
+			_ = client.SendText(title)
+		}
+
+		cards := recorder.Recorded()
+		if len(cards) != 2 || cards[0].Card.Header.Title.Content != "two" || cards[1].Card.Header.Title.Content != "three" {
+			t.Errorf("Expected the two newest cards, got %d", len(cards))
+		}
+		if texts := recorder.Texts(); len(texts) != 2 || texts[1] != "three" {
+			t.Errorf("Expected the two newest texts, got %v", texts)
+		}
+		if NewRecorder().limit != DefaultRecorderLimit {
+			t.Error("Expected recorders to be bounded by default")
+		}
+	})
+
+	t.Run("app client records with message ids", func(t *testing.T) {
+		recorder := NewRecorder()
+		app := NewAppClient("cli_x", "secret", WithBaseURL("http://127.0.0.1:1"), WithRecorder(recorder))
+		logger := NewAppLogger(context.Background(), app, ToChat("oc_1")).(*LarkLogger)
+
+		ref, err := logger.Notify(context.Background(), LevelError, "disk full", nil)
+		if err != nil || ref.MessageID == "" {
+			t.Fatalf("Expected a fake message_id, got %+v (err %v)", ref, err)
+		}
+		if err := logger.Resolve(ref, nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		cards := recorder.Recorded()
+		if len(cards) != 2 || !contains(cards[1].Card.Header.Title.Content, "Resolved") {
+			t.Errorf("Expected sent and resolved cards, got %d", len(cards))
+		}
+	})
+}
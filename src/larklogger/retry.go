@@ -0,0 +1,141 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Lark API codes with special retry semantics
+const (
+	CodeFrequencyLimited = 11232 // Too many requests for this bot
+	CodeSignMismatch     = 19021 // Signature verification failed
+)
+
+// RetryPolicy decides whether a failed send should be retried and how long to wait first
+type RetryPolicy interface {
+	// Backoff is called after the given failed attempt (starting at 1) with the time
+	// elapsed since the first attempt. It returns the delay before the next attempt,
+	// or false to give up.
+	Backoff(attempt int, elapsed time.Duration, err error) (time.Duration, bool)
+}
+
+// ExponentialBackoff retries transient failures with exponentially growing, jittered delays
+type ExponentialBackoff struct {
+	MaxRetries     int           // Maximum number of retries after the first attempt
+	InitialDelay   time.Duration // Delay before the first retry
+	MaxDelay       time.Duration // Upper bound for a computed delay (0 = unbounded)
+	Multiplier     float64       // Growth factor between retries (defaults to 2)
+	Jitter         float64       // Random spread as a fraction of the delay, in [0, 1]
+	MaxElapsed     time.Duration // Give up once this much time has passed (0 = unbounded)
+	RateLimitDelay time.Duration // Minimum delay after a rate-limit response
+}
+
+// NewExponentialBackoff creates a backoff policy with sensible defaults
+func NewExponentialBackoff(maxRetries int, initialDelay time.Duration) *ExponentialBackoff {
+	return &ExponentialBackoff{
+		MaxRetries:     maxRetries,
+		InitialDelay:   initialDelay,
+		MaxDelay:       30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RateLimitDelay: 2 * time.Second,
+	}
+}
+
+// Backoff implements RetryPolicy
+func (b *ExponentialBackoff) Backoff(attempt int, elapsed time.Duration, err error) (time.Duration, bool) {
+	if attempt > b.MaxRetries || !IsRetryable(err) {
+		return 0, false
+	}
+
+	multiplier := b.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := time.Duration(float64(b.InitialDelay) * math.Pow(multiplier, float64(attempt-1)))
+	if b.MaxDelay > 0 && delay > b.MaxDelay {
+		delay = b.MaxDelay
+	}
+
+	if b.Jitter > 0 && delay > 0 {
+		spread := float64(delay) * b.Jitter
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+
+	// Back off harder when Lark tells us we are sending too fast
+	if IsRateLimited(err) {
+		delay *= 2
+		if delay < b.RateLimitDelay {
+			delay = b.RateLimitDelay
+		}
+	}
+
+	// Retry-After is authoritative, even above MaxDelay
+	if after := retryAfterFromError(err); after > delay {
+		delay = after
+	}
+
+	if b.MaxElapsed > 0 && elapsed+delay > b.MaxElapsed {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+// WithRetryPolicy sets a custom retry policy, overriding WithRetry
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.RetryPolicy = policy
+	}
+}
+
+// retryAfterFromError returns the server-requested delay carried by err, if any
+func retryAfterFromError(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(lastAttemptError(err), &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// sleepCtx waits for d or until ctx is done, whichever comes first
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
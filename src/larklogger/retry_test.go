@@ -0,0 +1,158 @@
+package larklogger
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	policy := &ExponentialBackoff{
+		MaxRetries:     3,
+		InitialDelay:   100 * time.Millisecond,
+		MaxDelay:       250 * time.Millisecond,
+		Multiplier:     2,
+		RateLimitDelay: time.Second,
+	}
+	transient := &APIError{HTTPStatus: http.StatusBadGateway}
+
+	t.Run("grows exponentially up to MaxDelay", func(t *testing.T) {
+		expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 250 * time.Millisecond}
+		for i, want := range expected {
+			delay, ok := policy.Backoff(i+1, 0, transient)
+			if !ok {
+				t.Fatalf("Expected retry on attempt %d", i+1)
+			}
+			if delay != want {
+				t.Errorf("Expected delay %v on attempt %d, got %v", want, i+1, delay)
+			}
+		}
+		if _, ok := policy.Backoff(4, 0, transient); ok {
+			t.Error("Expected no retry after MaxRetries")
+		}
+	})
+
+	t.Run("does not retry permanent failures", func(t *testing.T) {
+		permanent := []error{
+			&APIError{HTTPStatus: http.StatusBadRequest},
+			&APIError{HTTPStatus: http.StatusOK, Code: CodeSignMismatch},
+			context.Canceled,
+		}
+		for _, err := range permanent {
+			if _, ok := policy.Backoff(1, 0, err); ok {
+				t.Errorf("Expected no retry for %v", err)
+			}
+		}
+	})
+
+	t.Run("backs off harder when rate limited", func(t *testing.T) {
+		for _, err := range []error{
+			&APIError{HTTPStatus: http.StatusTooManyRequests},
+			&APIError{HTTPStatus: http.StatusOK, Code: CodeFrequencyLimited},
+		} {
+			delay, ok := policy.Backoff(1, 0, err)
+			if !ok || delay < policy.RateLimitDelay {
+				t.Errorf("Expected rate-limit delay of at least %v for %v, got %v (retry=%t)", policy.RateLimitDelay, err, delay, ok)
+			}
+		}
+	})
+
+	t.Run("honours Retry-After", func(t *testing.T) {
+		err := &APIError{HTTPStatus: http.StatusTooManyRequests, RetryAfter: 5 * time.Second}
+		delay, ok := policy.Backoff(1, 0, err)
+		if !ok || delay != 5*time.Second {
+			t.Errorf("Expected delay 5s, got %v (retry=%t)", delay, ok)
+		}
+	})
+
+	t.Run("stops after MaxElapsed", func(t *testing.T) {
+		limited := *policy
+		limited.MaxElapsed = time.Second
+		if _, ok := limited.Backoff(1, 950*time.Millisecond, transient); ok {
+			t.Error("Expected no retry beyond MaxElapsed")
+		}
+	})
+
+	t.Run("jitter stays within bounds", func(t *testing.T) {
+		jittered := *policy
+		jittered.Jitter = 0.5
+		for i := 0; i < 50; i++ {
+			delay, _ := jittered.Backoff(1, 0, transient)
+			if delay < 50*time.Millisecond || delay > 150*time.Millisecond {
+				t.Fatalf("Expected delay within [50ms, 150ms], got %v", delay)
+			}
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("Expected 3s, got %v", d)
+	}
+	if d := parseRetryAfter(""); d != 0 {
+		t.Errorf("Expected 0 for empty header, got %v", d)
+	}
+	if d := parseRetryAfter(time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)); d <= 0 || d > 10*time.Second {
+		t.Errorf("Expected positive delay up to 10s for HTTP date, got %v", d)
+	}
+}
+
+func TestSendWithRetry(t *testing.T) {
+	t.Run("retries transient failures", func(t *testing.T) {
+		srv := fakelark.NewServer(t)
+		srv.Script(fakelark.ServerError(http.StatusServiceUnavailable), fakelark.ServerError(http.StatusServiceUnavailable))
+
+		client := NewLarkClient(srv.WebhookURL(), WithRetry(3, time.Millisecond))
+		if err := client.SendText("test"); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if calls := srv.Requests(); calls != 3 {
+			t.Errorf("Expected 3 attempts, got %d", calls)
+		}
+	})
+
+	t.Run("does not retry signature errors", func(t *testing.T) {
+		srv := fakelark.NewServer(t)
+		srv.Script(fakelark.Reject(CodeSignMismatch, "sign match fail"))
+
+		client := NewLarkClient(srv.WebhookURL(), WithRetry(3, time.Millisecond))
+		if err := client.SendText("test"); err == nil {
+			t.Error("Expected error, got nil")
+		}
+		if calls := srv.Requests(); calls != 1 {
+			t.Errorf("Expected 1 attempt, got %d", calls)
+		}
+	})
+
+	t.Run("aborts when context is done", func(t *testing.T) {
+		srv := fakelark.NewServer(t)
+		srv.Script(fakelark.ServerError(http.StatusInternalServerError), fakelark.ServerError(http.StatusInternalServerError))
+
+		client := NewLarkClient(srv.WebhookURL(), WithRetry(5, time.Minute))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := client.SendTextCtx(ctx, "test")
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+		if time.Since(start) > time.Second {
+			t.Errorf("Expected send to abort promptly, took %v", time.Since(start))
+		}
+	})
+
+	t.Run("uses custom policy", func(t *testing.T) {
+		srv := fakelark.NewServer(t)
+		srv.Script(fakelark.ServerError(http.StatusInternalServerError), fakelark.ServerError(http.StatusInternalServerError))
+
+		client := NewLarkClient(srv.WebhookURL(), WithRetryPolicy(&ExponentialBackoff{MaxRetries: 1, InitialDelay: time.Millisecond}))
+		_ = client.SendText("test")
+		if calls := srv.Requests(); calls != 2 {
+			t.Errorf("Expected 2 attempts, got %d", calls)
+		}
+	})
+}
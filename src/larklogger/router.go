@@ -0,0 +1,288 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RouteMatch selects messages for a route; empty criteria match everything
+type RouteMatch struct {
+	Levels   []LogLevel        // Any of these levels
+	Services []string          // Any of these services: the message's "service" field, else the router's WithService
+	Envs     []string          // Any of these environments: the message's "env" field, else the router's WithEnv
+	Fields   map[string]string // Field values (compared via fmt.Sprint); "*" only requires the key
+}
+
+// RouteRule sends matching messages to one or more named clients
+type RouteRule struct {
+	Name     string         // Optional name, for diagnostics
+	Match    RouteMatch     // Which messages this rule applies to
+	Clients  []string       // Names of the clients to deliver to
+	Options  []LoggerOption // Per-route overrides (title, buttons, ShowConfig...)
+	Continue bool           // Keep evaluating later rules after a match
+}
+
+// RouterOption is a function that configures the router
+type RouterOption func(*routerConfig)
+
+type routerConfig struct {
+	loggerOpts []LoggerOption
+	rules      []RouteRule
+	fallback   *RouteRule
+}
+
+// WithRoute appends a routing rule; rules are evaluated in order
+func WithRoute(rule RouteRule) RouterOption {
+	return func(c *routerConfig) {
+		c.rules = append(c.rules, rule)
+	}
+}
+
+// WithDefaultRoute sets where messages go when no rule matches
+func WithDefaultRoute(clients []string, opts ...LoggerOption) RouterOption {
+	return func(c *routerConfig) {
+		c.fallback = &RouteRule{Name: "default", Clients: clients, Options: opts}
+	}
+}
+
+// WithRouterLoggerOptions sets logger options shared by every route
+func WithRouterLoggerOptions(opts ...LoggerOption) RouterOption {
+	return func(c *routerConfig) {
+		c.loggerOpts = append(c.loggerOpts, opts...)
+	}
+}
+
+// routeTarget is a configured logger for one client of one route
+type routeTarget struct {
+	client string
+	logger *LarkLogger
+}
+
+// compiledRoute is a rule with its loggers built
+type compiledRoute struct {
+	rule    RouteRule
+	targets []routeTarget
+}
+
+// Router implements Logger by fanning messages out to named clients according to rules
+type Router struct {
+	base     *LoggerConfig
+	baseCtx  context.Context
+	routes   []compiledRoute
+	fallback *compiledRoute
+}
+
+// NewRouter creates a Router over named senders, e.g. *LarkClient webhooks or AppClient targets
+func NewRouter(ctx context.Context, clients map[string]Sender, opts ...RouterOption) (*Router, error) {
+	config := &routerConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	r := &Router{base: newLoggerConfig(config.loggerOpts...), baseCtx: ctx}
+
+	compile := func(rule RouteRule) (compiledRoute, error) {
+		if len(rule.Clients) == 0 {
+			return compiledRoute{}, fmt.Errorf("route %q has no clients", rule.Name)
+		}
+		route := compiledRoute{rule: rule}
+		for _, name := range rule.Clients {
+			client, ok := clients[name]
+			if !ok || client == nil {
+				return compiledRoute{}, fmt.Errorf("route %q references unknown client %q", rule.Name, name)
+			}
+			loggerOpts := append(append([]LoggerOption{}, config.loggerOpts...), rule.Options...)
+			logger := NewLarkLogger(ctx, client, loggerOpts...).(*LarkLogger)
+			logger.route = rule.Name
+			route.targets = append(route.targets, routeTarget{client: name, logger: logger})
+		}
+		return route, nil
+	}
+
+	for _, rule := range config.rules {
+		route, err := compile(rule)
+		if err != nil {
+			return nil, err
+		}
+		r.routes = append(r.routes, route)
+	}
+
+	if config.fallback != nil {
+		route, err := compile(*config.fallback)
+		if err != nil {
+			return nil, err
+		}
+		r.fallback = &route
+	}
+
+	return r, nil
+}
+
+// matches reports whether a message satisfies the criteria
+func (m RouteMatch) matches(level LogLevel, service, env string, fields map[string]interface{}) bool {
+	if len(m.Levels) > 0 && !containsValue(m.Levels, level) {
+		return false
+	}
+	if len(m.Services) > 0 && !containsValue(m.Services, service) {
+		return false
+	}
+	if len(m.Envs) > 0 && !containsValue(m.Envs, env) {
+		return false
+	}
+	for key, want := range m.Fields {
+		value, ok := fields[key]
+		if !ok {
+			return false
+		}
+		if want != "*" && fmt.Sprint(value) != want {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue[T comparable](values []T, v T) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// targets resolves the loggers a message goes to, each client at most once
+func (r *Router) targets(level LogLevel, fields map[string]interface{}) []*LarkLogger {
+	var loggers []*LarkLogger
+	seen := make(map[string]bool)
+
+	add := func(route *compiledRoute) {
+		for _, target := range route.targets {
+			if !seen[target.client] {
+				seen[target.client] = true
+				loggers = append(loggers, target.logger)
+			}
+		}
+	}
+
+	service, env := r.scope(fields)
+	for i := range r.routes {
+		route := &r.routes[i]
+		if !route.rule.Match.matches(level, service, env, fields) {
+			continue
+		}
+		add(route)
+		if !route.rule.Continue {
+			break
+		}
+	}
+
+	if len(loggers) == 0 && r.fallback != nil {
+		add(r.fallback)
+	}
+
+	return loggers
+}
+
+// scope returns the service and env a message is routed by: its own "service" and "env" fields,
+// falling back to the router's base config
+func (r *Router) scope(fields map[string]interface{}) (service, env string) {
+	service, env = r.base.Service, r.base.Env
+	if value, ok := fields["service"]; ok {
+		service = fmt.Sprint(value)
+	}
+	if value, ok := fields["env"]; ok {
+		env = fmt.Sprint(value)
+	}
+	return service, env
+}
+
+func (r *Router) logCtx(ctx context.Context, level LogLevel, message string, fields map[string]interface{}) {
+	for _, logger := range r.targets(level, fields) {
+		logger.logCtx(ctx, level, message, fields)
+	}
+}
+
+// Info logs an info level message
+func (r *Router) Info(message string, fields map[string]interface{}) {
+	r.logCtx(r.baseCtx, LevelInfo, message, fields)
+}
+
+// Warn logs a warning level message
+func (r *Router) Warn(message string, fields map[string]interface{}) {
+	r.logCtx(r.baseCtx, LevelWarn, message, fields)
+}
+
+// Error logs an error level message
+func (r *Router) Error(message string, fields map[string]interface{}) {
+	r.logCtx(r.baseCtx, LevelError, message, fields)
+}
+
+// Context-aware variants
+func (r *Router) InfoCtx(ctx context.Context, message string, fields map[string]interface{}) {
+	r.logCtx(ctx, LevelInfo, message, fields)
+}
+
+func (r *Router) WarnCtx(ctx context.Context, message string, fields map[string]interface{}) {
+	r.logCtx(ctx, LevelWarn, message, fields)
+}
+
+func (r *Router) ErrorCtx(ctx context.Context, message string, fields map[string]interface{}) {
+	r.logCtx(ctx, LevelError, message, fields)
+}
+
+// Infof logs an info level message with formatted title and key-value pairs
+func (r *Router) Infof(title string, args ...interface{}) {
+	r.logCtx(r.baseCtx, LevelInfo, title, parseKeyValuePairs(args...))
+}
+
+// Warnf logs a warning level message with formatted title and key-value pairs
+func (r *Router) Warnf(title string, args ...interface{}) {
+	r.logCtx(r.baseCtx, LevelWarn, title, parseKeyValuePairs(args...))
+}
+
+// Errorf logs an error level message with formatted title and key-value pairs
+func (r *Router) Errorf(title string, args ...interface{}) {
+	r.logCtx(r.baseCtx, LevelError, title, parseKeyValuePairs(args...))
+}
+
+// allLoggers returns every logger owned by the router
+func (r *Router) allLoggers() []*LarkLogger {
+	var loggers []*LarkLogger
+	for _, route := range r.routes {
+		for _, target := range route.targets {
+			loggers = append(loggers, target.logger)
+		}
+	}
+	if r.fallback != nil {
+		for _, target := range r.fallback.targets {
+			loggers = append(loggers, target.logger)
+		}
+	}
+	return loggers
+}
+
+// Flush waits for every async route logger to deliver its queue
+func (r *Router) Flush(ctx context.Context) error {
+	var errs []error
+	for _, logger := range r.allLoggers() {
+		if err := logger.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close drains and stops every async route logger
+func (r *Router) Close(ctx context.Context) error {
+	var errs []error
+	for _, logger := range r.allLoggers() {
+		if err := logger.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
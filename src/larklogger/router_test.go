@@ -0,0 +1,150 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+// cardTitles returns the titles of the cards the server accepted, in order
+func cardTitles(srv *fakelark.Server) []string {
+	var titles []string
+	for _, msg := range srv.Messages() {
+		var card CardData
+		_ = json.Unmarshal(msg.Card, &card)
+		titles = append(titles, card.Header.Title.Content)
+	}
+	return titles
+}
+
+func TestRouter(t *testing.T) {
+	ops := fakelark.NewServer(t)
+	oncall := fakelark.NewServer(t)
+	payments := fakelark.NewServer(t)
+
+	clients := map[string]Sender{
+		"ops":      NewLarkClient(ops.WebhookURL()),
+		"oncall":   NewLarkClient(oncall.WebhookURL()),
+		"payments": NewLarkClient(payments.WebhookURL()),
+	}
+
+	router, err := NewRouter(context.Background(), clients,
+		WithRouterLoggerOptions(WithService("api"), WithEnv("production"), WithTitle("API")),
+		WithRoute(RouteRule{
+			Name:     "payments",
+			Match:    RouteMatch{Fields: map[string]string{"team": "payments"}},
+			Clients:  []string{"payments"},
+			Continue: true,
+		}),
+		WithRoute(RouteRule{
+			Name:    "page",
+			Match:   RouteMatch{Levels: []LogLevel{LevelError}, Envs: []string{"production"}},
+			Clients: []string{"oncall", "ops"},
+			Options: []LoggerOption{WithTitle("PAGE")},
+		}),
+		WithRoute(RouteRule{
+			Name:    "staging",
+			Match:   RouteMatch{Envs: []string{"staging"}},
+			Clients: []string{"oncall"},
+		}),
+		WithDefaultRoute([]string{"ops"}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var _ Logger = router
+	var _ Flusher = router
+
+	router.Info("Service started", nil)
+	router.Error("Database down", nil)
+	router.Errorf("Charge failed", "team", "payments")
+
+	if got := cardTitles(ops); len(got) != 3 || !contains(got[0], "API") || !contains(got[1], "PAGE") {
+		t.Errorf("Unexpected ops deliveries: %v", got)
+	}
+	if got := cardTitles(oncall); len(got) != 2 || !contains(got[0], "PAGE") {
+		t.Errorf("Unexpected oncall deliveries: %v", got)
+	}
+	if got := cardTitles(payments); len(got) != 1 {
+		t.Errorf("Expected payments to receive the tagged error once, got %v", got)
+	}
+}
+
+func TestRouterSenders(t *testing.T) {
+	var mu sync.Mutex
+	var titles []string
+	app := senderFunc(func(ctx context.Context, card *Card) error {
+		mu.Lock()
+		defer mu.Unlock()
+		titles = append(titles, card.Card.Header.Title.Content)
+		return nil
+	})
+	ops := fakelark.NewServer(t)
+
+	router, err := NewRouter(context.Background(), map[string]Sender{"ops": NewLarkClient(ops.WebhookURL()), "app": app},
+		WithRouterLoggerOptions(WithService("api"), WithEnv("production")),
+		WithRoute(RouteRule{
+			Name:    "staging",
+			Match:   RouteMatch{Envs: []string{"staging"}},
+			Clients: []string{"ops"},
+			Options: []LoggerOption{WithTitle("STAGING")},
+		}),
+		WithRoute(RouteRule{
+			Name:    "billing",
+			Match:   RouteMatch{Services: []string{"billing"}, Envs: []string{"production"}},
+			Clients: []string{"app"},
+			Options: []LoggerOption{WithTitle("BILLING")},
+		}),
+		WithDefaultRoute([]string{"ops"}, WithTitle("DEFAULT")),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	router.Errorf("Job failed", "env", "staging")
+	router.Errorf("Invoice failed", "service", "billing")
+	router.Error("Request failed", nil)
+
+	t.Run("message fields override the base service and env", func(t *testing.T) {
+		if got := cardTitles(ops); len(got) != 2 || !contains(got[0], "STAGING") || !contains(got[1], "DEFAULT") {
+			t.Errorf("Unexpected ops deliveries: %v", got)
+		}
+	})
+
+	t.Run("routes to any Sender", func(t *testing.T) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(titles) != 1 || !contains(titles[0], "BILLING") {
+			t.Errorf("Expected the billing error on the app sender, got %v", titles)
+		}
+	})
+}
+
+func TestRouterValidation(t *testing.T) {
+	clients := map[string]Sender{"ops": NewLarkClient("https://example.com/webhook")}
+
+	if _, err := NewRouter(context.Background(), clients, WithDefaultRoute([]string{"missing"})); err == nil {
+		t.Error("Expected error for unknown client")
+	}
+	if _, err := NewRouter(context.Background(), clients, WithRoute(RouteRule{Name: "empty"})); err == nil {
+		t.Error("Expected error for route without clients")
+	}
+}
+
+func TestRouteMatch(t *testing.T) {
+	match := RouteMatch{Fields: map[string]string{"code": "500", "trace": "*"}}
+
+	if !match.matches(LevelInfo, "", "", map[string]interface{}{"code": 500, "trace": "abc"}) {
+		t.Error("Expected numeric field to match its formatted value")
+	}
+	if match.matches(LevelInfo, "", "", map[string]interface{}{"code": 500}) {
+		t.Error("Expected missing wildcard field not to match")
+	}
+	if !(RouteMatch{}).matches(LevelWarn, "svc", "env", nil) {
+		t.Error("Expected empty match to accept everything")
+	}
+}
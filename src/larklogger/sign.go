@@ -0,0 +1,48 @@
+package larklogger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// genSign computes the signature expected by Lark custom bots with
+// "signature verification" enabled: base64(HmacSHA256(timestamp + "\n" + secret, "")).
+func genSign(secret string, timestamp int64) (string, error) {
+	stringToSign := fmt.Sprintf("%d\n%s", timestamp, secret)
+
+	h := hmac.New(sha256.New, []byte(stringToSign))
+	if _, err := h.Write([]byte{}); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// signPayload injects a fresh timestamp and sign pair into the JSON payload.
+// It returns data unchanged when no secret is configured.
+func (c *LarkClient) signPayload(data []byte) ([]byte, error) {
+	if c.opts.Secret == "" {
+		return data, nil
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode payload for signing: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	sign, err := genSign(c.opts.Secret, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign payload: %w", err)
+	}
+
+	payload["timestamp"], _ = json.Marshal(strconv.FormatInt(timestamp, 10))
+	payload["sign"], _ = json.Marshal(sign)
+
+	return json.Marshal(payload)
+}
@@ -0,0 +1,411 @@
+package larklogger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxPayloadBytes approximates Lark's card payload limit
+const DefaultMaxPayloadBytes = 30 * 1024
+
+// ErrCardTooLarge is returned by card updates that stay over the payload limit even after trimming
+var ErrCardTooLarge = errors.New("card exceeds the payload limit even after trimming")
+
+// shrinkLimits are the value lengths tried, in order, before rows are dropped
+var shrinkLimits = []int{1000, 300, 100}
+
+// TrimReport describes how an oversized card was reduced to fit the payload limit
+type TrimReport struct {
+	OriginalBytes int
+	FinalBytes    int
+	ShrunkValues  []string // Keys of KV rows whose values were truncated
+	DroppedFields []string // Keys of KV rows removed from the card
+	TextFallback  bool     // The card was replaced by a text message
+}
+
+func (r TrimReport) String() string {
+	parts := []string{fmt.Sprintf("%d -> %d bytes", r.OriginalBytes, r.FinalBytes)}
+	if len(r.ShrunkValues) > 0 {
+		parts = append(parts, fmt.Sprintf("shrunk %s", strings.Join(r.ShrunkValues, ", ")))
+	}
+	if len(r.DroppedFields) > 0 {
+		parts = append(parts, fmt.Sprintf("dropped %s", strings.Join(r.DroppedFields, ", ")))
+	}
+	if r.TextFallback {
+		parts = append(parts, "sent as text")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// kvSection records where a KV table was rendered so it can be re-rendered with fewer rows
+type kvSection struct {
+	start, end int // Element range of the rendered table
+	items      []KVItem
+	style      string // Background style for AddKVTableWithStyle, "" for AddKVTable
+	omitted    int    // Rows dropped from this table
+}
+
+// trackKVSection records a KV table rendered from element index start up to the current end
+func (cb *CardBuilder) trackKVSection(start int, items []KVItem, style string) {
+	cb.card.kvSections = append(cb.card.kvSections, kvSection{
+		start: start,
+		end:   len(cb.card.Card.Elements),
+		items: append([]KVItem{}, items...),
+		style: style,
+	})
+}
+
+// SetMaxBytes sets the payload limit Build trims the card to
+func (cb *CardBuilder) SetMaxBytes(maxBytes int) *CardBuilder {
+	cb.maxBytes = maxBytes
+	return cb
+}
+
+// TrimReport returns what was trimmed to make the card fit, or nil if it was left untouched
+func (c *Card) TrimReport() *TrimReport {
+	return c.trim
+}
+
+// size returns the marshalled size of the card
+func (c *Card) size() int {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// clone copies the card deeply enough for fit to trim the copy without touching the original
+func (c *Card) clone() *Card {
+	clone := *c
+	clone.trim = nil
+	clone.kvSections = make([]kvSection, len(c.kvSections))
+	for i, section := range c.kvSections {
+		section.items = append([]KVItem{}, section.items...)
+		clone.kvSections[i] = section
+	}
+	return &clone
+}
+
+// fit degrades the card in place until it is within maxBytes: long values are shrunk first, then the
+// lowest-priority rows are collapsed into a "N more fields omitted" row. It returns what was trimmed,
+// nil if the card already fit, and whether the card fits now.
+func (c *Card) fit(maxBytes int) (*TrimReport, bool) {
+	size := c.size()
+	if size <= maxBytes {
+		return nil, true
+	}
+
+	report := &TrimReport{OriginalBytes: size}
+	defer func() { report.FinalBytes = c.size() }()
+
+	if c.IsTemplate() {
+		return report, c.fitTemplate(maxBytes, report)
+	}
+
+	shrunk := make(map[string]bool)
+
+	for _, limit := range shrinkLimits {
+		changed := false
+		for i := range c.kvSections {
+			section := &c.kvSections[i]
+			sectionChanged := false
+			for j := range section.items {
+				item := &section.items[j]
+				if len(item.Value) <= limit {
+					continue
+				}
+				item.Value = truncateWithHint(item.Value, limit)
+				sectionChanged = true
+				if !shrunk[item.Key] {
+					shrunk[item.Key] = true
+					report.ShrunkValues = append(report.ShrunkValues, item.Key)
+				}
+			}
+			if sectionChanged {
+				c.renderKVSection(i)
+				changed = true
+			}
+		}
+		if changed && c.size() <= maxBytes {
+			return report, true
+		}
+	}
+
+	for c.size() > maxBytes {
+		section, row := c.lowestPriorityRow()
+		if section < 0 {
+			return report, false
+		}
+		s := &c.kvSections[section]
+		report.DroppedFields = append(report.DroppedFields, s.items[row].Key)
+		s.items = append(s.items[:row], s.items[row+1:]...)
+		s.omitted++
+		c.renderKVSection(section)
+	}
+	return report, true
+}
+
+// lowestPriorityRow finds the row to drop next: lowest priority, latest position
+func (c *Card) lowestPriorityRow() (section, row int) {
+	section, row = -1, -1
+	lowest := math.MaxInt
+	for i, s := range c.kvSections {
+		for j, item := range s.items {
+			if item.Priority <= lowest {
+				lowest = item.Priority
+				section, row = i, j
+			}
+		}
+	}
+	return section, row
+}
+
+// renderKVSection re-renders a tracked KV table in place
+func (c *Card) renderKVSection(i int) {
+	section := &c.kvSections[i]
+
+	items := section.items
+	if section.omitted > 0 {
+		items = append(append([]KVItem{}, items...), KVItem{
+			Key:   "…",
+			Value: fmt.Sprintf("%d more fields omitted", section.omitted),
+		})
+	}
+
+	tmp := &CardBuilder{card: &Card{}, isMobile: c.isMobile}
+	if section.style == "" {
+		tmp.AddKVTable(items)
+	} else {
+		tmp.AddKVTableWithStyle(items, section.style)
+	}
+	rendered := tmp.card.Card.Elements
+
+	elements := make([]Element, 0, len(c.Card.Elements)-(section.end-section.start)+len(rendered))
+	elements = append(elements, c.Card.Elements[:section.start]...)
+	elements = append(elements, rendered...)
+	elements = append(elements, c.Card.Elements[section.end:]...)
+	c.Card.Elements = elements
+
+	delta := len(rendered) - (section.end - section.start)
+	section.end += delta
+	for j := range c.kvSections {
+		if j != i && c.kvSections[j].start >= section.start {
+			c.kvSections[j].start += delta
+			c.kvSections[j].end += delta
+		}
+	}
+}
+
+// templateFieldsVariable is the template variable holding the {key, value} rows of a log card
+const templateFieldsVariable = "fields"
+
+// fitTemplate trims template variables the way fit trims KV tables: long string values and field rows
+// are shrunk first, then the lowest-priority rows are dropped together with their per-key variables
+func (c *Card) fitTemplate(maxBytes int, report *TrimReport) bool {
+	if c.Card.Data == nil {
+		return false
+	}
+
+	// Work on copies so the caller's variables map is left untouched
+	data := *c.Card.Data
+	variables := make(map[string]interface{}, len(data.TemplateVariable))
+	for k, v := range data.TemplateVariable {
+		variables[k] = v
+	}
+	var rows []map[string]string
+	if original, ok := variables[templateFieldsVariable].([]map[string]string); ok {
+		rows = make([]map[string]string, 0, len(original))
+		for _, row := range original {
+			rows = append(rows, map[string]string{"key": row["key"], "value": row["value"]})
+		}
+		variables[templateFieldsVariable] = rows
+	}
+	data.TemplateVariable = variables
+	c.Card.Data = &data
+
+	shrunk := make(map[string]bool)
+	noteShrunk := func(key string) {
+		if !shrunk[key] {
+			shrunk[key] = true
+			report.ShrunkValues = append(report.ShrunkValues, key)
+		}
+	}
+
+	keys := make([]string, 0, len(variables))
+	for k := range variables {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, limit := range shrinkLimits {
+		changed := false
+		for _, key := range keys {
+			if value, ok := variables[key].(string); ok && len(value) > limit {
+				variables[key] = truncateWithHint(value, limit)
+				noteShrunk(key)
+				changed = true
+			}
+		}
+		for _, row := range rows {
+			if len(row["value"]) > limit {
+				row["value"] = truncateWithHint(row["value"], limit)
+				noteShrunk(row["key"])
+				changed = true
+			}
+		}
+		if changed && c.size() <= maxBytes {
+			return true
+		}
+	}
+
+	for c.size() > maxBytes {
+		row := lowestPriorityTemplateRow(rows)
+		if row < 0 {
+			return false
+		}
+		key, value := rows[row]["key"], rows[row]["value"]
+		report.DroppedFields = append(report.DroppedFields, key)
+		if v, ok := variables[key].(string); ok && v == value {
+			delete(variables, key)
+		}
+
+		rows = append(rows[:row], rows[row+1:]...)
+		omitted := fmt.Sprintf("%d more fields omitted", len(report.DroppedFields))
+		if n := len(rows); n > 0 && rows[n-1]["key"] == "…" {
+			rows[n-1]["value"] = omitted
+		} else {
+			rows = append(rows, map[string]string{"key": "…", "value": omitted})
+		}
+		variables[templateFieldsVariable] = rows
+	}
+	return true
+}
+
+// lowestPriorityTemplateRow finds the field row to drop next, skipping the omitted-fields row
+func lowestPriorityTemplateRow(rows []map[string]string) int {
+	row := -1
+	lowest := math.MaxInt
+	for i, r := range rows {
+		if r["key"] == "…" {
+			continue
+		}
+		if p := fieldPriority(r["key"]); p <= lowest {
+			lowest = p
+			row = i
+		}
+	}
+	return row
+}
+
+// templateFallbackLines renders the title, message and field rows of a template card as text
+func (c *Card) templateFallbackLines() []string {
+	if c.Card.Data == nil {
+		return nil
+	}
+	variables := c.Card.Data.TemplateVariable
+
+	var lines []string
+	for _, key := range []string{"title", "message"} {
+		if value, ok := variables[key].(string); ok && value != "" {
+			lines = append(lines, value)
+		}
+	}
+	if rows, ok := variables[templateFieldsVariable].([]map[string]string); ok {
+		for _, row := range rows {
+			lines = append(lines, row["key"]+": "+row["value"])
+		}
+		return lines
+	}
+
+	// Custom variables: list every string variable
+	keys := make([]string, 0, len(variables))
+	for k, v := range variables {
+		if _, ok := v.(string); ok && k != "title" && k != "message" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, k+": "+variables[k].(string))
+	}
+	return lines
+}
+
+// textFallback renders a card that cannot be trimmed enough as plain text within maxBytes
+func (c *Card) textFallback(maxBytes int) string {
+	var lines []string
+	if c.IsTemplate() {
+		lines = c.templateFallbackLines()
+	} else {
+		lines = []string{html.UnescapeString(c.Card.Header.Title.Content)}
+		for _, el := range c.Card.Elements {
+			if el.Text != nil && el.Text.Content != "" {
+				lines = append(lines, html.UnescapeString(stripFontTags(el.Text.Content)))
+				break
+			}
+		}
+	}
+	note := fmt.Sprintf("(card exceeded the %d byte payload limit and was sent as text)", maxBytes)
+
+	text := strings.Join(lines, "\n")
+	// Leave room for the JSON envelope and escaping
+	if budget := maxBytes/2 - len(note); len(text) > budget && budget > 0 {
+		text = strings.ToValidUTF8(text[:budget], "") + "…"
+	}
+	return text + "\n" + note
+}
+
+// stripFontTags removes the <font> wrappers used for grey card text
+func stripFontTags(s string) string {
+	if i := strings.Index(s, ">"); strings.HasPrefix(s, "<font") && i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSuffix(s, "</font>")
+}
+
+// fieldPriority ranks log fields for trimming; error details are kept longest, then warnings and IDs
+func fieldPriority(key string) int {
+	keyLower := strings.ToLower(key)
+	switch {
+	case strings.Contains(keyLower, "err") || strings.Contains(keyLower, "exception"):
+		return 2
+	case strings.Contains(keyLower, "warn") || isIDKey(key):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// isIDKey matches identifier keys such as "id", "request_id", "order.id" or "traceID",
+// but not words that merely end in "id" like "valid" or "paid"
+func isIDKey(key string) bool {
+	keyLower := strings.ToLower(key)
+	return keyLower == "id" || strings.HasSuffix(keyLower, "_id") || strings.HasSuffix(keyLower, ".id") ||
+		strings.HasSuffix(keyLower, "-id") || strings.HasSuffix(key, "ID")
+}
+
+// fitCard trims a copy of card to maxBytes, leaving the caller's card untouched, and returns the
+// copy with what was trimmed (nil if the card already fit). When even the trimmed card is too large,
+// fitted is nil and text holds a plain-text rendering to send instead.
+func fitCard(card *Card, maxBytes int) (fitted *Card, text string, report *TrimReport) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxPayloadBytes
+	}
+	if card.size() <= maxBytes {
+		return card, "", nil
+	}
+
+	fitted = card.clone()
+	report, ok := fitted.fit(maxBytes)
+	if !ok {
+		report.TextFallback = true
+		return nil, fitted.textFallback(maxBytes), report
+	}
+	return fitted, "", report
+}
@@ -0,0 +1,306 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+func TestCardFit(t *testing.T) {
+	t.Run("small card is untouched", func(t *testing.T) {
+		card := NewCardBuilder().SetHeader("ok", ColorBlue).AddKVTable([]KVItem{{Key: "a", Value: "b"}}).Build()
+		if card.TrimReport() != nil {
+			t.Errorf("Expected no trim report, got %+v", card.TrimReport())
+		}
+	})
+
+	t.Run("shrinks long values first", func(t *testing.T) {
+		card := NewCardBuilder().
+			SetHeader("big", ColorRed).
+			AddKVTable([]KVItem{{Key: "payload", Value: strings.Repeat("x", 50*1024)}, {Key: "user", Value: "alice"}}).
+			Build()
+
+		report := card.TrimReport()
+		if report == nil {
+			t.Fatal("Expected a trim report")
+		}
+		if card.size() > DefaultMaxPayloadBytes || report.FinalBytes != card.size() {
+			t.Errorf("Expected card within limit, got %d bytes (report %d)", card.size(), report.FinalBytes)
+		}
+		if len(report.ShrunkValues) != 1 || report.ShrunkValues[0] != "payload" {
+			t.Errorf("Expected payload to be shrunk, got %v", report.ShrunkValues)
+		}
+		if len(report.DroppedFields) != 0 {
+			t.Errorf("Expected no dropped fields, got %v", report.DroppedFields)
+		}
+		data, _ := json.Marshal(card)
+		if !contains(string(data), "alice") || !contains(string(data), "51200 chars") {
+			t.Error("Expected short rows kept and a length hint on the shrunk value")
+		}
+	})
+
+	t.Run("drops low-priority rows", func(t *testing.T) {
+		items := []KVItem{{Key: "error", Value: "connection refused", Priority: fieldPriority("error")}}
+		for i := 0; i < 400; i++ {
+			items = append(items, KVItem{Key: fmt.Sprintf("field_%03d", i), Value: strings.Repeat("v", 60)})
+		}
+
+		card := NewCardBuilder().SetHeader("many", ColorRed).AddKVTable(items).AddDivider().Build()
+
+		report := card.TrimReport()
+		if report == nil || len(report.DroppedFields) == 0 {
+			t.Fatalf("Expected dropped fields, got %+v", report)
+		}
+		if card.size() > DefaultMaxPayloadBytes {
+			t.Errorf("Expected card within limit, got %d bytes", card.size())
+		}
+
+		data, _ := json.Marshal(card)
+		if !contains(string(data), "connection refused") {
+			t.Error("Expected high-priority error row to be kept")
+		}
+		if !contains(string(data), fmt.Sprintf("%d more fields omitted", len(report.DroppedFields))) {
+			t.Error("Expected an omitted-fields row")
+		}
+		if last := card.Card.Elements[len(card.Card.Elements)-1]; last.Tag != "hr" {
+			t.Errorf("Expected elements after the table to be preserved, got %s", last.Tag)
+		}
+	})
+
+	t.Run("trims template variables", func(t *testing.T) {
+		rows := []map[string]string{{"key": "error", "value": "connection refused"}}
+		variables := map[string]interface{}{"title": "Alert", "message": strings.Repeat("m", 5000), "error": "connection refused"}
+		for i := 0; i < 300; i++ {
+			key, value := fmt.Sprintf("field_%03d", i), strings.Repeat("v", 60)
+			rows = append(rows, map[string]string{"key": key, "value": value})
+			variables[key] = value
+		}
+		variables["fields"] = rows
+
+		card := NewTemplateCard("tpl", "", variables)
+		report, ok := card.fit(DefaultMaxPayloadBytes)
+		if !ok {
+			t.Fatal("Expected the template card to fit")
+		}
+		if card.size() > DefaultMaxPayloadBytes {
+			t.Errorf("Expected card within limit, got %d bytes", card.size())
+		}
+
+		if report == nil || len(report.DroppedFields) == 0 || report.ShrunkValues[0] != "message" {
+			t.Fatalf("Expected message shrunk and fields dropped, got %+v", report)
+		}
+		data, _ := json.Marshal(card)
+		if !contains(string(data), "connection refused") {
+			t.Error("Expected high-priority error row to be kept")
+		}
+		if !contains(string(data), fmt.Sprintf("%d more fields omitted", len(report.DroppedFields))) {
+			t.Error("Expected an omitted-fields row")
+		}
+		if len(variables["fields"].([]map[string]string)) != 301 || len(variables["message"].(string)) != 5000 {
+			t.Error("Expected the caller's variables to be left untouched")
+		}
+	})
+
+	t.Run("respects builder limit", func(t *testing.T) {
+		card := NewCardBuilder().SetMaxBytes(4096).
+			AddKVTable([]KVItem{{Key: "a", Value: strings.Repeat("a", 3000)}, {Key: "b", Value: strings.Repeat("b", 3000)}}).
+			Build()
+		if card.size() > 4096 {
+			t.Errorf("Expected card within 4096 bytes, got %d", card.size())
+		}
+	})
+}
+
+func TestClientPayloadLimit(t *testing.T) {
+	srv := fakelark.NewServer(t)
+	last := func() fakelark.Message {
+		messages := srv.Messages()
+		return messages[len(messages)-1]
+	}
+
+	t.Run("logger cards are trimmed", func(t *testing.T) {
+		var reports []TrimReport
+		client := NewLarkClient(srv.WebhookURL(), WithTrimObserver(func(r TrimReport) { reports = append(reports, r) }))
+		logger := NewLarkLogger(context.Background(), client)
+
+		fields := map[string]interface{}{"request_id": "req-1"}
+		for i := 0; i < 300; i++ {
+			fields[fmt.Sprintf("k%d", i)] = strings.Repeat("z", 200)
+		}
+		logger.Error("huge", fields)
+
+		if len(reports) != 1 || reports[0].TextFallback {
+			t.Fatalf("Expected one card trim report, got %+v", reports)
+		}
+		if msg := last(); msg.MsgType != "interactive" {
+			t.Errorf("Expected trimmed card, got %v", msg.MsgType)
+		}
+	})
+
+	t.Run("falls back to text", func(t *testing.T) {
+		var reports []TrimReport
+		client := NewLarkClient(srv.WebhookURL(), WithMaxPayloadBytes(2048),
+			WithTrimObserver(func(r TrimReport) { reports = append(reports, r) }))
+
+		card := NewCardBuilder().SetHeader(strings.Repeat("T", 4096), ColorRed).Build()
+		if err := client.SendCard(card); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(reports) != 1 || !reports[0].TextFallback {
+			t.Fatalf("Expected text fallback report, got %+v", reports)
+		}
+		msg := last()
+		if msg.MsgType != "text" {
+			t.Fatalf("Expected text message, got %v", msg.MsgType)
+		}
+		if text := msg.Text; len(text) > 2048 || !contains(text, "payload limit") {
+			t.Errorf("Expected short text with a note, got %d bytes", len(text))
+		}
+	})
+
+	t.Run("template cards are trimmed", func(t *testing.T) {
+		var reports []TrimReport
+		client := NewLarkClient(srv.WebhookURL(), WithTrimObserver(func(r TrimReport) { reports = append(reports, r) }))
+		logger := NewLarkLogger(context.Background(), client, WithTemplate(LogTemplate{TemplateID: "tpl"}))
+
+		fields := map[string]interface{}{"error": "upstream timeout"}
+		for i := 0; i < 300; i++ {
+			fields[fmt.Sprintf("k%03d", i)] = strings.Repeat("z", 200)
+		}
+		logger.Error("huge", fields)
+
+		if len(reports) != 1 || reports[0].TextFallback || len(reports[0].DroppedFields) == 0 {
+			t.Fatalf("Expected one template trim report, got %+v", reports)
+		}
+		if msg := last(); msg.MsgType != "interactive" || !contains(string(msg.Card), "upstream timeout") {
+			t.Errorf("Expected trimmed template card keeping the error, got %v", msg.MsgType)
+		}
+	})
+
+	t.Run("template falls back to text", func(t *testing.T) {
+		client := NewLarkClient(srv.WebhookURL(), WithMaxPayloadBytes(2048))
+		card := NewTemplateCard("tpl", "", map[string]interface{}{
+			"title":   "Deploy failed",
+			"message": "rollout stuck",
+			"hosts":   strings.Split(strings.Repeat("host,", 1000), ","),
+		})
+		if err := client.SendCard(card); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		msg := last()
+		if msg.MsgType != "text" {
+			t.Fatalf("Expected text message, got %v", msg.MsgType)
+		}
+		if text := msg.Text; !contains(text, "Deploy failed") || !contains(text, "rollout stuck") {
+			t.Errorf("Expected fallback built from template variables, got %q", text)
+		}
+	})
+}
+
+func TestFitLeavesCardUntouched(t *testing.T) {
+	items := []KVItem{{Key: "error", Value: "connection refused"}}
+	for i := 0; i < 40; i++ {
+		items = append(items, KVItem{Key: fmt.Sprintf("field_%02d", i), Value: strings.Repeat("v", 200)})
+	}
+
+	t.Run("concurrent and repeated sends", func(t *testing.T) {
+		var mu sync.Mutex
+		var reports []TrimReport
+		recorder := NewRecorder()
+		client := NewLarkClient("http://127.0.0.1:1/unreachable", WithRecorder(recorder), WithMaxPayloadBytes(4096),
+			WithTrimObserver(func(r TrimReport) {
+				mu.Lock()
+				reports = append(reports, r)
+				mu.Unlock()
+			}))
+
+		card := NewCardBuilder().SetHeader("shared", ColorRed).AddKVTable(items).Build()
+		before, _ := json.Marshal(card)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = client.SendCard(card)
+			}()
+		}
+		wg.Wait()
+		_ = client.SendCard(card)
+
+		if after, _ := json.Marshal(card); string(after) != string(before) {
+			t.Error("Expected the caller's card to be left untouched")
+		}
+		if card.TrimReport() != nil {
+			t.Errorf("Expected no trim report stored on the card, got %+v", card.TrimReport())
+		}
+		if len(reports) != 9 {
+			t.Fatalf("Expected one report per send, got %d", len(reports))
+		}
+		for _, r := range reports {
+			if r.OriginalBytes != len(before) || len(r.DroppedFields) != len(reports[0].DroppedFields) {
+				t.Errorf("Expected every send to trim the original card the same way, got %+v", r)
+			}
+		}
+		for _, sent := range recorder.Recorded() {
+			if sent.size() > 4096 {
+				t.Errorf("Expected sent card within 4096 bytes, got %d", sent.size())
+			}
+		}
+	})
+
+	t.Run("card updates are trimmed", func(t *testing.T) {
+		var reports []TrimReport
+		recorder := NewRecorder()
+		client := NewAppClient("cli_test", "secret", WithRecorder(recorder), WithMaxPayloadBytes(4096),
+			WithTrimObserver(func(r TrimReport) { reports = append(reports, r) }))
+
+		card := NewCardBuilder().SetHeader("resolved", ColorGreen).AddKVTable(items).Build()
+		if err := client.UpdateCard(context.Background(), MessageRef{MessageID: "om_1"}, card); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(reports) != 1 || len(reports[0].DroppedFields) == 0 {
+			t.Fatalf("Expected one trim report, got %+v", reports)
+		}
+		updated := recorder.Recorded()
+		if len(updated) != 1 || updated[0].size() > 4096 {
+			t.Fatalf("Expected a trimmed update, got %+v", updated)
+		}
+
+		huge := NewCardBuilder().SetHeader(strings.Repeat("T", 8192), ColorRed).Build()
+		if err := client.UpdateCard(context.Background(), MessageRef{MessageID: "om_1"}, huge); !errors.Is(err, ErrCardTooLarge) {
+			t.Errorf("Expected ErrCardTooLarge, got %v", err)
+		}
+	})
+}
+
+func TestFieldPriority(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"error", 2},
+		{"panic_exception", 2},
+		{"warning", 1},
+		{"id", 1},
+		{"request_id", 1},
+		{"order.id", 1},
+		{"traceID", 1},
+		{"valid", 0},
+		{"paid", 0},
+		{"status", 0},
+	}
+	for _, tt := range tests {
+		if got := fieldPriority(tt.key); got != tt.want {
+			t.Errorf("Expected priority %d for %q, got %d", tt.want, tt.key, got)
+		}
+	}
+}
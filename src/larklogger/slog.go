@@ -0,0 +1,120 @@
+package larklogger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogOptions configures a SlogHandler
+type SlogOptions struct {
+	// Level is the minimum level sent to Lark (nil = slog.LevelWarn)
+	Level slog.Leveler
+}
+
+// SlogHandler is a slog.Handler that sends records to Lark as log cards.
+// Attributes become card fields; groups are flattened into dotted keys such as "request.method".
+type SlogHandler struct {
+	logger *LarkLogger
+	level  slog.Leveler
+	fields map[string]interface{} // Attributes added by WithAttrs, already qualified
+	prefix string                 // Group path of attributes added from here on, e.g. "request."
+}
+
+// NewSlogHandler creates a slog.Handler logging through logger
+func NewSlogHandler(logger *LarkLogger, opts *SlogOptions) *SlogHandler {
+	var level slog.Leveler = slog.LevelWarn
+	if opts != nil && opts.Level != nil {
+		level = opts.Level
+	}
+	return &SlogHandler{logger: logger, level: level, fields: map[string]interface{}{}}
+}
+
+// SlogLevel maps a slog level onto a LogLevel: Error and above are errors, Warn warnings, anything lower info
+func SlogLevel(level slog.Level) LogLevel {
+	switch {
+	case level >= slog.LevelError:
+		return LevelError
+	case level >= slog.LevelWarn:
+		return LevelWarn
+	default:
+		return LevelInfo
+	}
+}
+
+// Enabled reports whether records at level reach Lark
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle sends the record, delivering with ctx so deadlines and trace context carry over.
+// The card is timestamped with the record time.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make(map[string]interface{}, len(h.fields)+record.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(fields, h.prefix, a)
+		return true
+	})
+	h.logger.LogAtCtx(ctx, record.Time, SlogLevel(record.Level), record.Message, fields)
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	clone := h.clone()
+	for _, a := range attrs {
+		addSlogAttr(clone.fields, clone.prefix, a)
+	}
+	return clone
+}
+
+// WithGroup returns a handler that qualifies later attributes with name
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := h.clone()
+	clone.prefix += name + "."
+	return clone
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
+	fields := make(map[string]interface{}, len(h.fields))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	return &SlogHandler{logger: h.logger, level: h.level, fields: fields, prefix: h.prefix}
+}
+
+// addSlogAttr stores a resolved attribute under prefix, flattening groups. Following slog's rules,
+// empty attributes and empty groups are dropped and groups without a key are inlined.
+func addSlogAttr(fields map[string]interface{}, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+		if len(group) == 0 {
+			return
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range group {
+			addSlogAttr(fields, prefix, ga)
+		}
+	default:
+		if a.Key == "" {
+			return
+		}
+		fields[prefix+a.Key] = a.Value.Any()
+	}
+}
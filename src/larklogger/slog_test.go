@@ -0,0 +1,155 @@
+package larklogger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+// newEntryLogger returns a logger that captures rendered log entries and the context of each send
+func newEntryLogger(entries *[]LogEntry, ctxs *[]context.Context) *LarkLogger {
+	sender := senderFunc(func(ctx context.Context, card *Card) error {
+		*ctxs = append(*ctxs, ctx)
+		return nil
+	})
+	return NewLarkLogger(context.Background(), sender, WithTemplate(LogTemplate{
+		TemplateID: "tpl",
+		Variables: func(entry LogEntry) map[string]interface{} {
+			*entries = append(*entries, entry)
+			return nil
+		},
+	})).(*LarkLogger)
+}
+
+func TestSlogHandler(t *testing.T) {
+	t.Run("level mapping and minimum level", func(t *testing.T) {
+		var entries []LogEntry
+		var ctxs []context.Context
+		logger := slog.New(NewSlogHandler(newEntryLogger(&entries, &ctxs), nil))
+
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+		logger.Log(context.Background(), slog.LevelError+4, "critical")
+
+		if len(entries) != 3 {
+			t.Fatalf("Expected 3 entries at Warn and above, got %d", len(entries))
+		}
+		want := []LogLevel{LevelWarn, LevelError, LevelError}
+		for i, entry := range entries {
+			if entry.Level != want[i] {
+				t.Errorf("Expected %s for %q, got %s", want[i], entry.Message, entry.Level)
+			}
+		}
+	})
+
+	t.Run("custom minimum level", func(t *testing.T) {
+		var entries []LogEntry
+		var ctxs []context.Context
+		handler := NewSlogHandler(newEntryLogger(&entries, &ctxs), &SlogOptions{Level: slog.LevelInfo})
+		slog.New(handler).Info("deployed")
+
+		if len(entries) != 1 || entries[0].Level != LevelInfo {
+			t.Errorf("Expected one info entry, got %+v", entries)
+		}
+		if handler.Enabled(context.Background(), slog.LevelDebug) {
+			t.Error("Expected debug to be disabled")
+		}
+	})
+
+	t.Run("attributes and groups", func(t *testing.T) {
+		var entries []LogEntry
+		var ctxs []context.Context
+		logger := slog.New(NewSlogHandler(newEntryLogger(&entries, &ctxs), nil)).
+			With("service", "payments").
+			WithGroup("request").
+			With("method", "POST")
+
+		logger.Error("charge failed",
+			"status", 502,
+			slog.Group("db", "host", "primary", "latency", 1500*time.Millisecond),
+			slog.Group("empty"),
+			slog.Group("", "inlined", true),
+			"err", errors.New("upstream timeout"),
+		)
+
+		if len(entries) != 1 {
+			t.Fatalf("Expected 1 entry, got %d", len(entries))
+		}
+		fields := entries[0].Fields
+		want := map[string]string{
+			"service":            "payments",
+			"request.method":     "POST",
+			"request.status":     "502",
+			"request.db.host":    "primary",
+			"request.db.latency": "1.5s",
+			"request.inlined":    "true",
+			"request.err":        "upstream timeout",
+		}
+		for k, v := range want {
+			if got := formatValue(fields[k]); got != v {
+				t.Errorf("Expected %s=%s, got %q", k, v, got)
+			}
+		}
+		if _, ok := fields["request.empty"]; ok {
+			t.Error("Expected empty group to be dropped")
+		}
+		if len(fields) != len(want) {
+			t.Errorf("Expected %d fields, got %v", len(want), fields)
+		}
+	})
+
+	t.Run("handler context reaches the sender", func(t *testing.T) {
+		var entries []LogEntry
+		var ctxs []context.Context
+		logger := slog.New(NewSlogHandler(newEntryLogger(&entries, &ctxs), nil))
+
+		ctx := context.WithValue(context.Background(), ctxKey{}, "request-1")
+		logger.ErrorContext(ctx, "boom")
+
+		if len(ctxs) != 1 || ctxs[0].Value(ctxKey{}) != "request-1" {
+			t.Errorf("Expected the record context to be used for sending, got %v", ctxs)
+		}
+	})
+
+	t.Run("record time becomes the card time", func(t *testing.T) {
+		var entries []LogEntry
+		var ctxs []context.Context
+		handler := NewSlogHandler(newEntryLogger(&entries, &ctxs), nil)
+
+		logged := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+		if err := handler.Handle(context.Background(), slog.NewRecord(logged, slog.LevelError, "late", 0)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := handler.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelError, "untimed", 0)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(entries) != 2 || !entries[0].Time.Equal(logged) {
+			t.Fatalf("Expected the record time, got %+v", entries)
+		}
+		if time.Since(entries[1].Time) > time.Minute {
+			t.Errorf("Expected a record without time to use now, got %v", entries[1].Time)
+		}
+	})
+
+	t.Run("WithAttrs does not leak into parent", func(t *testing.T) {
+		var entries []LogEntry
+		var ctxs []context.Context
+		parent := slog.New(NewSlogHandler(newEntryLogger(&entries, &ctxs), nil))
+		parent.With("child", true).Warn("child")
+		parent.Warn("parent")
+
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d", len(entries))
+		}
+		if _, ok := entries[1].Fields["child"]; ok {
+			t.Error("Expected parent handler to be unaffected by With")
+		}
+	})
+}
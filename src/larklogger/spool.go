@@ -0,0 +1,518 @@
+package larklogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	spoolSegmentExt  = ".seg"
+	spoolCursorFile  = "cursor.json"
+	spoolReplayBatch = 100
+)
+
+// ErrSpooled is returned when a message could not be sent now and was written to the spool
+var ErrSpooled = errors.New("message spooled for later delivery")
+
+// ErrSpoolClosed is returned when a message would be spooled or replayed after the client was closed
+var ErrSpoolClosed = errors.New("spool closed")
+
+// SpoolOptions configures the durable on-disk outbox
+type SpoolOptions struct {
+	Dir            string        // Directory holding segment files (required)
+	MaxBytes       int64         // Total spool size cap; oldest segments are dropped beyond it (0 = unbounded)
+	MaxAge         time.Duration // Entries older than this are discarded instead of replayed (0 = unbounded)
+	SegmentBytes   int64         // Rotate to a new segment file after this size (default 1MB)
+	ReplayInterval time.Duration // How often to try replaying a non-empty backlog (default 30s)
+}
+
+// SpoolStats summarizes spool backlog and activity
+type SpoolStats struct {
+	Pending  int       // Entries waiting to be replayed
+	Bytes    int64     // Size of all segment files
+	Segments int       // Number of segment files
+	Oldest   time.Time // Time the oldest pending entry was spooled (zero if empty)
+	Spooled  uint64    // Entries written since the client started
+	Replayed uint64    // Entries delivered by replay
+	Dropped  uint64    // Entries discarded by MaxBytes or permanent send errors
+	Expired  uint64    // Entries discarded by MaxAge
+}
+
+// spoolRecord is one line of a segment file
+type spoolRecord struct {
+	Time    int64           `json:"t"`
+	Payload json.RawMessage `json:"p"`
+}
+
+// spoolSegment describes one append-only segment file
+type spoolSegment struct {
+	id      uint64
+	size    int64
+	entries int
+}
+
+// spoolCursor records replay progress inside the oldest segment
+type spoolCursor struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+	Entries int    `json:"entries"`
+}
+
+// spoolEntry is a record read back for replay
+type spoolEntry struct {
+	record spoolRecord
+	end    int64 // Offset just past this record
+}
+
+// spool is a directory of append-only segment files replayed in order
+type spool struct {
+	opts SpoolOptions
+
+	mu       sync.Mutex
+	segments []*spoolSegment // Oldest first; the last one is the active segment
+	active   *os.File
+	cursor   spoolCursor
+	oldest   time.Time
+	stats    SpoolStats
+	closed   bool // Set by close; append and replay fail afterwards
+
+	replayMu sync.Mutex // Serializes replays
+}
+
+// openSpool opens (or creates) a spool directory and recovers its backlog
+func openSpool(opts SpoolOptions) (*spool, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("spool directory is required")
+	}
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = 1 << 20
+	}
+	if opts.ReplayInterval <= 0 {
+		opts.ReplayInterval = 30 * time.Second
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &spool{opts: opts}
+
+	names, err := filepath.Glob(filepath.Join(opts.Dir, "*"+spoolSegmentExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spool segments: %w", err)
+	}
+	for _, name := range names {
+		id, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seg, err := s.scanSegment(id)
+		if err != nil {
+			return nil, err
+		}
+		s.segments = append(s.segments, seg)
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].id < s.segments[j].id })
+
+	// Segment IDs only grow, so a stale cursor file can never match a new segment
+	var lastID uint64
+	if len(s.segments) > 0 {
+		lastID = s.segments[len(s.segments)-1].id
+	}
+
+	// Empty segments hold nothing to replay; only the newest is kept, to be reused below
+	kept := s.segments[:0]
+	for i, seg := range s.segments {
+		if seg.size == 0 && i < len(s.segments)-1 {
+			_ = os.Remove(s.segmentPath(seg.id))
+			continue
+		}
+		kept = append(kept, seg)
+	}
+	s.segments = kept
+
+	if data, err := os.ReadFile(filepath.Join(opts.Dir, spoolCursorFile)); err == nil {
+		var cursor spoolCursor
+		if json.Unmarshal(data, &cursor) == nil && len(s.segments) > 0 && cursor.Segment == s.segments[0].id {
+			s.cursor = cursor
+		}
+	}
+
+	// The oldest segment may have been fully replayed before the previous run stopped
+	if len(s.segments) > 0 && s.segments[0].size > 0 && s.cursor.Segment == s.segments[0].id &&
+		s.cursor.Offset >= s.segments[0].size {
+		_ = os.Remove(s.segmentPath(s.segments[0].id))
+		s.segments = s.segments[1:]
+	}
+	if len(s.segments) > 0 && s.cursor.Segment != s.segments[0].id {
+		s.cursor = spoolCursor{Segment: s.segments[0].id}
+		s.saveCursorLocked()
+	}
+
+	// Never append to records from a previous run: reuse the newest segment only if it is empty,
+	// otherwise start a fresh active segment
+	if n := len(s.segments); n > 0 && s.segments[n-1].size == 0 {
+		if err := s.openActive(s.segments[n-1].id); err != nil {
+			return nil, err
+		}
+	} else if err := s.openSegment(lastID + 1); err != nil {
+		return nil, err
+	}
+
+	s.oldest = s.peekOldestLocked()
+	return s, nil
+}
+
+func (s *spool) segmentPath(id uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%020d%s", id, spoolSegmentExt))
+}
+
+// scanSegment counts the complete records of an existing segment
+func (s *spool) scanSegment(id uint64) (*spoolSegment, error) {
+	data, err := os.ReadFile(s.segmentPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool segment: %w", err)
+	}
+	return &spoolSegment{id: id, size: int64(len(data)), entries: bytes.Count(data, []byte{'\n'})}, nil
+}
+
+// openSegment creates a new active segment
+func (s *spool) openSegment(id uint64) error {
+	if s.closed {
+		return ErrSpoolClosed
+	}
+	if err := s.openActive(id); err != nil {
+		return err
+	}
+	s.segments = append(s.segments, &spoolSegment{id: id})
+	if len(s.segments) == 1 {
+		s.cursor = spoolCursor{Segment: id}
+	}
+	return nil
+}
+
+// openActive opens the segment file appends go to, creating it if needed
+func (s *spool) openActive(id uint64) error {
+	f, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	if s.active != nil {
+		_ = s.active.Close()
+	}
+	s.active = f
+	return nil
+}
+
+// append durably writes a payload to the active segment
+func (s *spool) append(payload []byte) error {
+	line, err := json.Marshal(spoolRecord{Time: time.Now().UnixNano(), Payload: payload})
+	if err != nil {
+		return fmt.Errorf("failed to encode spool record: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSpoolClosed
+	}
+
+	active := s.segments[len(s.segments)-1]
+	if active.size > 0 && active.size+int64(len(line)) > s.opts.SegmentBytes {
+		if err := s.openSegment(active.id + 1); err != nil {
+			return err
+		}
+		active = s.segments[len(s.segments)-1]
+	}
+
+	if _, err := s.active.Write(line); err != nil {
+		return fmt.Errorf("failed to write spool record: %w", err)
+	}
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+
+	active.size += int64(len(line))
+	active.entries++
+	s.stats.Spooled++
+	if s.oldest.IsZero() {
+		s.oldest = time.Now()
+	}
+
+	s.enforceMaxBytesLocked()
+	return nil
+}
+
+// enforceMaxBytesLocked drops the oldest sealed segments until the spool fits MaxBytes
+func (s *spool) enforceMaxBytesLocked() {
+	if s.opts.MaxBytes <= 0 {
+		return
+	}
+	for len(s.segments) > 1 && s.totalBytesLocked() > s.opts.MaxBytes {
+		oldest := s.segments[0]
+		s.stats.Dropped += uint64(oldest.entries - s.cursor.Entries)
+		s.removeOldestLocked()
+	}
+}
+
+func (s *spool) totalBytesLocked() int64 {
+	var total int64
+	for _, seg := range s.segments {
+		total += seg.size
+	}
+	return total
+}
+
+// removeOldestLocked deletes the oldest segment and resets the cursor to the next one
+func (s *spool) removeOldestLocked() {
+	oldest := s.segments[0]
+	_ = os.Remove(s.segmentPath(oldest.id))
+	s.segments = s.segments[1:]
+	s.cursor = spoolCursor{Segment: s.segments[0].id}
+	s.saveCursorLocked()
+	s.oldest = s.peekOldestLocked()
+}
+
+// saveCursorLocked persists replay progress atomically
+func (s *spool) saveCursorLocked() {
+	data, err := json.Marshal(s.cursor)
+	if err != nil {
+		return
+	}
+	tmp := filepath.Join(s.opts.Dir, spoolCursorFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, filepath.Join(s.opts.Dir, spoolCursorFile))
+}
+
+// pendingLocked counts entries not yet replayed
+func (s *spool) pendingLocked() int {
+	pending := 0
+	for _, seg := range s.segments {
+		pending += seg.entries
+	}
+	return pending - s.cursor.Entries
+}
+
+func (s *spool) pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pendingLocked()
+}
+
+// peekOldestLocked returns the spool time of the next entry to replay
+func (s *spool) peekOldestLocked() time.Time {
+	for _, seg := range s.segments {
+		offset := int64(0)
+		if seg.id == s.cursor.Segment {
+			offset = s.cursor.Offset
+		}
+		if offset >= seg.size {
+			continue
+		}
+		entries, err := s.readSegment(seg.id, offset, 1)
+		if err == nil && len(entries) > 0 {
+			return time.Unix(0, entries[0].record.Time)
+		}
+	}
+	return time.Time{}
+}
+
+// readSegment reads up to limit records starting at offset
+func (s *spool) readSegment(id uint64, offset int64, limit int) ([]spoolEntry, error) {
+	f, err := os.Open(s.segmentPath(id))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, 0); err != nil {
+		return nil, err
+	}
+
+	var entries []spoolEntry
+	reader := bufio.NewReader(f)
+	for len(entries) < limit {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// A partial trailing line is an interrupted write; it is never replayed
+			break
+		}
+		offset += int64(len(line))
+
+		var record spoolRecord
+		if json.Unmarshal(line, &record) != nil {
+			// Corrupt record: skip it but keep the offset moving
+			entries = append(entries, spoolEntry{end: offset})
+			continue
+		}
+		entries = append(entries, spoolEntry{record: record, end: offset})
+	}
+	return entries, nil
+}
+
+// nextBatch returns the next records to replay, sealing the active segment if needed
+func (s *spool) nextBatch() (uint64, []spoolEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, nil, ErrSpoolClosed
+	}
+
+	for s.pendingLocked() > 0 {
+		if len(s.segments) == 1 {
+			// Seal the active segment so replay only reads immutable files
+			if err := s.openSegment(s.segments[0].id + 1); err != nil {
+				return 0, nil, err
+			}
+		}
+
+		oldest := s.segments[0]
+		entries, err := s.readSegment(oldest.id, s.cursor.Offset, spoolReplayBatch)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read spool segment: %w", err)
+		}
+		if len(entries) > 0 {
+			return oldest.id, entries, nil
+		}
+
+		// Fully consumed, empty or truncated by a crash: move on to the next segment
+		s.removeOldestLocked()
+	}
+	return 0, nil, nil
+}
+
+// commit marks an entry as consumed
+func (s *spool) commit(segment uint64, entry spoolEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The segment may have been dropped by MaxBytes meanwhile
+	if len(s.segments) == 0 || s.segments[0].id != segment {
+		return
+	}
+
+	s.cursor.Offset = entry.end
+	s.cursor.Entries++
+
+	if s.cursor.Offset >= s.segments[0].size && len(s.segments) > 1 {
+		s.removeOldestLocked()
+		return
+	}
+	s.saveCursorLocked()
+	s.oldest = s.peekOldestLocked()
+}
+
+// replay sends pending entries in order until the backlog is empty or send fails transiently
+func (s *spool) replay(ctx context.Context, send func(context.Context, []byte) error) error {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	for {
+		segment, entries, err := s.nextBatch()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			switch {
+			case entry.record.Payload == nil:
+				s.countDropped()
+			case s.opts.MaxAge > 0 && time.Since(time.Unix(0, entry.record.Time)) > s.opts.MaxAge:
+				s.countExpired()
+			default:
+				if err := send(ctx, entry.record.Payload); err != nil {
+					if shouldSpool(err) {
+						return err
+					}
+					// Lark will never accept this payload; do not block the backlog on it
+					s.countDropped()
+				} else {
+					s.countReplayed()
+				}
+			}
+			s.commit(segment, entry)
+		}
+	}
+}
+
+func (s *spool) countDropped() {
+	s.mu.Lock()
+	s.stats.Dropped++
+	s.mu.Unlock()
+}
+
+func (s *spool) countExpired() {
+	s.mu.Lock()
+	s.stats.Expired++
+	s.mu.Unlock()
+}
+
+func (s *spool) countReplayed() {
+	s.mu.Lock()
+	s.stats.Replayed++
+	s.mu.Unlock()
+}
+
+// snapshot returns current backlog statistics
+func (s *spool) snapshot() SpoolStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Pending = s.pendingLocked()
+	stats.Bytes = s.totalBytesLocked()
+	stats.Segments = len(s.segments)
+	if stats.Pending > 0 {
+		stats.Oldest = s.oldest
+	}
+	return stats
+}
+
+// close releases the active segment; the spool rejects appends and replays afterwards
+func (s *spool) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.active == nil {
+		return nil
+	}
+	err := s.active.Close()
+	s.active = nil
+	return err
+}
+
+// shouldSpool reports whether a failed send is worth keeping for later delivery
+func shouldSpool(err error) bool {
+	return IsRetryable(err) || IsRateLimited(err) || errors.Is(err, ErrCircuitOpen) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// WithSpool enables the durable on-disk outbox for messages that cannot be delivered now
+func WithSpool(opts SpoolOptions) ClientOption {
+	return func(o *ClientOptions) {
+		o.Spool = &opts
+	}
+}
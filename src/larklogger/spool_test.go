@@ -0,0 +1,275 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func textPayload(text string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{"msg_type": "text", "content": map[string]string{"text": text}})
+	return data
+}
+
+func payloadText(data []byte) string {
+	var payload struct {
+		Content struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	_ = json.Unmarshal(data, &payload)
+	return payload.Content.Text
+}
+
+func TestSpool(t *testing.T) {
+	t.Run("replays in order across restarts", func(t *testing.T) {
+		dir := t.TempDir()
+		sp, err := openSpool(SpoolOptions{Dir: dir, SegmentBytes: 200})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for i := 0; i < 5; i++ {
+			if err := sp.append(textPayload(fmt.Sprintf("msg-%d", i))); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		_ = sp.close()
+
+		// Deliver two messages, then fail
+		sp, _ = openSpool(SpoolOptions{Dir: dir, SegmentBytes: 200})
+		if stats := sp.snapshot(); stats.Pending != 5 || stats.Segments < 2 {
+			t.Fatalf("Expected 5 pending entries across segments, got %+v", stats)
+		}
+		var got []string
+		err = sp.replay(context.Background(), func(ctx context.Context, data []byte) error {
+			if len(got) == 2 {
+				return &APIError{HTTPStatus: http.StatusBadGateway}
+			}
+			got = append(got, payloadText(data))
+			return nil
+		})
+		if err == nil {
+			t.Error("Expected replay to stop on a transient error")
+		}
+		_ = sp.close()
+
+		// Resume from the persisted cursor
+		sp, _ = openSpool(SpoolOptions{Dir: dir, SegmentBytes: 200})
+		defer sp.close()
+		if stats := sp.snapshot(); stats.Pending != 3 {
+			t.Fatalf("Expected 3 pending entries after restart, got %d", stats.Pending)
+		}
+		err = sp.replay(context.Background(), func(ctx context.Context, data []byte) error {
+			got = append(got, payloadText(data))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected := []string{"msg-0", "msg-1", "msg-2", "msg-3", "msg-4"}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+		if stats := sp.snapshot(); stats.Pending != 0 || stats.Replayed != 3 {
+			t.Errorf("Unexpected stats after replay: %+v", stats)
+		}
+	})
+
+	t.Run("restarts do not pile up segments", func(t *testing.T) {
+		dir := t.TempDir()
+		segmentFiles := func() int {
+			names, _ := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+			return len(names)
+		}
+
+		for i := 0; i < 5; i++ {
+			sp, err := openSpool(SpoolOptions{Dir: dir})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if stats := sp.snapshot(); stats.Segments != 1 || segmentFiles() != 1 {
+				t.Fatalf("Expected one segment after restart %d, got %d (%d files)", i, stats.Segments, segmentFiles())
+			}
+			_ = sp.close()
+		}
+
+		// A drained backlog is not kept around either
+		sp, _ := openSpool(SpoolOptions{Dir: dir})
+		_ = sp.append(textPayload("msg-0"))
+		_ = sp.append(textPayload("msg-1"))
+		if err := sp.replay(context.Background(), func(ctx context.Context, data []byte) error { return nil }); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		_ = sp.close()
+
+		for i := 0; i < 3; i++ {
+			sp, _ = openSpool(SpoolOptions{Dir: dir})
+			if stats := sp.snapshot(); stats.Segments != 1 || stats.Pending != 0 || segmentFiles() != 1 {
+				t.Fatalf("Expected one empty segment after replay and restart, got %+v (%d files)", stats, segmentFiles())
+			}
+			_ = sp.close()
+		}
+
+		// A pending backlog survives alongside the new active segment
+		sp, _ = openSpool(SpoolOptions{Dir: dir})
+		_ = sp.append(textPayload("pending"))
+		_ = sp.close()
+		sp, _ = openSpool(SpoolOptions{Dir: dir})
+		defer sp.close()
+		var got []string
+		_ = sp.replay(context.Background(), func(ctx context.Context, data []byte) error {
+			got = append(got, payloadText(data))
+			return nil
+		})
+		if fmt.Sprint(got) != "[pending]" {
+			t.Errorf("Expected the pending entry to be replayed, got %v", got)
+		}
+	})
+
+	t.Run("drops oldest segments beyond MaxBytes", func(t *testing.T) {
+		sp, _ := openSpool(SpoolOptions{Dir: t.TempDir(), SegmentBytes: 100, MaxBytes: 300})
+		defer sp.close()
+		for i := 0; i < 20; i++ {
+			_ = sp.append(textPayload(fmt.Sprintf("msg-%d", i)))
+		}
+
+		stats := sp.snapshot()
+		if stats.Bytes > 300 {
+			t.Errorf("Expected spool to stay within 300 bytes, got %d", stats.Bytes)
+		}
+		if stats.Dropped == 0 || uint64(stats.Pending)+stats.Dropped != 20 {
+			t.Errorf("Expected dropped + pending to account for every entry, got %+v", stats)
+		}
+	})
+
+	t.Run("expires entries beyond MaxAge", func(t *testing.T) {
+		sp, _ := openSpool(SpoolOptions{Dir: t.TempDir(), MaxAge: time.Millisecond})
+		defer sp.close()
+		_ = sp.append(textPayload("stale"))
+		time.Sleep(5 * time.Millisecond)
+
+		sent := 0
+		_ = sp.replay(context.Background(), func(ctx context.Context, data []byte) error {
+			sent++
+			return nil
+		})
+		if stats := sp.snapshot(); sent != 0 || stats.Expired != 1 || stats.Pending != 0 {
+			t.Errorf("Expected the stale entry to expire unsent, sent=%d stats=%+v", sent, stats)
+		}
+	})
+
+	t.Run("skips a truncated trailing record", func(t *testing.T) {
+		dir := t.TempDir()
+		sp, _ := openSpool(SpoolOptions{Dir: dir})
+		_ = sp.append(textPayload("complete"))
+		_ = sp.close()
+
+		// Simulate a crash in the middle of a write
+		segments, _ := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+		f, _ := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0o644)
+		_, _ = f.WriteString(`{"t":1,"p":{"msg_`)
+		_ = f.Close()
+
+		sp, _ = openSpool(SpoolOptions{Dir: dir})
+		defer sp.close()
+		_ = sp.append(textPayload("after restart"))
+
+		var got []string
+		_ = sp.replay(context.Background(), func(ctx context.Context, data []byte) error {
+			got = append(got, payloadText(data))
+			return nil
+		})
+		if fmt.Sprint(got) != "[complete after restart]" {
+			t.Errorf("Expected both complete records to be replayed, got %v", got)
+		}
+	})
+}
+
+func TestLarkClientSpool(t *testing.T) {
+	var healthy atomic.Bool
+	var mu sync.Mutex
+	var received []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var payload map[string]json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		data, _ := json.Marshal(payload)
+		mu.Lock()
+		received = append(received, payloadText(data))
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "msg": "success"})
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := NewLarkClient(server.URL, WithRetry(1, time.Millisecond), WithSpool(SpoolOptions{Dir: dir, ReplayInterval: time.Hour}))
+
+	if err := client.SendText("first"); !errors.Is(err, ErrSpooled) {
+		t.Fatalf("Expected ErrSpooled, got %v", err)
+	}
+
+	// With a backlog, later messages queue behind it and wake the replay instead of waiting for the tick
+	healthy.Store(true)
+	if err := client.SendText("second"); !errors.Is(err, ErrSpooled) {
+		t.Fatalf("Expected ErrSpooled while backlog is pending, got %v", err)
+	}
+	waitDrained(t, client)
+
+	// Messages spooled while Lark is down survive a restart
+	healthy.Store(false)
+	for _, text := range []string{"third", "fourth"} {
+		if err := client.SendText(text); !errors.Is(err, ErrSpooled) {
+			t.Fatalf("Expected ErrSpooled, got %v", err)
+		}
+	}
+	if stats := client.SpoolStats(); stats.Pending != 2 || stats.Oldest.IsZero() {
+		t.Errorf("Expected 2 pending entries, got %+v", stats)
+	}
+	_ = client.Close()
+
+	if err := client.SendText("after close"); !errors.Is(err, ErrSpoolClosed) {
+		t.Errorf("Expected ErrSpoolClosed after Close, got %v", err)
+	}
+	if err := client.ReplaySpool(context.Background()); !errors.Is(err, ErrSpoolClosed) {
+		t.Errorf("Expected replay to fail after Close, got %v", err)
+	}
+
+	healthy.Store(true)
+	// A new client replays the backlog on startup
+	restarted := NewLarkClient(server.URL, WithSpool(SpoolOptions{Dir: dir, ReplayInterval: time.Hour}))
+	defer restarted.Close()
+
+	waitDrained(t, restarted)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(received) != "[first second third fourth]" {
+		t.Errorf("Expected backlog to be replayed in order, got %v", received)
+	}
+}
+
+// waitDrained waits for the background replay to empty the spool
+func waitDrained(t *testing.T, client *LarkClient) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for client.SpoolStats().Pending > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if pending := client.SpoolStats().Pending; pending != 0 {
+		t.Fatalf("Expected the backlog to drain before the replay tick, got %d pending", pending)
+	}
+}
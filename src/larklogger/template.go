@@ -0,0 +1,145 @@
+package larklogger
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CardTypeTemplate is the card body type of cards published from the Lark Card Builder
+const CardTypeTemplate = "template"
+
+// TemplateData references a published card template and fills in its variables
+type TemplateData struct {
+	TemplateID          string                 `json:"template_id"`
+	TemplateVersionName string                 `json:"template_version_name,omitempty"` // Empty = latest published version
+	TemplateVariable    map[string]interface{} `json:"template_variable,omitempty"`
+}
+
+// NewTemplateCard creates a card rendered from a Card Builder template
+func NewTemplateCard(templateID, versionName string, variables map[string]interface{}) *Card {
+	return &Card{
+		MsgType: "interactive",
+		Card: CardData{
+			Type: CardTypeTemplate,
+			Data: &TemplateData{
+				TemplateID:          templateID,
+				TemplateVersionName: versionName,
+				TemplateVariable:    variables,
+			},
+		},
+	}
+}
+
+// IsTemplate reports whether the card is rendered from a template
+func (c *Card) IsTemplate() bool {
+	return c.Card.Type == CardTypeTemplate
+}
+
+// MarshalJSON renders template cards as {"type":"template","data":{...}} without the built-in layout fields
+func (d CardData) MarshalJSON() ([]byte, error) {
+	if d.Type == CardTypeTemplate {
+		return json.Marshal(struct {
+			Type string        `json:"type"`
+			Data *TemplateData `json:"data"`
+		}{d.Type, d.Data})
+	}
+	type cardData CardData
+	return json.Marshal(cardData(d))
+}
+
+// LogEntry is a log message as rendered into template variables
+type LogEntry struct {
+	Level      LogLevel
+	Message    string
+	Fields     map[string]interface{} // Mention values already removed
+	Mentions   []Mention
+	Resolved   bool
+	ResolvedAt time.Time // Set on resolved cards; Time stays when the alert was logged
+	Time       time.Time
+}
+
+// LogTemplate renders log cards from a Card Builder template instead of the built-in layout
+type LogTemplate struct {
+	TemplateID  string
+	VersionName string
+	// Variables maps a log entry to template variables; nil uses the logger's default variables
+	Variables func(entry LogEntry) map[string]interface{}
+}
+
+// WithTemplate renders log cards from a Card Builder template.
+// By default the template receives title, level, level_emoji, color, message, timestamp, service, env,
+// hostname, mentions, resolved, trace_url (with WithTraceURL), fields (a list of {key, value} objects)
+// and every field under its own key.
+func WithTemplate(template LogTemplate) LoggerOption {
+	return func(c *LoggerConfig) {
+		c.Template = &template
+	}
+}
+
+// renderTemplateCard builds a log card from the configured template
+func (l *LarkLogger) renderTemplateCard(entry LogEntry) *Card {
+	tmpl := l.opts.Template
+	variables := l.templateVariables(entry)
+	if tmpl.Variables != nil {
+		variables = tmpl.Variables(entry)
+	}
+	return NewTemplateCard(tmpl.TemplateID, tmpl.VersionName, variables)
+}
+
+// templateVariables returns the default template variables of a log entry
+func (l *LarkLogger) templateVariables(entry LogEntry) map[string]interface{} {
+	variables := make(map[string]interface{}, len(entry.Fields)+12)
+
+	keys := make([]string, 0, len(entry.Fields))
+	for k := range entry.Fields {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	rows := make([]map[string]string, 0, len(keys))
+	for _, k := range keys {
+		value := formatValue(entry.Fields[k])
+		rows = append(rows, map[string]string{"key": k, "value": value})
+		variables[k] = value
+	}
+
+	mentions := make([]string, 0, len(entry.Mentions))
+	for _, m := range entry.Mentions {
+		mentions = append(mentions, m.markdown())
+	}
+
+	color, emoji := getVisualConfig(entry.Level), GetLogLevelEmoji(entry.Level)
+	resolvedAt := ""
+	if entry.Resolved {
+		color, emoji = ColorGreen, EmojiResolved
+		resolvedAt = FormatTimestamp(entry.ResolvedAt)
+	}
+
+	// Built-in variables win over fields of the same name
+	builtins := map[string]interface{}{
+		"title":       l.opts.Title,
+		"level":       strings.ToUpper(string(entry.Level)),
+		"level_emoji": emoji,
+		"color":       color,
+		"message":     entry.Message,
+		"timestamp":   FormatTimestamp(entry.Time),
+		"service":     l.opts.Service,
+		"env":         l.opts.Env,
+		"hostname":    l.opts.Hostname,
+		"mentions":    strings.Join(mentions, " "),
+		"resolved":    entry.Resolved,
+		"resolved_at": resolvedAt,
+		"fields":      rows,
+	}
+	if button, ok := l.opts.traceButton(entry.Fields); ok {
+		builtins["trace_url"] = button.URL
+	}
+	for k, v := range builtins {
+		variables[k] = v
+	}
+	return variables
+}
@@ -0,0 +1,111 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTemplateCard(t *testing.T) {
+	card := NewTemplateCard("AAqk1234", "1.0.2", map[string]interface{}{"message": "hi"})
+
+	data, err := json.Marshal(card)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := `{"msg_type":"interactive","card":{"type":"template","data":{"template_id":"AAqk1234","template_version_name":"1.0.2","template_variable":{"message":"hi"}}}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+	if !card.IsTemplate() {
+		t.Error("Expected IsTemplate to be true")
+	}
+
+	var decoded Card
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Card.Data == nil || decoded.Card.Data.TemplateID != "AAqk1234" {
+		t.Errorf("Expected template to round-trip, got %+v (err %v)", decoded.Card, err)
+	}
+
+	built := NewCardBuilder().SetHeader("Plain", ColorBlue).Build()
+	if data, _ := json.Marshal(built); contains(string(data), `"type"`) || built.IsTemplate() {
+		t.Errorf("Expected built-in cards to be unchanged, got %s", data)
+	}
+}
+
+func TestLoggerTemplate(t *testing.T) {
+	t.Run("default variables", func(t *testing.T) {
+		logger := NewLarkLogger(context.Background(), nil,
+			WithTitle("Payments"),
+			WithService("billing"),
+			WithLevelMentions(LevelError, MentionOpenID("ou_oncall")),
+			WithTemplate(LogTemplate{TemplateID: "AAqk1234", VersionName: "1.0.0"}),
+		).(*LarkLogger)
+
+		card := logger.buildLogCard(LevelError, "Charge failed", map[string]interface{}{"order_id": "o-1", "amount": 42, "level": "spoofed"})
+		if !card.IsTemplate() || card.Card.Data.TemplateID != "AAqk1234" || card.Card.Data.TemplateVersionName != "1.0.0" {
+			t.Fatalf("Expected template card, got %+v", card.Card)
+		}
+
+		vars := card.Card.Data.TemplateVariable
+		checks := map[string]interface{}{
+			"title":    "Payments",
+			"level":    "ERROR",
+			"color":    ColorRed,
+			"message":  "Charge failed",
+			"service":  "billing",
+			"order_id": "o-1",
+			"amount":   "42",
+			"mentions": "<at id=ou_oncall></at>",
+		}
+		for key, want := range checks {
+			if vars[key] != want {
+				t.Errorf("Expected %s=%v, got %v", key, want, vars[key])
+			}
+		}
+		rows, _ := vars["fields"].([]map[string]string)
+		if len(rows) != 3 || rows[0]["key"] != "amount" {
+			t.Errorf("Expected sorted field rows, got %v", rows)
+		}
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		logger := NewLarkLogger(context.Background(), nil, WithTemplate(LogTemplate{TemplateID: "AAqk1234"})).(*LarkLogger)
+		loggedAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+		resolvedAt := loggedAt.Add(time.Hour)
+		vars := logger.renderLogCardAt(LevelError, "down", nil, loggedAt, resolvedAt).Card.Data.TemplateVariable
+		if vars["resolved"] != true || vars["color"] != ColorGreen {
+			t.Errorf("Expected resolved variables, got %v", vars)
+		}
+		if vars["timestamp"] != FormatTimestamp(loggedAt) || vars["resolved_at"] != FormatTimestamp(resolvedAt) {
+			t.Errorf("Expected the original timestamp and a separate resolved_at, got %v / %v", vars["timestamp"], vars["resolved_at"])
+		}
+	})
+
+	t.Run("custom variables", func(t *testing.T) {
+		logger := NewLarkLogger(context.Background(), nil, WithTemplate(LogTemplate{
+			TemplateID: "AAqk1234",
+			Variables: func(entry LogEntry) map[string]interface{} {
+				return map[string]interface{}{"headline": string(entry.Level) + ": " + entry.Message}
+			},
+		})).(*LarkLogger)
+
+		vars := logger.buildLogCard(LevelWarn, "slow", nil).Card.Data.TemplateVariable
+		if len(vars) != 1 || vars["headline"] != "warn: slow" {
+			t.Errorf("Expected custom variables, got %v", vars)
+		}
+	})
+}
+
+func TestAppTemplateCard(t *testing.T) {
+	srv, app := newFakeApp(t)
+
+	if _, err := app.SendCard(ToChat("oc_1"), NewTemplateCard("AAqk1234", "", nil)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	msgs := srv.Messages()
+	if len(msgs) != 1 || string(msgs[0].Card) != `{"type":"template","data":{"template_id":"AAqk1234"}}` {
+		t.Errorf("Expected template content, got %+v", msgs)
+	}
+}
@@ -0,0 +1,70 @@
+package larklogger
+
+import (
+	"context"
+	"strings"
+)
+
+// Field keys used for trace correlation rows
+const (
+	FieldTraceID = "trace_id"
+	FieldSpanID  = "span_id"
+)
+
+// TraceExtractor returns the IDs of the span active in ctx, or empty strings if there is none
+type TraceExtractor func(ctx context.Context) (traceID, spanID string)
+
+// WithTraceExtractor adds trace_id/span_id rows to log cards from the span active in the log context.
+// larkotel.WithTraceContext provides an extractor for OpenTelemetry.
+func WithTraceExtractor(extractor TraceExtractor) LoggerOption {
+	return func(c *LoggerConfig) {
+		c.TraceExtractor = extractor
+	}
+}
+
+// WithTraceURL adds an "Open trace" button to cards that carry a trace_id.
+// The template may contain {trace_id} and {span_id}, e.g. "https://jaeger.example.com/trace/{trace_id}".
+func WithTraceURL(template string) LoggerOption {
+	return func(c *LoggerConfig) {
+		c.TraceURLTemplate = template
+	}
+}
+
+// withTraceFields returns fields with trace_id/span_id rows for the span active in ctx;
+// IDs already present in fields are kept and the caller's map is left untouched
+func (c *LoggerConfig) withTraceFields(ctx context.Context, fields map[string]interface{}) map[string]interface{} {
+	if c.TraceExtractor == nil || ctx == nil {
+		return fields
+	}
+	traceID, spanID := c.TraceExtractor(ctx)
+	if traceID == "" {
+		return fields
+	}
+
+	out := make(map[string]interface{}, len(fields)+2)
+	for k, v := range fields {
+		out[k] = v
+	}
+	if _, ok := out[FieldTraceID]; !ok {
+		out[FieldTraceID] = traceID
+	}
+	if _, ok := out[FieldSpanID]; !ok && spanID != "" {
+		out[FieldSpanID] = spanID
+	}
+	return out
+}
+
+// traceButton returns the "Open trace" button for a card's fields, if a URL template is configured
+func (c *LoggerConfig) traceButton(fields map[string]interface{}) (Button, bool) {
+	if c.TraceURLTemplate == "" {
+		return Button{}, false
+	}
+	traceID, _ := fields[FieldTraceID].(string)
+	if traceID == "" {
+		return Button{}, false
+	}
+	spanID, _ := fields[FieldSpanID].(string)
+
+	url := strings.NewReplacer("{trace_id}", traceID, "{span_id}", spanID).Replace(c.TraceURLTemplate)
+	return Button{Text: "🔍 Open trace", URL: url, Style: ButtonStyleSecondary}, true
+}
@@ -0,0 +1,67 @@
+package larklogger
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type traceKey struct{}
+
+// fakeTraceExtractor reads "trace/span" pairs stored under traceKey
+func fakeTraceExtractor(ctx context.Context) (string, string) {
+	ids, _ := ctx.Value(traceKey{}).([2]string)
+	return ids[0], ids[1]
+}
+
+func TestTraceCorrelation(t *testing.T) {
+	var cards []*Card
+	sender := senderFunc(func(ctx context.Context, card *Card) error {
+		cards = append(cards, card)
+		return nil
+	})
+	ctx := context.WithValue(context.Background(), traceKey{}, [2]string{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"})
+
+	t.Run("adds trace rows and button", func(t *testing.T) {
+		logger := NewLarkLogger(context.Background(), sender,
+			WithTraceExtractor(fakeTraceExtractor),
+			WithTraceURL("https://jaeger.example.com/trace/{trace_id}?uiFind={span_id}"),
+		).(*LarkLogger)
+
+		fields := map[string]interface{}{"user": "alice"}
+		logger.ErrorCtx(ctx, "boom", fields)
+
+		data, _ := json.Marshal(cards[len(cards)-1])
+		if !contains(string(data), "4bf92f3577b34da6a3ce929d0e0e4736") || !contains(string(data), "00f067aa0ba902b7") {
+			t.Error("Expected trace_id and span_id rows")
+		}
+		if !contains(string(data), "https://jaeger.example.com/trace/4bf92f3577b34da6a3ce929d0e0e4736?uiFind=00f067aa0ba902b7") {
+			t.Error("Expected Open trace button URL")
+		}
+		if _, ok := fields[FieldTraceID]; ok {
+			t.Error("Expected caller's fields to be left untouched")
+		}
+	})
+
+	t.Run("keeps explicit trace_id", func(t *testing.T) {
+		logger := NewLarkLogger(context.Background(), sender, WithTraceExtractor(fakeTraceExtractor)).(*LarkLogger)
+		logger.InfoCtx(ctx, "ok", map[string]interface{}{FieldTraceID: "explicit"})
+
+		data, _ := json.Marshal(cards[len(cards)-1])
+		if !contains(string(data), "explicit") || contains(string(data), "4bf92f3577b34da6a3ce929d0e0e4736") {
+			t.Error("Expected explicit trace_id to win over the extracted one")
+		}
+	})
+
+	t.Run("no span no rows", func(t *testing.T) {
+		logger := NewLarkLogger(context.Background(), sender,
+			WithTraceExtractor(fakeTraceExtractor), WithTraceURL("https://jaeger.example.com/trace/{trace_id}"),
+		).(*LarkLogger)
+		logger.InfoCtx(context.Background(), "ok", nil)
+
+		data, _ := json.Marshal(cards[len(cards)-1])
+		if contains(string(data), "trace") {
+			t.Error("Expected no trace rows or button without an active span")
+		}
+	})
+}
@@ -0,0 +1,253 @@
+package larklogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// transport holds the HTTP, retry and rate-limit machinery shared by LarkClient and AppClient
+type transport struct {
+	httpClient  *http.Client
+	opts        *ClientOptions
+	retryPolicy RetryPolicy
+	limiter     *RateLimiter
+	recorder    *Recorder // Non-nil in test mode: messages are captured instead of sent
+}
+
+// newClientOptions applies options on top of the default client configuration
+func newClientOptions(opts ...ClientOption) *ClientOptions {
+	options := &ClientOptions{
+		Timeout:    30 * time.Second,
+		RetryCount: 3,
+		RetryDelay: 1 * time.Second,
+		UserAgent:  "larklogger-go/1.0.0",
+		Headers:    make(map[string]string),
+
+		RateLimitPerSecond: DefaultRateLimitPerSecond,
+		RateLimitPerMinute: DefaultRateLimitPerMinute,
+		RateLimitPolicy:    RateLimitBlock,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+func newTransport(options *ClientOptions) transport {
+	retryPolicy := options.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = NewExponentialBackoff(options.RetryCount, options.RetryDelay)
+	}
+
+	return transport{
+		httpClient:  newHTTPClient(options),
+		opts:        options,
+		retryPolicy: retryPolicy,
+		limiter:     NewRateLimiter(options.RateLimitPerSecond, options.RateLimitPerMinute, options.RateLimitPolicy),
+	}
+}
+
+// newHTTPClient returns the caller's client as-is, or builds one from Timeout, Transport and ProxyURL
+func newHTTPClient(options *ClientOptions) *http.Client {
+	if options.HTTPClient != nil {
+		return options.HTTPClient
+	}
+
+	client := &http.Client{
+		Timeout:   options.Timeout,
+		Transport: options.Transport,
+	}
+
+	if options.ProxyURL != "" {
+		proxy, err := url.Parse(options.ProxyURL)
+		if err != nil {
+			fmt.Printf("Invalid Lark proxy URL, connecting directly: %v\n", err)
+			return client
+		}
+
+		base, ok := client.Transport.(*http.Transport)
+		if client.Transport == nil {
+			base, ok = http.DefaultTransport.(*http.Transport)
+		}
+		if !ok {
+			fmt.Printf("Lark proxy ignored: custom transport %T is not an *http.Transport\n", client.Transport)
+			return client
+		}
+		transport := base.Clone()
+		transport.Proxy = http.ProxyURL(proxy)
+		client.Transport = transport
+	}
+
+	return client
+}
+
+// fitCard trims a copy of card to the payload limit and reports what this send trimmed; a nil card
+// means it was still too large and text should be sent instead
+func (t *transport) fitCard(card *Card) (*Card, string) {
+	fitted, text, report := fitCard(card, t.opts.MaxPayloadBytes)
+	if report != nil {
+		t.reportTrim(*report)
+	}
+	return fitted, text
+}
+
+func (t *transport) reportTrim(report TrimReport) {
+	if t.opts.TrimObserver != nil {
+		t.opts.TrimObserver(report)
+		return
+	}
+	fmt.Printf("Lark card trimmed to fit the payload limit: %s\n", report)
+}
+
+// retryCtx runs attempt against endpoint until it succeeds or the RetryPolicy gives up
+func (t *transport) retryCtx(ctx context.Context, endpoint string, attempt func(context.Context) error) error {
+	return t.instrumentSend(ctx, endpoint, func(ctx context.Context) (int, error) {
+		return t.retryAttempts(ctx, endpoint, attempt)
+	})
+}
+
+// attemptOnceCtx runs attempt against endpoint exactly once, bypassing the RetryPolicy
+func (t *transport) attemptOnceCtx(ctx context.Context, endpoint string, attempt func(context.Context) error) error {
+	return t.instrumentSend(ctx, endpoint, func(ctx context.Context) (int, error) {
+		if err := t.waitLimiter(ctx); err != nil {
+			return 0, err
+		}
+		return 1, t.instrumentAttempt(ctx, endpoint, 1, attempt)
+	})
+}
+
+// waitLimiter waits for the client-side rate limiter, reporting any wait to the RateLimitObserver
+func (t *transport) waitLimiter(ctx context.Context) error {
+	wait, err := t.limiter.Wait(ctx)
+	if t.opts.RateLimitObserver != nil && (wait > 0 || err != nil) {
+		t.opts.RateLimitObserver(wait)
+	}
+	return err
+}
+
+// retryAttempts implements retryCtx, also returning the number of attempts made
+func (t *transport) retryAttempts(ctx context.Context, endpoint string, attempt func(context.Context) error) (int, error) {
+	start := time.Now()
+	exhausted := &RetryExhaustedError{}
+
+	for {
+		// Every attempt, retries included, counts against the bot quota
+		err := t.waitLimiter(ctx)
+		if err != nil {
+			if exhausted.Attempts == 0 {
+				return 0, err
+			}
+			exhausted.Aborted = err
+			return exhausted.Attempts, exhausted
+		}
+
+		exhausted.Attempts++
+
+		err = t.instrumentAttempt(ctx, endpoint, exhausted.Attempts, attempt)
+		if err == nil {
+			return exhausted.Attempts, nil
+		}
+		exhausted.Errors = append(exhausted.Errors, err)
+
+		if ctx.Err() != nil {
+			exhausted.Aborted = ctx.Err()
+			return exhausted.Attempts, exhausted
+		}
+
+		delay, ok := t.retryPolicy.Backoff(exhausted.Attempts, time.Since(start), err)
+		if !ok {
+			return exhausted.Attempts, exhausted
+		}
+
+		if sleepErr := sleepCtx(ctx, delay); sleepErr != nil {
+			exhausted.Aborted = sleepErr
+			return exhausted.Attempts, exhausted
+		}
+	}
+}
+
+// postJSONCtx POSTs a JSON body and returns the response body once Lark reports success
+func (t *transport) postJSONCtx(ctx context.Context, url string, headers map[string]string, data []byte) ([]byte, error) {
+	return t.doJSONCtx(ctx, http.MethodPost, url, headers, data)
+}
+
+// doJSONCtx sends a single JSON request and maps failures to TransportError and APIError
+func (t *transport) doJSONCtx(ctx context.Context, method, url string, headers map[string]string, data []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, &TransportError{Op: "create request", Err: err}
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", t.opts.UserAgent)
+
+	for key, value := range t.opts.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, &TransportError{Op: "send request", Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &TransportError{Op: "read response body", Err: err}
+	}
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+
+	// Open API errors come with a non-200 status and a JSON code; keep the code when present
+	var response struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	parseErr := json.Unmarshal(body, &response)
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{
+			HTTPStatus: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: retryAfter,
+		}
+		if parseErr == nil && response.Code != 0 {
+			apiErr.Code = response.Code
+			apiErr.Msg = response.Msg
+		}
+		return nil, apiErr
+	}
+
+	// Parse response to check for errors
+	if parseErr != nil {
+		return nil, &TransportError{Op: "parse response", Err: parseErr}
+	}
+
+	if response.Code != 0 {
+		msg := response.Msg
+		if msg == "" {
+			msg = "unknown error"
+		}
+		return nil, &APIError{
+			Code:       response.Code,
+			Msg:        msg,
+			HTTPStatus: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: retryAfter,
+		}
+	}
+
+	return body, nil
+}
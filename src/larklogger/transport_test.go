@@ -0,0 +1,106 @@
+package larklogger
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+)
+
+// countingTransport counts requests before delegating to http.DefaultTransport
+type countingTransport struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.count++
+	c.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestHTTPClientOptions(t *testing.T) {
+	srv := fakelark.NewServer(t)
+
+	t.Run("with HTTP client", func(t *testing.T) {
+		custom := &http.Client{Timeout: 5 * time.Second}
+		client := NewLarkClient(srv.WebhookURL(), WithHTTPClient(custom))
+
+		if client.httpClient != custom {
+			t.Error("Expected custom HTTP client to be used as-is")
+		}
+		if err := client.SendText("hello"); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("with transport", func(t *testing.T) {
+		transport := &countingTransport{}
+		client := NewLarkClient(srv.WebhookURL(), WithTransport(transport), WithTimeout(3*time.Second))
+
+		if err := client.SendText("hello"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if transport.count != 1 {
+			t.Errorf("Expected 1 request through custom transport, got %d", transport.count)
+		}
+		if client.httpClient.Timeout != 3*time.Second {
+			t.Errorf("Expected timeout %v, got %v", 3*time.Second, client.httpClient.Timeout)
+		}
+	})
+
+	t.Run("with proxy", func(t *testing.T) {
+		client := NewLarkClient(srv.WebhookURL(), WithProxy("http://proxy.example.com:3128"))
+
+		transport, ok := client.httpClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("Expected *http.Transport, got %T", client.httpClient.Transport)
+		}
+		req, _ := http.NewRequest(http.MethodPost, "https://open.feishu.cn/open-apis/bot/v2/hook/x", nil)
+		proxy, err := transport.Proxy(req)
+		if err != nil || proxy == nil || proxy.Host != "proxy.example.com:3128" {
+			t.Errorf("Expected proxy.example.com:3128, got %v (err %v)", proxy, err)
+		}
+		if transport == http.DefaultTransport {
+			t.Error("Expected default transport to be cloned, not modified")
+		}
+	})
+
+	t.Run("with invalid proxy", func(t *testing.T) {
+		client := NewLarkClient(srv.WebhookURL(), WithProxy("://bad"))
+		if client.httpClient.Transport != nil {
+			t.Errorf("Expected default transport for invalid proxy, got %T", client.httpClient.Transport)
+		}
+	})
+}
+
+// senderFunc adapts a function to Sender
+type senderFunc func(ctx context.Context, card *Card) error
+
+func (f senderFunc) SendCardCtx(ctx context.Context, card *Card) error {
+	return f(ctx, card)
+}
+
+func TestLoggerCustomSender(t *testing.T) {
+	var titles []string
+	sender := senderFunc(func(ctx context.Context, card *Card) error {
+		titles = append(titles, card.Card.Header.Title.Content)
+		return nil
+	})
+
+	logger := NewLarkLogger(context.Background(), sender, WithTitle("Custom")).(*LarkLogger)
+	logger.Info("hello", nil)
+
+	if len(titles) != 1 || !contains(titles[0], "Custom") {
+		t.Errorf("Expected one card through custom sender, got %v", titles)
+	}
+
+	ref, err := logger.Notify(context.Background(), LevelInfo, "hello", nil)
+	if err != nil || ref.MessageID != "" {
+		t.Errorf("Expected ref without message_id, got %+v (err %v)", ref, err)
+	}
+}
@@ -0,0 +1,66 @@
+// Package larklogrus forwards logrus entries to Lark.
+package larklogrus
+
+import (
+	"context"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"github.com/sirupsen/logrus"
+)
+
+// fatalFlushTimeout bounds how long a Fatal or Panic entry waits for delivery before logrus exits or panics
+const fatalFlushTimeout = 5 * time.Second
+
+// Hook is a logrus.Hook sending entries at its levels through a LarkLogger.
+// Hooks run on the logging goroutine, so use an async logger (larklogger.WithAsync) to keep logging fast.
+type Hook struct {
+	logger *larklogger.LarkLogger
+	levels []logrus.Level
+}
+
+// NewHook creates a hook for levels (default Warn and above), e.g. logrus.AddHook(larklogrus.NewHook(lark))
+func NewHook(logger *larklogger.LarkLogger, levels ...logrus.Level) *Hook {
+	if len(levels) == 0 {
+		levels = []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel}
+	}
+	return &Hook{logger: logger, levels: levels}
+}
+
+// Level maps a logrus level onto a LogLevel: Panic, Fatal and Error are errors, Warn warnings, anything lower info
+func Level(level logrus.Level) larklogger.LogLevel {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return larklogger.LevelError
+	case logrus.WarnLevel:
+		return larklogger.LevelWarn
+	default:
+		return larklogger.LevelInfo
+	}
+}
+
+// Levels implements logrus.Hook
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook. Entry data become card fields and the entry's context, if any, is used
+// for sending. Fatal and Panic entries are flushed before returning so they are delivered before the exit.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	fields := make(map[string]interface{}, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		fields[k] = v
+	}
+	if entry.HasCaller() {
+		fields["caller"] = entry.Caller.Function
+	}
+
+	h.logger.LogAtCtx(entry.Context, entry.Time, Level(entry.Level), entry.Message, fields)
+
+	if entry.Level <= logrus.FatalLevel {
+		flushCtx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+		defer cancel()
+		return h.logger.Flush(flushCtx)
+	}
+	return nil
+}
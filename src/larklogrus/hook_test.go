@@ -0,0 +1,121 @@
+package larklogrus
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"github.com/KCNyu/lark-logger/src/larktest"
+	"github.com/sirupsen/logrus"
+)
+
+func newLogrus(hook logrus.Hook) *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	log.SetLevel(logrus.TraceLevel)
+	log.ExitFunc = func(int) {}
+	log.AddHook(hook)
+	return log
+}
+
+func TestHookLevels(t *testing.T) {
+	lark, recorder := larktest.NewLogger()
+	log := newLogrus(NewHook(lark))
+
+	log.Debug("debug")
+	log.Info("info")
+	log.Warn("warn")
+	log.Error("error")
+	log.Fatal("fatal")
+	func() {
+		defer func() { _ = recover() }()
+		log.Panic("panic")
+	}()
+
+	cards := recorder.Recorded()
+	want := []larklogger.LogLevel{larklogger.LevelWarn, larklogger.LevelError, larklogger.LevelError, larklogger.LevelError}
+	if len(cards) != len(want) {
+		t.Fatalf("Expected %d cards at Warn and above, got %d", len(want), len(cards))
+	}
+	for i, card := range cards {
+		if got := larktest.CardLevel(card); got != want[i] {
+			t.Errorf("Expected %s for card %d, got %s", want[i], i, got)
+		}
+	}
+
+	t.Run("configured levels", func(t *testing.T) {
+		lark, recorder := larktest.NewLogger()
+		log := newLogrus(NewHook(lark, logrus.InfoLevel))
+		log.Info("deployed")
+		log.Error("ignored")
+		if cards := recorder.Recorded(); len(cards) != 1 || larktest.CardLevel(cards[0]) != larklogger.LevelInfo {
+			t.Errorf("Expected only the info entry, got %d cards", len(cards))
+		}
+	})
+}
+
+func TestHookFields(t *testing.T) {
+	lark, recorder := larktest.NewLogger()
+	log := newLogrus(NewHook(lark))
+
+	log.WithFields(logrus.Fields{"order_id": "o-1", "status": 502}).
+		WithError(errors.New("upstream timeout")).
+		Error("charge failed")
+
+	cards := recorder.Recorded()
+	if len(cards) != 1 {
+		t.Fatalf("Expected 1 card, got %d", len(cards))
+	}
+	fields := larktest.CardFields(cards[0])
+	if fields["order_id"] != "o-1" || fields["status"] != "502" {
+		t.Errorf("Expected entry data as fields, got %q", fields)
+	}
+	if fields[logrus.ErrorKey] != "upstream timeout" {
+		t.Errorf("Expected error field, got %q", fields[logrus.ErrorKey])
+	}
+
+	t.Run("entry context", func(t *testing.T) {
+		server := larktest.NewServer(t)
+		lark := larklogger.NewLarkLogger(context.Background(), larklogger.NewLarkClient(server.WebhookURL())).(*larklogger.LarkLogger)
+		log := newLogrus(NewHook(lark))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		log.WithContext(ctx).Error("caller gave up")
+		log.Error("sent")
+
+		if cards := server.Cards(); len(cards) != 1 || !larktest.CardContains(cards[0], "sent") {
+			t.Errorf("Expected the entry context to be used for sending, got %d cards", len(cards))
+		}
+	})
+
+	t.Run("entry time", func(t *testing.T) {
+		lark, recorder := larktest.NewLogger()
+		log := newLogrus(NewHook(lark))
+		logged := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+		log.WithTime(logged).Error("late")
+
+		if cards := recorder.Recorded(); len(cards) != 1 || !larktest.CardContains(cards[0], larklogger.FormatTimestamp(logged)) {
+			t.Errorf("Expected the entry time on the card, got %d cards", len(cards))
+		}
+	})
+}
+
+func TestHookFatalFlushes(t *testing.T) {
+	server := larktest.NewServer(t)
+	server.Script(larktest.Slow(50*time.Millisecond), larktest.Slow(50*time.Millisecond))
+	lark := larklogger.NewLarkLogger(context.Background(), larklogger.NewLarkClient(server.WebhookURL()),
+		larklogger.WithAsync(10, 1)).(*larklogger.LarkLogger)
+	defer lark.Close(context.Background())
+	log := newLogrus(NewHook(lark))
+
+	log.Error("queued")
+	log.Fatal("exiting")
+
+	if sent := len(server.Cards()); sent != 2 {
+		t.Errorf("Expected the queue flushed before exit, got %d sent", sent)
+	}
+}
@@ -0,0 +1,132 @@
+// Package larkotel traces lark-logger deliveries with OpenTelemetry and correlates log cards with traces.
+package larkotel
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer
+const ScopeName = "github.com/KCNyu/lark-logger/src/larkotel"
+
+// Span names
+const (
+	SpanSend    = "lark.send"    // One send to an endpoint, retries included
+	SpanAttempt = "lark.attempt" // One HTTP request to Lark
+)
+
+// Tracer is a larklogger.Instrumentation that wraps every send in a span with a child span per attempt
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// Option configures the tracer
+type Option func(*config)
+
+type config struct {
+	provider trace.TracerProvider
+}
+
+// WithTracerProvider sets the provider spans are created with (default otel.GetTracerProvider())
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// NewTracer creates a tracer; pass it to larklogger.WithInstrumentation
+func NewTracer(opts ...Option) *Tracer {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.provider == nil {
+		cfg.provider = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: cfg.provider.Tracer(ScopeName)}
+}
+
+// OnSendStart starts the send span
+func (t *Tracer) OnSendStart(ctx context.Context, event larklogger.SendEvent) context.Context {
+	ctx, _ = t.tracer.Start(ctx, SpanSend,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(eventAttributes(event)...),
+	)
+	return ctx
+}
+
+// OnSendEnd ends the send span, recording the attempts made and the final error
+func (t *Tracer) OnSendEnd(ctx context.Context, event larklogger.SendEvent) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int("lark.attempts", event.Attempt))
+	if event.ShortCircuited {
+		span.SetAttributes(attribute.Bool("lark.short_circuited", true))
+	}
+	endSpan(span, event)
+}
+
+// OnAttempt starts the attempt span; its context is used for the HTTP request
+func (t *Tracer) OnAttempt(ctx context.Context, event larklogger.SendEvent) context.Context {
+	attrs := append(eventAttributes(event), attribute.Int("lark.attempt", event.Attempt))
+	ctx, _ = t.tracer.Start(ctx, SpanAttempt,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx
+}
+
+// OnSuccess ends the attempt span
+func (t *Tracer) OnSuccess(ctx context.Context, event larklogger.SendEvent) {
+	endSpan(trace.SpanFromContext(ctx), event)
+}
+
+// OnFailure ends the attempt span with the error recorded
+func (t *Tracer) OnFailure(ctx context.Context, event larklogger.SendEvent) {
+	endSpan(trace.SpanFromContext(ctx), event)
+}
+
+// eventAttributes returns the span attributes of an event. Only the endpoint host is recorded
+// because webhook URLs embed their token.
+func eventAttributes(event larklogger.SendEvent) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if u, err := url.Parse(event.Endpoint); err == nil && u.Host != "" {
+		attrs = append(attrs, attribute.String("server.address", u.Hostname()))
+	}
+	if event.Level != "" {
+		attrs = append(attrs, attribute.String("lark.level", string(event.Level)))
+	}
+	if event.Route != "" {
+		attrs = append(attrs, attribute.String("lark.route", event.Route))
+	}
+	return attrs
+}
+
+// endSpan sets the span status from the event and ends it
+func endSpan(span trace.Span, event larklogger.SendEvent) {
+	if event.Err != nil {
+		span.RecordError(event.Err)
+		span.SetAttributes(attribute.String("lark.error_class", string(event.Class)))
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End()
+}
+
+// SpanContext returns the trace and span IDs of the span active in ctx; it is a larklogger.TraceExtractor
+func SpanContext(ctx context.Context) (traceID, spanID string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+	return sc.TraceID().String(), sc.SpanID().String()
+}
+
+// WithTraceContext adds trace_id/span_id rows for the active OpenTelemetry span to log cards
+func WithTraceContext() larklogger.LoggerOption {
+	return larklogger.WithTraceExtractor(SpanContext)
+}
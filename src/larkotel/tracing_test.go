@@ -0,0 +1,146 @@
+package larkotel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	var calls int32
+	var lastBody atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		data, _ := json.Marshal(payload)
+		lastBody.Store(string(data))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	client := larklogger.NewLarkClient(server.URL+"/open-apis/bot/v2/hook/secret-token",
+		larklogger.WithInstrumentation(NewTracer(WithTracerProvider(provider))),
+		larklogger.WithRetry(2, time.Millisecond),
+	)
+	logger := larklogger.NewLarkLogger(context.Background(), client,
+		WithTraceContext(),
+		larklogger.WithTraceURL("https://jaeger.example.com/trace/{trace_id}"),
+	).(*larklogger.LarkLogger)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "handle request")
+	logger.ErrorCtx(ctx, "boom", nil)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	var send tracetest.SpanStub
+	var attempts []tracetest.SpanStub
+	for _, span := range spans {
+		switch span.Name {
+		case SpanSend:
+			send = span
+		case SpanAttempt:
+			attempts = append(attempts, span)
+		}
+	}
+
+	t.Run("send span", func(t *testing.T) {
+		if send.Name == "" {
+			t.Fatalf("Expected a %s span, got %d spans", SpanSend, len(spans))
+		}
+		if send.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Error("Expected send span to be a child of the caller's span")
+		}
+		for _, attr := range send.Attributes {
+			if attr.Key == "lark.attempts" && attr.Value.AsInt64() != 2 {
+				t.Errorf("Expected 2 attempts, got %d", attr.Value.AsInt64())
+			}
+			if strings.Contains(attr.Value.Emit(), "secret-token") {
+				t.Errorf("Expected webhook token not to be recorded, got %s=%s", attr.Key, attr.Value.Emit())
+			}
+		}
+	})
+
+	t.Run("attempt spans", func(t *testing.T) {
+		if len(attempts) != 2 {
+			t.Fatalf("Expected 2 attempt spans, got %d", len(attempts))
+		}
+		for _, attempt := range attempts {
+			if attempt.Parent.SpanID() != send.SpanContext.SpanID() {
+				t.Error("Expected attempt span to be a child of the send span")
+			}
+		}
+		if attempts[0].Status.Code != codes.Error || len(attempts[0].Events) == 0 {
+			t.Errorf("Expected first attempt to record an error, got %+v", attempts[0].Status)
+		}
+		if attempts[1].Status.Code == codes.Error {
+			t.Error("Expected second attempt to succeed")
+		}
+	})
+
+	t.Run("card carries trace context", func(t *testing.T) {
+		body, _ := lastBody.Load().(string)
+		traceID := parent.SpanContext().TraceID().String()
+		if !strings.Contains(body, traceID) || !strings.Contains(body, parent.SpanContext().SpanID().String()) {
+			t.Error("Expected trace_id and span_id rows in the card")
+		}
+		if !strings.Contains(body, "https://jaeger.example.com/trace/"+traceID) {
+			t.Error("Expected Open trace button")
+		}
+	})
+}
+
+func TestTracerShortCircuited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	client := larklogger.NewLarkClient(server.URL,
+		larklogger.WithInstrumentation(NewTracer(WithTracerProvider(provider))),
+		larklogger.WithRetry(0, 0),
+		larklogger.WithCircuitBreaker(larklogger.CircuitBreakerOptions{MinRequests: 1, CoolDown: time.Hour}),
+	)
+
+	_ = client.SendText("trips the breaker")
+	exporter.Reset()
+	_ = client.SendText("short-circuited")
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != SpanSend {
+		t.Fatalf("Expected a single %s span, got %d spans", SpanSend, len(spans))
+	}
+	shortCircuited := false
+	for _, attr := range spans[0].Attributes {
+		if attr.Key == "lark.short_circuited" {
+			shortCircuited = attr.Value.AsBool()
+		}
+	}
+	if !shortCircuited || spans[0].Status.Code != codes.Error {
+		t.Errorf("Expected a failed short-circuited send span, got %+v", spans[0].Attributes)
+	}
+}
+
+func TestSpanContext(t *testing.T) {
+	if traceID, spanID := SpanContext(context.Background()); traceID != "" || spanID != "" {
+		t.Errorf("Expected no IDs without a span, got %q %q", traceID, spanID)
+	}
+}
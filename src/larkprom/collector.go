@@ -0,0 +1,167 @@
+// Package larkprom exports lark-logger delivery metrics to Prometheus.
+package larkprom
+
+import (
+	"context"
+	"sync"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AsyncStatsProvider is implemented by async loggers, e.g. *larklogger.LarkLogger
+type AsyncStatsProvider interface {
+	AsyncStats() larklogger.AsyncStats
+}
+
+// Collector is a prometheus.Collector fed by a client's Instrumentation hooks
+type Collector struct {
+	attempts       *prometheus.CounterVec
+	retries        *prometheus.CounterVec
+	latency        *prometheus.HistogramVec
+	shortCircuited *prometheus.CounterVec
+
+	queueDepth *prometheus.Desc
+	enqueued   *prometheus.Desc
+	dropped    *prometheus.Desc
+
+	mu     sync.Mutex
+	queues map[string]AsyncStatsProvider
+}
+
+// Option configures the collector
+type Option func(*config)
+
+type config struct {
+	namespace string
+	buckets   []float64
+}
+
+// WithNamespace sets the metric namespace (default "lark")
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithBuckets sets the send latency histogram buckets, in seconds
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// NewCollector creates a collector; register it with a prometheus.Registerer and pass it to
+// larklogger.WithInstrumentation
+func NewCollector(opts ...Option) *Collector {
+	cfg := &config{
+		namespace: "lark",
+		buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	labels := []string{"level", "route", "status"}
+	return &Collector{
+		attempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "send_attempts_total",
+			Help:      "Delivery attempts to Lark by level, route and status (ok or error class).",
+		}, labels),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "send_retries_total",
+			Help:      "Delivery attempts to Lark that were retries.",
+		}, []string{"level", "route"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.namespace,
+			Name:      "send_duration_seconds",
+			Help:      "Time Lark took to answer a delivery attempt.",
+			Buckets:   cfg.buckets,
+		}, labels),
+		shortCircuited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "send_short_circuited_total",
+			Help:      "Sends refused without a request by an open circuit breaker or a fail-fast limiter, by reason.",
+		}, []string{"level", "route", "reason"}),
+		queueDepth: prometheus.NewDesc(prometheus.BuildFQName(cfg.namespace, "", "queue_depth"),
+			"Messages waiting in an async logger queue.", []string{"queue"}, nil),
+		enqueued: prometheus.NewDesc(prometheus.BuildFQName(cfg.namespace, "", "queue_enqueued_total"),
+			"Messages accepted by an async logger queue.", []string{"queue"}, nil),
+		dropped: prometheus.NewDesc(prometheus.BuildFQName(cfg.namespace, "", "queue_dropped_total"),
+			"Messages dropped by an async logger queue on overflow.", []string{"queue"}, nil),
+		queues: make(map[string]AsyncStatsProvider),
+	}
+}
+
+// WatchQueue exports the queue depth and drop counters of an async logger under the given name
+func (c *Collector) WatchQueue(name string, logger AsyncStatsProvider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queues[name] = logger
+}
+
+// OnAttempt implements larklogger.Instrumentation
+func (c *Collector) OnAttempt(ctx context.Context, event larklogger.SendEvent) context.Context {
+	if event.Attempt > 1 {
+		c.retries.WithLabelValues(string(event.Level), event.Route).Inc()
+	}
+	return ctx
+}
+
+// OnSuccess implements larklogger.Instrumentation
+func (c *Collector) OnSuccess(ctx context.Context, event larklogger.SendEvent) {
+	c.observe(event, "ok")
+}
+
+// OnFailure implements larklogger.Instrumentation
+func (c *Collector) OnFailure(ctx context.Context, event larklogger.SendEvent) {
+	c.observe(event, string(event.Class))
+}
+
+// OnSendStart implements larklogger.SendObserver
+func (c *Collector) OnSendStart(ctx context.Context, event larklogger.SendEvent) context.Context {
+	return ctx
+}
+
+// OnSendEnd implements larklogger.SendObserver, counting sends that never reached Lark
+func (c *Collector) OnSendEnd(ctx context.Context, event larklogger.SendEvent) {
+	if event.ShortCircuited {
+		c.shortCircuited.WithLabelValues(string(event.Level), event.Route, string(event.Class)).Inc()
+	}
+}
+
+func (c *Collector) observe(event larklogger.SendEvent, status string) {
+	level, route := string(event.Level), event.Route
+	c.attempts.WithLabelValues(level, route, status).Inc()
+	c.latency.WithLabelValues(level, route, status).Observe(event.Latency.Seconds())
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.attempts.Describe(ch)
+	c.retries.Describe(ch)
+	c.latency.Describe(ch)
+	c.shortCircuited.Describe(ch)
+	ch <- c.queueDepth
+	ch <- c.enqueued
+	ch <- c.dropped
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.attempts.Collect(ch)
+	c.retries.Collect(ch)
+	c.latency.Collect(ch)
+	c.shortCircuited.Collect(ch)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, logger := range c.queues {
+		stats := logger.AsyncStats()
+		ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(stats.QueueDepth), name)
+		ch <- prometheus.MustNewConstMetric(c.enqueued, prometheus.CounterValue, float64(stats.Enqueued), name)
+		ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped), name)
+	}
+}
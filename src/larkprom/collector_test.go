@@ -0,0 +1,99 @@
+package larkprom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer server.Close()
+
+	collector := NewCollector()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	client := larklogger.NewLarkClient(server.URL,
+		larklogger.WithInstrumentation(collector),
+		larklogger.WithRetry(2, time.Millisecond),
+	)
+	logger := larklogger.NewLarkLogger(context.Background(), client, larklogger.WithAsync(10, 1)).(*larklogger.LarkLogger)
+	collector.WatchQueue("main", logger)
+
+	logger.Error("boom", nil)
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	t.Run("counts attempts by status", func(t *testing.T) {
+		if got := testutil.ToFloat64(collector.attempts.WithLabelValues("error", "", "ok")); got != 1 {
+			t.Errorf("Expected 1 successful attempt, got %v", got)
+		}
+		if got := testutil.ToFloat64(collector.attempts.WithLabelValues("error", "", "server")); got != 1 {
+			t.Errorf("Expected 1 server failure, got %v", got)
+		}
+		if got := testutil.ToFloat64(collector.retries.WithLabelValues("error", "")); got != 1 {
+			t.Errorf("Expected 1 retry, got %v", got)
+		}
+	})
+
+	t.Run("exports latency and queue metrics", func(t *testing.T) {
+		expected := `
+# HELP lark_queue_enqueued_total Messages accepted by an async logger queue.
+# TYPE lark_queue_enqueued_total counter
+lark_queue_enqueued_total{queue="main"} 1
+# HELP lark_queue_depth Messages waiting in an async logger queue.
+# TYPE lark_queue_depth gauge
+lark_queue_depth{queue="main"} 0
+`
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+			"lark_queue_enqueued_total", "lark_queue_depth"); err != nil {
+			t.Error(err)
+		}
+
+		if count := testutil.CollectAndCount(collector, "lark_send_duration_seconds"); count != 2 {
+			t.Errorf("Expected 2 latency series, got %d", count)
+		}
+	})
+}
+
+func TestCollectorShortCircuited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	collector := NewCollector()
+	client := larklogger.NewLarkClient(server.URL,
+		larklogger.WithInstrumentation(collector),
+		larklogger.WithRetry(0, 0),
+		larklogger.WithCircuitBreaker(larklogger.CircuitBreakerOptions{MinRequests: 1, CoolDown: time.Hour}),
+	)
+	logger := larklogger.NewLarkLogger(context.Background(), client)
+
+	logger.Error("trips the breaker", nil)
+	logger.Error("short-circuited", nil)
+
+	if got := testutil.ToFloat64(collector.shortCircuited.WithLabelValues("error", "", "circuit_open")); got != 1 {
+		t.Errorf("Expected 1 short-circuited send, got %v", got)
+	}
+	if got := testutil.ToFloat64(collector.attempts.WithLabelValues("error", "", "server")); got != 1 {
+		t.Errorf("Expected only the real request counted as an attempt, got %v", got)
+	}
+}
@@ -0,0 +1,90 @@
+package larktest
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+	"testing"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+)
+
+// CardText returns every string of a card (title, markdown, KV rows, button labels and URLs),
+// one per line and HTML-unescaped, for substring assertions
+func CardText(card *larklogger.Card) string {
+	data, err := json.Marshal(card)
+	if err != nil {
+		return ""
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return ""
+	}
+
+	var parts []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			parts = append(parts, html.UnescapeString(v))
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(tree)
+	return strings.Join(parts, "\n")
+}
+
+// CardContains reports whether any string of the card contains substr
+func CardContains(card *larklogger.Card, substr string) bool {
+	return strings.Contains(CardText(card), substr)
+}
+
+// AssertCardContains fails the test unless some received card contains substr
+func (s *Server) AssertCardContains(t testing.TB, substr string) {
+	t.Helper()
+	cards := s.Cards()
+	for _, card := range cards {
+		if CardContains(card, substr) {
+			return
+		}
+	}
+	t.Errorf("Expected a card containing %q, got %d cards", substr, len(cards))
+}
+
+// AssertTextContains fails the test unless some received text message contains substr
+func (s *Server) AssertTextContains(t testing.TB, substr string) {
+	t.Helper()
+	var texts []string
+	for _, m := range s.Messages() {
+		if m.MsgType == "text" {
+			if strings.Contains(m.Text, substr) {
+				return
+			}
+			texts = append(texts, m.Text)
+		}
+	}
+	t.Errorf("Expected a text containing %q, got %q", substr, texts)
+}
+
+// AssertMessageCount fails the test unless exactly n messages were accepted
+func (s *Server) AssertMessageCount(t testing.TB, n int) {
+	t.Helper()
+	if got := len(s.Messages()); got != n {
+		t.Errorf("Expected %d messages, got %d", n, got)
+	}
+}
+
+// AssertNoRejections fails the test if any request was refused for its schema, signature or credentials
+func (s *Server) AssertNoRejections(t testing.TB) {
+	t.Helper()
+	for _, r := range s.Rejections() {
+		t.Errorf("Expected no rejections, got %s: %s", r.Path, r.Reason)
+	}
+}
@@ -0,0 +1,44 @@
+package larktest
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+)
+
+func TestServeFixture(t *testing.T) {
+	fixture := filepath.Join("..", "larklogger", "testdata", "events", larklogger.EventTypeMessageReceive+".json")
+
+	for name, opts := range map[string]larklogger.InboundOptions{
+		"plaintext": {VerificationToken: "fixture-verification-token"},
+		"encrypted": {VerificationToken: "fixture-verification-token", EncryptKey: "ekey"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			receiver := larklogger.NewEventReceiver(opts)
+			var args []string
+			receiver.OnMessageReceive(func(ctx context.Context, event *larklogger.MessageReceiveEvent) error {
+				args = event.Args()
+				return nil
+			})
+
+			rec := ServeFixture(t, receiver, fixture, opts)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d %s", rec.Code, rec.Body.String())
+			}
+			if len(args) != 3 || args[0] != "silence" {
+				t.Errorf("Expected command args, got %v", args)
+			}
+		})
+	}
+
+	t.Run("wrong encrypt key rejected", func(t *testing.T) {
+		receiver := larklogger.NewEventReceiver(larklogger.InboundOptions{EncryptKey: "ekey"})
+		rec := ServeFixture(t, receiver, fixture, larklogger.InboundOptions{EncryptKey: "other"})
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rec.Code)
+		}
+	})
+}
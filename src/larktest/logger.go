@@ -0,0 +1,78 @@
+package larktest
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+)
+
+// NewLogger returns a LarkLogger whose cards are recorded instead of sent, with the recorder holding them
+func NewLogger(opts ...larklogger.LoggerOption) (*larklogger.LarkLogger, *larklogger.Recorder) {
+	recorder := larklogger.NewRecorder(larklogger.WithRecorderLimit(0))
+	client := larklogger.NewLarkClient(larklogger.TestWebhookURL, larklogger.WithRecorder(recorder))
+	return larklogger.NewLarkLogger(context.Background(), client, opts...).(*larklogger.LarkLogger), recorder
+}
+
+// CardLevel returns the level a log card was rendered for, from its title emoji, or "" for other cards
+func CardLevel(card *larklogger.Card) larklogger.LogLevel {
+	for _, level := range []larklogger.LogLevel{larklogger.LevelInfo, larklogger.LevelWarn, larklogger.LevelError} {
+		if strings.HasPrefix(card.Card.Header.Title.Content, larklogger.GetLogLevelEmoji(level)+" ") {
+			return level
+		}
+	}
+	return ""
+}
+
+// CardFields returns the rows of a card's "Data Fields" table, e.g. the fields of a log card, by key
+func CardFields(card *larklogger.Card) map[string]string {
+	fields := make(map[string]string)
+	elements := card.Card.Elements
+
+	start := -1
+	for i, el := range elements {
+		if el.Text != nil && strings.HasSuffix(el.Text.Content, "**Data Fields**") {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return fields
+	}
+
+	for _, el := range elements[start:] {
+		switch {
+		case el.Tag == "column_set" && len(el.Columns) == 2:
+			key, value := columnContent(el.Columns[0]), columnContent(el.Columns[1])
+			if key == "**Key**" && value == "**Value**" {
+				continue
+			}
+			fields[fieldKey(key)] = value
+		case el.Tag == "hr":
+			// Separates short rows from stacked long values
+		case el.Tag == "div" && el.Text != nil && strings.HasPrefix(el.Text.Content, "**"):
+			// Stacked long value: "**key**\nvalue"
+			key, value, ok := strings.Cut(el.Text.Content, "**\n")
+			if !ok {
+				return fields
+			}
+			fields[fieldKey(key+"**")] = value
+		default:
+			return fields
+		}
+	}
+	return fields
+}
+
+func columnContent(column larklogger.Column) string {
+	if len(column.Elements) != 1 {
+		return ""
+	}
+	return column.Elements[0].Content
+}
+
+// fieldKey undoes the bold markup and non-breaking characters of a rendered key
+func fieldKey(key string) string {
+	key = strings.TrimSuffix(strings.TrimPrefix(key, "**"), "**")
+	return strings.NewReplacer("\u00a0", " ", "\u2011", "-", "\u2215", "/").Replace(key)
+}
@@ -0,0 +1,46 @@
+package larktest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+)
+
+func TestNewLogger(t *testing.T) {
+	logger, recorder := NewLogger(larklogger.WithTitle("Payments"))
+
+	logger.Error("charge failed", map[string]interface{}{
+		"order-id": "o-1",
+		"status":   502,
+		"trace":    strings.Repeat("frame\n", 20),
+	})
+	logger.Info("deployed", nil)
+
+	cards := recorder.Recorded()
+	if len(cards) != 2 {
+		t.Fatalf("Expected 2 recorded cards, got %d", len(cards))
+	}
+
+	t.Run("level", func(t *testing.T) {
+		if got := CardLevel(cards[0]); got != larklogger.LevelError {
+			t.Errorf("Expected %s, got %s", larklogger.LevelError, got)
+		}
+		if got := CardLevel(cards[1]); got != larklogger.LevelInfo {
+			t.Errorf("Expected %s, got %s", larklogger.LevelInfo, got)
+		}
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		fields := CardFields(cards[0])
+		if len(fields) != 3 || fields["order-id"] != "o-1" || fields["status"] != "502" {
+			t.Errorf("Expected the rendered rows, got %q", fields)
+		}
+		if !strings.HasPrefix(fields["trace"], "frame") {
+			t.Errorf("Expected the stacked long value, got %q", fields["trace"])
+		}
+		if fields := CardFields(cards[1]); len(fields) != 0 {
+			t.Errorf("Expected no rows on a card without fields, got %q", fields)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+// Package larktest provides a fake Lark webhook and Open API server for tests.
+//
+// The server validates payload schema and webhook signatures the way Lark does, records every
+// accepted message, and can be scripted to answer with rate limits, 5xx, slow or malformed responses.
+// InboundRequest and ServeFixture replay recorded events and callbacks against handlers.
+package larktest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/internal/fakelark"
+	"github.com/KCNyu/lark-logger/src/larklogger"
+)
+
+const (
+	// WebhookToken is the token in the URL returned by Server.WebhookURL
+	WebhookToken = fakelark.WebhookToken
+
+	// Default app credentials accepted by the token endpoint
+	AppID     = fakelark.AppID
+	AppSecret = fakelark.AppSecret
+)
+
+// Message is a message accepted by the server
+type Message struct {
+	Path    string
+	MsgType string
+	Card    *larklogger.Card                   // Interactive messages and card updates
+	Text    string                             // Text messages
+	Post    map[string]*larklogger.PostContent // Post messages, by language
+	Signed  bool                               // The webhook payload carried a valid signature
+	Raw     []byte                             // Request body
+	Header  http.Header                        // Request headers
+
+	// Open API only
+	ReceiveIDType string
+	ReceiveID     string
+	MessageID     string // Assigned message_id, or the message a card update targets
+	Update        bool   // A PATCH of an existing card
+}
+
+// Rejection is a request the server refused because of its schema, signature or credentials
+type Rejection = fakelark.Rejection
+
+// Response scripts the answer to one message request
+type Response = fakelark.Response
+
+// OK accepts the message normally
+func OK() Response {
+	return fakelark.OK()
+}
+
+// Slow accepts the message after delay
+func Slow(delay time.Duration) Response {
+	return fakelark.Slow(delay)
+}
+
+// RateLimited answers with Lark's frequency limit code
+func RateLimited() Response {
+	return fakelark.RateLimited()
+}
+
+// ServerError answers with the given 5xx status
+func ServerError(status int) Response {
+	return fakelark.ServerError(status)
+}
+
+// Malformed answers 200 with a body that is not JSON
+func Malformed() Response {
+	return fakelark.Malformed()
+}
+
+// Reject answers with a Lark error code
+func Reject(code int, msg string) Response {
+	return fakelark.Reject(code, msg)
+}
+
+// Option configures the server
+type Option = fakelark.Option
+
+// WithSecret requires webhook payloads to be signed with secret
+func WithSecret(secret string) Option {
+	return fakelark.WithSecret(secret)
+}
+
+// WithApp sets the app credentials accepted by the token endpoint (default AppID/AppSecret)
+func WithApp(appID, appSecret string) Option {
+	return fakelark.WithApp(appID, appSecret)
+}
+
+// WithTokenExpiry sets the lifetime reported for issued tenant_access_tokens (default 2h)
+func WithTokenExpiry(expiry time.Duration) Option {
+	return fakelark.WithTokenExpiry(expiry)
+}
+
+// Server is a fake Lark webhook and Open API server
+type Server struct {
+	URL string
+
+	fake *fakelark.Server
+}
+
+// NewServer starts a fake server that is closed when the test ends
+func NewServer(t testing.TB, opts ...Option) *Server {
+	fake := fakelark.NewServer(t, opts...)
+	return &Server{URL: fake.URL, fake: fake}
+}
+
+// WebhookURL returns the custom bot webhook URL served by the server
+func (s *Server) WebhookURL() string {
+	return s.fake.WebhookURL()
+}
+
+// BaseURL returns the Open API base URL for larklogger.WithBaseURL
+func (s *Server) BaseURL() string {
+	return s.fake.BaseURL()
+}
+
+// Script queues responses for the next message requests, in order; once they are used up
+// messages are accepted normally
+func (s *Server) Script(responses ...Response) {
+	s.fake.Script(responses...)
+}
+
+// Requests returns how many message requests were received, including rejected and scripted ones
+func (s *Server) Requests() int {
+	return s.fake.Requests()
+}
+
+// Messages returns the accepted messages, in order
+func (s *Server) Messages() []Message {
+	raw := s.fake.Messages()
+	messages := make([]Message, 0, len(raw))
+	for _, m := range raw {
+		msg := Message{
+			Path:          m.Path,
+			MsgType:       m.MsgType,
+			Text:          m.Text,
+			Signed:        m.Signed,
+			Raw:           m.Raw,
+			Header:        m.Header,
+			ReceiveIDType: m.ReceiveIDType,
+			ReceiveID:     m.ReceiveID,
+			MessageID:     m.MessageID,
+			Update:        m.Update,
+		}
+		if m.Card != nil {
+			msg.Card = &larklogger.Card{MsgType: "interactive"}
+			_ = json.Unmarshal(m.Card, &msg.Card.Card)
+		}
+		if m.Post != nil {
+			_ = json.Unmarshal(m.Post, &msg.Post)
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// Cards returns the cards of the accepted messages, card updates included
+func (s *Server) Cards() []*larklogger.Card {
+	var cards []*larklogger.Card
+	for _, m := range s.Messages() {
+		if m.Card != nil {
+			cards = append(cards, m.Card)
+		}
+	}
+	return cards
+}
+
+// Rejections returns the requests refused for their schema, signature or credentials
+func (s *Server) Rejections() []Rejection {
+	return s.fake.Rejections()
+}
+
+// RevokeTokens invalidates every tenant_access_token issued so far, as when Lark expires one early
+func (s *Server) RevokeTokens() {
+	s.fake.RevokeTokens()
+}
+
+// Reset forgets recorded messages, rejections and any remaining script
+func (s *Server) Reset() {
+	s.fake.Reset()
+}
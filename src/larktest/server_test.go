@@ -0,0 +1,193 @@
+package larktest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+)
+
+// fakeTB records failures instead of failing the test
+type fakeTB struct {
+	testing.TB
+	failures []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func TestWebhook(t *testing.T) {
+	t.Run("records cards and texts", func(t *testing.T) {
+		srv := NewServer(t)
+		client := larklogger.NewLarkClient(srv.WebhookURL())
+		logger := larklogger.NewLarkLogger(context.Background(), client)
+
+		logger.Error("Database down", map[string]interface{}{"error_code": "SYS_001"})
+		if err := client.SendText("hello"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		srv.AssertNoRejections(t)
+		srv.AssertMessageCount(t, 2)
+		srv.AssertCardContains(t, "SYS_001")
+		srv.AssertTextContains(t, "hello")
+
+		ft := &fakeTB{TB: t}
+		srv.AssertCardContains(ft, "SYS_404")
+		if len(ft.failures) != 1 {
+			t.Errorf("Expected AssertCardContains to fail for missing text, got %v", ft.failures)
+		}
+	})
+
+	t.Run("validates signatures", func(t *testing.T) {
+		srv := NewServer(t, WithSecret("s3cret"))
+
+		good := larklogger.NewLarkClient(srv.WebhookURL(), larklogger.WithSecret("s3cret"))
+		if err := good.SendText("signed"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if msgs := srv.Messages(); len(msgs) != 1 || !msgs[0].Signed {
+			t.Errorf("Expected one signed message, got %+v", msgs)
+		}
+
+		bad := larklogger.NewLarkClient(srv.WebhookURL(), larklogger.WithSecret("wrong"), larklogger.WithRetry(0, 0))
+		err := bad.SendText("forged")
+		var apiErr *larklogger.APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != larklogger.CodeSignMismatch {
+			t.Errorf("Expected signature error, got %v", err)
+		}
+		if len(srv.Rejections()) != 1 {
+			t.Errorf("Expected one rejection, got %+v", srv.Rejections())
+		}
+	})
+
+	t.Run("accepts template cards", func(t *testing.T) {
+		srv := NewServer(t)
+		client := larklogger.NewLarkClient(srv.WebhookURL())
+		if err := client.SendCard(larklogger.NewTemplateCard("AAqk1234", "", map[string]interface{}{"code": "SYS_001"})); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		srv.AssertNoRejections(t)
+		srv.AssertCardContains(t, "SYS_001")
+	})
+
+	t.Run("validates schema", func(t *testing.T) {
+		srv := NewServer(t)
+		payloads := []string{
+			`not json`,
+			`{"msg_type":"text","content":{"text":""}}`,
+			`{"msg_type":"sticker","content":{}}`,
+			`{"msg_type":"interactive","card":{"elements":[{"text":{}}]}}`,
+			`{"msg_type":"post","content":{"post":{}}}`,
+			`{"msg_type":"interactive","card":{"type":"template","data":{}}}`,
+		}
+		for _, p := range payloads {
+			resp, err := http.Post(srv.WebhookURL(), "application/json", bytes.NewBufferString(p))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			resp.Body.Close()
+		}
+
+		if got := len(srv.Rejections()); got != len(payloads) {
+			t.Errorf("Expected %d rejections, got %d: %+v", len(payloads), got, srv.Rejections())
+		}
+		srv.AssertMessageCount(t, 0)
+	})
+}
+
+func TestScript(t *testing.T) {
+	t.Run("5xx is retried", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.Script(ServerError(http.StatusBadGateway), ServerError(http.StatusServiceUnavailable))
+
+		client := larklogger.NewLarkClient(srv.WebhookURL(), larklogger.WithRetry(3, time.Millisecond))
+		if err := client.SendText("eventually"); err != nil {
+			t.Fatalf("Expected no error after retries, got %v", err)
+		}
+		if srv.Requests() != 3 {
+			t.Errorf("Expected 3 requests, got %d", srv.Requests())
+		}
+		srv.AssertMessageCount(t, 1)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.Script(RateLimited())
+
+		client := larklogger.NewLarkClient(srv.WebhookURL(), larklogger.WithRetry(0, 0))
+		if err := client.SendText("hello"); !larklogger.IsRateLimited(err) {
+			t.Errorf("Expected rate limit error, got %v", err)
+		}
+		srv.AssertMessageCount(t, 0)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.Script(Malformed())
+
+		client := larklogger.NewLarkClient(srv.WebhookURL(), larklogger.WithRetry(0, 0))
+		err := client.SendText("hello")
+		if larklogger.ClassifyError(err) != larklogger.ErrorClassTransport {
+			t.Errorf("Expected transport error, got %v", err)
+		}
+	})
+
+	t.Run("slow response times out", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.Script(Slow(200 * time.Millisecond))
+
+		client := larklogger.NewLarkClient(srv.WebhookURL(), larklogger.WithRetry(0, 0), larklogger.WithTimeout(20*time.Millisecond))
+		if err := client.SendText("hello"); err == nil {
+			t.Error("Expected timeout error")
+		}
+	})
+
+	t.Run("reject code", func(t *testing.T) {
+		srv := NewServer(t)
+		srv.Script(Reject(19024, "Key Words Not Found"))
+
+		client := larklogger.NewLarkClient(srv.WebhookURL(), larklogger.WithRetry(0, 0))
+		if err := client.SendText("hello"); larklogger.ClassifyError(err) != larklogger.ErrorClassRejected {
+			t.Errorf("Expected rejected error, got %v", err)
+		}
+	})
+}
+
+func TestOpenAPI(t *testing.T) {
+	srv := NewServer(t)
+	app := larklogger.NewAppClient(AppID, AppSecret, larklogger.WithBaseURL(srv.BaseURL()), larklogger.WithRetry(0, 0))
+	logger := larklogger.NewAppLogger(context.Background(), app, larklogger.ToChat("oc_ops")).(*larklogger.LarkLogger)
+
+	ref, err := logger.Notify(context.Background(), larklogger.LevelError, "Disk full", map[string]interface{}{"host": "db-1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := logger.Resolve(ref, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	srv.AssertNoRejections(t)
+	msgs := srv.Messages()
+	if len(msgs) != 2 || msgs[0].ReceiveID != "oc_ops" || msgs[0].ReceiveIDType != "chat_id" {
+		t.Fatalf("Expected a send and an update to oc_ops, got %+v", msgs)
+	}
+	if !msgs[1].Update || msgs[1].MessageID != ref.MessageID || !CardContains(msgs[1].Card, "Resolved") {
+		t.Errorf("Expected resolved card update of %s, got %+v", ref.MessageID, msgs[1])
+	}
+
+	t.Run("bad credentials", func(t *testing.T) {
+		bad := larklogger.NewAppClient(AppID, "wrong", larklogger.WithBaseURL(srv.BaseURL()), larklogger.WithRetry(0, 0))
+		if _, err := bad.SendText(larklogger.ToChat("oc_ops"), "hi"); err == nil {
+			t.Error("Expected error for bad credentials")
+		}
+	})
+}
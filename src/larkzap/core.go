@@ -0,0 +1,355 @@
+// Package larkzap sends selected zap logs to Lark.
+//
+// Core is a zapcore.Core meant to be teed with an existing core. Writes only encode the entry into an
+// in-memory buffer; a background goroutine delivers buffered entries in batches, so zap logging never
+// waits on Lark. A batch becomes one card per level: a lone entry is sent as a regular log card, several
+// entries as a digest card with one row per entry. When the buffer is full, entries are dropped and counted.
+package larkzap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"go.uber.org/zap/zapcore"
+)
+
+// Defaults for the delivery buffer
+const (
+	DefaultBufferSize    = 1024
+	DefaultBatchSize     = 50
+	DefaultFlushInterval = time.Second
+)
+
+// fatalFlushTimeout bounds how long a DPanic, Panic or Fatal entry waits for delivery before zap panics or exits
+const fatalFlushTimeout = 5 * time.Second
+
+// Option configures a Core
+type Option func(*config)
+
+type config struct {
+	bufferSize    int
+	batchSize     int
+	flushInterval time.Duration
+}
+
+// WithBufferSize sets how many entries may wait for delivery before new ones are dropped
+func WithBufferSize(size int) Option {
+	return func(c *config) {
+		c.bufferSize = size
+	}
+}
+
+// WithBatch delivers buffered entries once size of them are waiting, or every interval, combining
+// the entries of each level into one card
+func WithBatch(size int, interval time.Duration) Option {
+	return func(c *config) {
+		c.batchSize = size
+		c.flushInterval = interval
+	}
+}
+
+// Level maps a zap level onto a LogLevel: Error, DPanic, Panic and Fatal are errors, Warn warnings,
+// anything lower info
+func Level(level zapcore.Level) larklogger.LogLevel {
+	switch {
+	case level >= zapcore.ErrorLevel:
+		return larklogger.LevelError
+	case level == zapcore.WarnLevel:
+		return larklogger.LevelWarn
+	default:
+		return larklogger.LevelInfo
+	}
+}
+
+// Core is a zapcore.Core delivering entries to a LarkLogger
+type Core struct {
+	zapcore.LevelEnabler
+	fields map[string]interface{} // Fields added by With, already encoded
+	sink   *sink                  // Shared by every Core derived with With
+}
+
+// NewCore creates a core sending entries enabled by enab through logger, e.g.
+// zapcore.NewTee(existing, larkzap.NewCore(lark, zapcore.WarnLevel))
+func NewCore(logger *larklogger.LarkLogger, enab zapcore.LevelEnabler, opts ...Option) *Core {
+	cfg := &config{
+		bufferSize:    DefaultBufferSize,
+		batchSize:     DefaultBatchSize,
+		flushInterval: DefaultFlushInterval,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.batchSize <= 0 {
+		cfg.batchSize = 1
+	}
+	if cfg.flushInterval <= 0 {
+		cfg.flushInterval = DefaultFlushInterval
+	}
+
+	s := &sink{
+		logger:        logger,
+		entries:       make(chan entry, cfg.bufferSize),
+		batchSize:     cfg.batchSize,
+		flushInterval: cfg.flushInterval,
+		syncs:         make(chan chan struct{}),
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go s.run()
+	return &Core{LevelEnabler: enab, fields: map[string]interface{}{}, sink: s}
+}
+
+// With returns a core adding fields to every entry
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	clone := &Core{LevelEnabler: c.LevelEnabler, fields: make(map[string]interface{}, len(c.fields)+len(fields)), sink: c.sink}
+	for k, v := range c.fields {
+		clone.fields[k] = v
+	}
+	encodeFields(clone.fields, fields)
+	return clone
+}
+
+// Check adds the core to ce when the entry's level is enabled
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write encodes the entry and queues it for delivery without blocking. DPanic, Panic and Fatal entries
+// are delivered before Write returns, since zap panics or exits right after and never calls Sync.
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	out := make(map[string]interface{}, len(c.fields)+len(fields)+3)
+	for k, v := range c.fields {
+		out[k] = v
+	}
+	encodeFields(out, fields)
+	if ent.LoggerName != "" {
+		out["logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		out["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		out["stacktrace"] = ent.Stack
+	}
+
+	e := entry{level: Level(ent.Level), message: ent.Message, fields: out, time: ent.Time}
+	if ent.Level > zapcore.ErrorLevel {
+		ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+		defer cancel()
+		c.sink.enqueueCtx(ctx, e)
+		return c.sink.sync(ctx)
+	}
+	c.sink.enqueue(e)
+	return nil
+}
+
+// Sync waits until every queued entry has been delivered, e.g. from logger.Sync() before the program exits
+func (c *Core) Sync() error {
+	return c.sink.sync(context.Background())
+}
+
+// Close delivers the queued entries and stops the background goroutine; later entries are dropped
+func (c *Core) Close(ctx context.Context) error {
+	return c.sink.close(ctx)
+}
+
+// Dropped returns the number of entries dropped because the buffer was full or the core closed
+func (c *Core) Dropped() uint64 {
+	return c.sink.dropped.Load()
+}
+
+// encodeFields adds zap fields to out, flattening namespaces and objects into dotted keys
+func encodeFields(out map[string]interface{}, fields []zapcore.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	flatten(out, "", enc.Fields)
+}
+
+func flatten(out map[string]interface{}, prefix string, fields map[string]interface{}) {
+	for k, v := range fields {
+		if nested, ok := v.(map[string]interface{}); ok {
+			flatten(out, prefix+k+".", nested)
+			continue
+		}
+		out[prefix+k] = v
+	}
+}
+
+// entry is an encoded zap entry waiting for delivery
+type entry struct {
+	level   larklogger.LogLevel
+	message string
+	fields  map[string]interface{}
+	time    time.Time
+}
+
+// digestTimeFormat is the time shown on each row of a digest card
+const digestTimeFormat = "15:04:05.000"
+
+// digest combines several entries of one level into a single log card, one row per entry in order
+func digest(entries []entry) (string, map[string]interface{}) {
+	message := fmt.Sprintf("%s (+%d more)", entries[0].message, len(entries)-1)
+	width := len(fmt.Sprint(len(entries)))
+
+	rows := make(map[string]interface{}, len(entries))
+	for i, e := range entries {
+		keys := make([]string, 0, len(e.fields))
+		for k := range e.fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		parts := []string{e.message}
+		for _, k := range keys {
+			parts = append(parts, fmt.Sprintf("%s=%v", k, e.fields[k]))
+		}
+		// Zero-padded indexes keep the rows in logging order
+		rows[fmt.Sprintf("#%0*d %s", width, i+1, e.time.Format(digestTimeFormat))] = strings.Join(parts, " ")
+	}
+	return message, rows
+}
+
+// sink buffers entries and delivers them from a single goroutine
+type sink struct {
+	logger        *larklogger.LarkLogger
+	entries       chan entry
+	batchSize     int
+	flushInterval time.Duration
+
+	syncs     chan chan struct{} // Sync requests, answered once the buffer is delivered
+	quit      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	dropped atomic.Uint64
+}
+
+func (s *sink) enqueue(e entry) {
+	select {
+	case <-s.quit:
+		s.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case s.entries <- e:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// enqueueCtx queues an entry that must not be dropped, waiting for buffer space until ctx is done
+func (s *sink) enqueueCtx(ctx context.Context, e entry) {
+	select {
+	case <-s.quit:
+		s.dropped.Add(1)
+	case s.entries <- e:
+	case <-ctx.Done():
+		s.dropped.Add(1)
+	}
+}
+
+func (s *sink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]entry, 0, s.batchSize)
+	deliver := func() {
+		for _, group := range groupByLevel(batch) {
+			if len(group) == 1 {
+				s.logger.LogAt(group[0].time, group[0].level, group[0].message, group[0].fields)
+				continue
+			}
+			message, rows := digest(group)
+			s.logger.LogAt(group[0].time, group[0].level, message, rows)
+		}
+		batch = batch[:0]
+	}
+	drain := func() {
+		for {
+			select {
+			case e := <-s.entries:
+				batch = append(batch, e)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case e := <-s.entries:
+			batch = append(batch, e)
+			if len(batch) >= s.batchSize {
+				deliver()
+			}
+		case <-ticker.C:
+			deliver()
+		case done := <-s.syncs:
+			drain()
+			deliver()
+			close(done)
+		case <-s.quit:
+			drain()
+			deliver()
+			return
+		}
+	}
+}
+
+// groupByLevel splits a batch into per-level groups, in order of each level's first entry
+func groupByLevel(batch []entry) [][]entry {
+	var groups [][]entry
+	index := make(map[larklogger.LogLevel]int)
+	for _, e := range batch {
+		i, ok := index[e.level]
+		if !ok {
+			i = len(groups)
+			index[e.level] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], e)
+	}
+	return groups
+}
+
+// sync waits until the buffered entries have been handed to the logger and the logger has flushed them
+func (s *sink) sync(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case s.syncs <- done:
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.logger.Flush(ctx)
+}
+
+func (s *sink) close(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.quit) })
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.logger.Flush(ctx)
+}
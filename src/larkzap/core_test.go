@@ -0,0 +1,256 @@
+package larkzap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"github.com/KCNyu/lark-logger/src/larktest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// delivered counts the zap entries behind recorded cards, digest cards having one row per entry
+func delivered(cards []*larklogger.Card) uint64 {
+	var n uint64
+	for _, card := range cards {
+		if larktest.CardContains(card, " more)") {
+			n += uint64(len(larktest.CardFields(card)))
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+func TestCoreLevels(t *testing.T) {
+	lark, recorder := larktest.NewLogger()
+	core := NewCore(lark, zapcore.WarnLevel)
+	defer core.Close(context.Background())
+	logger := zap.New(core)
+
+	// Sync after each entry so every entry gets its own card
+	for _, log := range []func(string, ...zap.Field){logger.Debug, logger.Info, logger.Warn, logger.Error, logger.DPanic} {
+		log("entry")
+		if err := logger.Sync(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	cards := recorder.Recorded()
+	if len(cards) != 3 {
+		t.Fatalf("Expected 3 cards at Warn and above, got %d", len(cards))
+	}
+	want := []larklogger.LogLevel{larklogger.LevelWarn, larklogger.LevelError, larklogger.LevelError}
+	for i, card := range cards {
+		if got := larktest.CardLevel(card); got != want[i] {
+			t.Errorf("Expected %s for card %d, got %s", want[i], i, got)
+		}
+	}
+
+	t.Run("level mapping", func(t *testing.T) {
+		cases := map[zapcore.Level]larklogger.LogLevel{
+			zapcore.DebugLevel: larklogger.LevelInfo,
+			zapcore.InfoLevel:  larklogger.LevelInfo,
+			zapcore.WarnLevel:  larklogger.LevelWarn,
+			zapcore.PanicLevel: larklogger.LevelError,
+			zapcore.FatalLevel: larklogger.LevelError,
+		}
+		for level, want := range cases {
+			if got := Level(level); got != want {
+				t.Errorf("Expected %s for %s, got %s", want, level, got)
+			}
+		}
+	})
+}
+
+func TestCoreFields(t *testing.T) {
+	lark, recorder := larktest.NewLogger()
+	core := NewCore(lark, zapcore.InfoLevel)
+	defer core.Close(context.Background())
+	logger := zap.New(core, zap.AddCaller()).Named("payments").With(zap.String("region", "eu"))
+
+	logger.Error("charge failed",
+		zap.Int("status", 502),
+		zap.Duration("latency", 1500*time.Millisecond),
+		zap.Error(errors.New("upstream timeout")),
+		zap.Namespace("order"),
+		zap.String("id", "o-1"),
+	)
+	_ = logger.Sync()
+
+	cards := recorder.Recorded()
+	if len(cards) != 1 {
+		t.Fatalf("Expected 1 card, got %d", len(cards))
+	}
+	if !larktest.CardContains(cards[0], "charge failed") {
+		t.Error("Expected the message on the card")
+	}
+	fields := larktest.CardFields(cards[0])
+	want := map[string]string{
+		"region":   "eu",
+		"status":   "502",
+		"latency":  "1.5s",
+		"error":    "upstream timeout",
+		"order.id": "o-1",
+		"logger":   "payments",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("Expected %s=%s, got %q", k, v, fields[k])
+		}
+	}
+	if !strings.Contains(fields["caller"], "core_test.go") {
+		t.Errorf("Expected caller, got %q", fields["caller"])
+	}
+}
+
+func TestCoreNonBlocking(t *testing.T) {
+	server := larktest.NewServer(t)
+	server.Script(larktest.Slow(300 * time.Millisecond))
+	lark := larklogger.NewLarkLogger(context.Background(), larklogger.NewLarkClient(server.WebhookURL())).(*larklogger.LarkLogger)
+	core := NewCore(lark, zapcore.WarnLevel, WithBufferSize(2), WithBatch(1, time.Hour))
+	logger := zap.New(core)
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		logger.Error("flood")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected logging not to wait on Lark, took %v", elapsed)
+	}
+	if core.Dropped() == 0 {
+		t.Error("Expected entries beyond the buffer to be dropped")
+	}
+
+	if err := core.Close(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := delivered(server.Cards()) + core.Dropped(); got != 100 {
+		t.Errorf("Expected every entry delivered or dropped, got %d", got)
+	}
+
+	dropped := core.Dropped()
+	logger.Error("after close")
+	if core.Dropped() != dropped+1 {
+		t.Error("Expected entries after Close to be dropped")
+	}
+}
+
+func TestCoreBatching(t *testing.T) {
+	lark, recorder := larktest.NewLogger()
+	core := NewCore(lark, zapcore.WarnLevel, WithBatch(4, time.Hour))
+	defer core.Close(context.Background())
+
+	start := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	write := func(level zapcore.Level, message string, offset time.Duration, fields ...zapcore.Field) {
+		if err := core.Write(zapcore.Entry{Level: level, Message: message, Time: start.Add(offset)}, fields); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	write(zapcore.ErrorLevel, "charge failed", 0, zap.String("order_id", "o-1"))
+	write(zapcore.WarnLevel, "slow query", time.Second)
+	write(zapcore.ErrorLevel, "refund failed", 2*time.Second)
+	time.Sleep(50 * time.Millisecond)
+	if n := len(recorder.Recorded()); n != 0 {
+		t.Errorf("Expected entries to wait for a full batch, got %d delivered", n)
+	}
+
+	write(zapcore.ErrorLevel, "payout failed", 3*time.Second)
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.Recorded()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cards := recorder.Recorded()
+	if len(cards) != 2 {
+		t.Fatalf("Expected one card per level, got %d", len(cards))
+	}
+
+	t.Run("digest of several entries", func(t *testing.T) {
+		errs := cards[0]
+		if larktest.CardLevel(errs) != larklogger.LevelError || !larktest.CardContains(errs, "charge failed (+2 more)") {
+			t.Errorf("Expected an error digest, got %q", larktest.CardText(errs))
+		}
+		want := map[string]string{
+			"#1 12:30:00.000": "charge failed order_id=o-1",
+			"#2 12:30:02.000": "refund failed",
+			"#3 12:30:03.000": "payout failed",
+		}
+		rows := larktest.CardFields(errs)
+		if len(rows) != len(want) {
+			t.Fatalf("Expected %d rows, got %q", len(want), rows)
+		}
+		for k, v := range want {
+			if rows[k] != v {
+				t.Errorf("Expected row %s=%q, got %q", k, v, rows[k])
+			}
+		}
+		if !larktest.CardContains(errs, larklogger.FormatTimestamp(start)) {
+			t.Errorf("Expected the first entry time, got %q", larktest.CardText(errs))
+		}
+	})
+
+	t.Run("lone entry keeps its fields and time", func(t *testing.T) {
+		warn := cards[1]
+		if larktest.CardLevel(warn) != larklogger.LevelWarn || !larktest.CardContains(warn, "slow query") {
+			t.Errorf("Expected the warn entry, got %q", larktest.CardText(warn))
+		}
+		if !larktest.CardContains(warn, larklogger.FormatTimestamp(start.Add(time.Second))) {
+			t.Errorf("Expected the entry time, got %q", larktest.CardText(warn))
+		}
+	})
+}
+
+// exitHook stands in for os.Exit after a Fatal entry is written
+type exitHook func()
+
+func (h exitHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) { h() }
+
+func TestCoreFatal(t *testing.T) {
+	server := larktest.NewServer(t)
+	server.Script(larktest.Slow(50*time.Millisecond), larktest.Slow(50*time.Millisecond))
+	lark := larklogger.NewLarkLogger(context.Background(), larklogger.NewLarkClient(server.WebhookURL()),
+		larklogger.WithAsync(10, 1)).(*larklogger.LarkLogger)
+	core := NewCore(lark, zapcore.WarnLevel, WithBatch(50, time.Hour))
+	defer core.Close(context.Background())
+
+	// zap replaces WriteThenNoop with os.Exit for Fatal, so record the exit instead
+	var exited bool
+	logger := zap.New(core, zap.WithFatalHook(exitHook(func() { exited = true })))
+	logger.Error("before the crash")
+	logger.Fatal("crash")
+
+	if !exited {
+		t.Fatal("Expected the fatal hook to run")
+	}
+	// Both entries are errors, so they share one digest card
+	if cards := server.Cards(); len(cards) != 1 || delivered(cards) != 2 {
+		t.Errorf("Expected the buffered entries delivered before Fatal returns, got %d cards", len(cards))
+	}
+}
+
+func TestCoreTee(t *testing.T) {
+	var buf bytes.Buffer
+	local := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.InfoLevel)
+	logger, recorder := larktest.NewLogger()
+	lark := NewCore(logger, zapcore.ErrorLevel)
+	defer lark.Close(context.Background())
+
+	zl := zap.New(zapcore.NewTee(local, lark))
+	zl.Info("request served")
+	zl.Error("payment failed")
+	_ = zl.Sync()
+
+	if !bytes.Contains(buf.Bytes(), []byte("request served")) || !bytes.Contains(buf.Bytes(), []byte("payment failed")) {
+		t.Errorf("Expected both entries locally, got %s", buf.String())
+	}
+	if cards := recorder.Recorded(); len(cards) != 1 || !larktest.CardContains(cards[0], "payment failed") {
+		t.Errorf("Expected only the error in Lark, got %d cards", len(cards))
+	}
+}
@@ -0,0 +1,136 @@
+// Package larkzerolog forwards zerolog events to Lark.
+//
+// zerolog hooks cannot read event fields, so events are forwarded by a writer that decodes the JSON
+// zerolog produces. Combine it with the usual output using zerolog.MultiLevelWriter.
+package larkzerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"github.com/rs/zerolog"
+)
+
+// fatalFlushTimeout bounds how long a Fatal or Panic event waits for delivery before zerolog exits or panics
+const fatalFlushTimeout = 5 * time.Second
+
+// Writer is a zerolog.LevelWriter sending events at its levels through a LarkLogger.
+// It runs on the logging goroutine, so use an async logger (larklogger.WithAsync) to keep logging fast.
+type Writer struct {
+	logger *larklogger.LarkLogger
+	levels map[zerolog.Level]bool
+}
+
+// NewWriter creates a writer for levels (default Warn and above), e.g.
+// zerolog.New(zerolog.MultiLevelWriter(os.Stderr, larkzerolog.NewWriter(lark)))
+func NewWriter(logger *larklogger.LarkLogger, levels ...zerolog.Level) *Writer {
+	if len(levels) == 0 {
+		levels = []zerolog.Level{zerolog.WarnLevel, zerolog.ErrorLevel, zerolog.FatalLevel, zerolog.PanicLevel}
+	}
+	w := &Writer{logger: logger, levels: make(map[zerolog.Level]bool, len(levels))}
+	for _, level := range levels {
+		w.levels[level] = true
+	}
+	return w
+}
+
+// Level maps a zerolog level onto a LogLevel: Panic, Fatal and Error are errors, Warn warnings, anything lower info
+func Level(level zerolog.Level) larklogger.LogLevel {
+	switch level {
+	case zerolog.PanicLevel, zerolog.FatalLevel, zerolog.ErrorLevel:
+		return larklogger.LevelError
+	case zerolog.WarnLevel:
+		return larklogger.LevelWarn
+	default:
+		return larklogger.LevelInfo
+	}
+}
+
+// Write implements io.Writer, reading the level from the event itself
+func (w *Writer) Write(p []byte) (int, error) {
+	level := zerolog.NoLevel
+	var event map[string]json.RawMessage
+	if err := json.Unmarshal(p, &event); err == nil {
+		var name string
+		if json.Unmarshal(event[zerolog.LevelFieldName], &name) == nil {
+			if parsed, err := zerolog.ParseLevel(name); err == nil {
+				level = parsed
+			}
+		}
+	}
+	return w.WriteLevel(level, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter. Event fields become card fields, nested objects flattened
+// into dotted keys. Fatal and Panic events are flushed before returning so they are delivered before the exit.
+func (w *Writer) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if !w.levels[level] {
+		return len(p), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var event map[string]interface{}
+	if err := dec.Decode(&event); err != nil {
+		return 0, fmt.Errorf("failed to decode zerolog event: %w", err)
+	}
+
+	message, _ := event[zerolog.MessageFieldName].(string)
+	logged := eventTime(event[zerolog.TimestampFieldName])
+	delete(event, zerolog.MessageFieldName)
+	delete(event, zerolog.LevelFieldName)
+	delete(event, zerolog.TimestampFieldName)
+
+	fields := make(map[string]interface{}, len(event))
+	flatten(fields, "", event)
+	w.logger.LogAt(logged, Level(level), message, fields)
+
+	if level == zerolog.FatalLevel || level == zerolog.PanicLevel {
+		ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+		defer cancel()
+		if err := w.logger.Flush(ctx); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// eventTime parses the event timestamp written with zerolog.TimeFieldFormat; zero (delivery time) if absent
+func eventTime(v interface{}) time.Time {
+	switch ts := v.(type) {
+	case string:
+		if t, err := time.Parse(zerolog.TimeFieldFormat, ts); err == nil {
+			return t
+		}
+	case json.Number:
+		n, err := ts.Int64()
+		if err != nil {
+			return time.Time{}
+		}
+		switch zerolog.TimeFieldFormat {
+		case zerolog.TimeFormatUnixMs:
+			return time.UnixMilli(n)
+		case zerolog.TimeFormatUnixMicro:
+			return time.UnixMicro(n)
+		case zerolog.TimeFormatUnixNano:
+			return time.Unix(0, n)
+		default:
+			return time.Unix(n, 0)
+		}
+	}
+	return time.Time{}
+}
+
+func flatten(out map[string]interface{}, prefix string, fields map[string]interface{}) {
+	for k, v := range fields {
+		if nested, ok := v.(map[string]interface{}); ok {
+			flatten(out, prefix+k+".", nested)
+			continue
+		}
+		out[prefix+k] = v
+	}
+}
@@ -0,0 +1,142 @@
+package larkzerolog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KCNyu/lark-logger/src/larklogger"
+	"github.com/KCNyu/lark-logger/src/larktest"
+	"github.com/rs/zerolog"
+)
+
+func TestWriterLevels(t *testing.T) {
+	lark, recorder := larktest.NewLogger()
+	log := zerolog.New(NewWriter(lark)).Level(zerolog.TraceLevel)
+
+	log.Debug().Msg("debug")
+	log.Info().Msg("info")
+	log.Warn().Msg("warn")
+	log.Error().Msg("error")
+	func() {
+		defer func() { _ = recover() }()
+		log.Panic().Msg("panic")
+	}()
+
+	cards := recorder.Recorded()
+	want := []larklogger.LogLevel{larklogger.LevelWarn, larklogger.LevelError, larklogger.LevelError}
+	if len(cards) != len(want) {
+		t.Fatalf("Expected %d cards at Warn and above, got %d", len(want), len(cards))
+	}
+	for i, card := range cards {
+		if got := larktest.CardLevel(card); got != want[i] {
+			t.Errorf("Expected %s for card %d, got %s", want[i], i, got)
+		}
+	}
+
+	t.Run("configured levels", func(t *testing.T) {
+		lark, recorder := larktest.NewLogger()
+		log := zerolog.New(NewWriter(lark, zerolog.InfoLevel))
+		log.Info().Msg("deployed")
+		log.Error().Msg("ignored")
+		if cards := recorder.Recorded(); len(cards) != 1 || larktest.CardLevel(cards[0]) != larklogger.LevelInfo {
+			t.Errorf("Expected only the info entry, got %d cards", len(cards))
+		}
+	})
+
+	t.Run("plain writer reads the level", func(t *testing.T) {
+		lark, recorder := larktest.NewLogger()
+		w := NewWriter(lark)
+		if _, err := w.Write([]byte(`{"level":"error","message":"boom"}`)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := w.Write([]byte(`{"level":"info","message":"skipped"}`)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cards := recorder.Recorded(); len(cards) != 1 || larktest.CardLevel(cards[0]) != larklogger.LevelError {
+			t.Errorf("Expected only the error entry, got %d cards", len(cards))
+		}
+	})
+}
+
+func TestWriterFields(t *testing.T) {
+	lark, recorder := larktest.NewLogger()
+	log := zerolog.New(NewWriter(lark)).With().Timestamp().Str("service", "payments").Logger()
+
+	log.Error().
+		Err(errors.New("upstream timeout")).
+		Int("status", 502).
+		Dict("order", zerolog.Dict().Str("id", "o-1")).
+		Msg("charge failed")
+
+	cards := recorder.Recorded()
+	if len(cards) != 1 {
+		t.Fatalf("Expected 1 card, got %d", len(cards))
+	}
+	if !larktest.CardContains(cards[0], "charge failed") {
+		t.Errorf("Expected message, got %q", larktest.CardText(cards[0]))
+	}
+	fields := larktest.CardFields(cards[0])
+	want := map[string]string{
+		"service":  "payments",
+		"error":    "upstream timeout",
+		"status":   "502",
+		"order.id": "o-1",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("Expected %s=%s, got %q", k, v, fields[k])
+		}
+	}
+	if len(fields) != len(want) {
+		t.Errorf("Expected level, message and time to be dropped, got %q", fields)
+	}
+}
+
+func TestWriterEventTime(t *testing.T) {
+	lark, recorder := larktest.NewLogger()
+	w := NewWriter(lark)
+	if _, err := w.Write([]byte(`{"level":"error","time":"2024-03-01T12:30:00Z","message":"late"}`)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := w.Write([]byte(`{"level":"error","message":"untimed"}`)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	cards := recorder.Recorded()
+	logged := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	if len(cards) != 2 || !larktest.CardContains(cards[0], larklogger.FormatTimestamp(logged)) {
+		t.Fatalf("Expected the event time on the card, got %d cards", len(cards))
+	}
+	if today := time.Now().Format("2006-01-02"); !larktest.CardContains(cards[1], today) {
+		t.Errorf("Expected an event without time to use now, got %q", larktest.CardText(cards[1]))
+	}
+}
+
+func TestWriterFatalFlushes(t *testing.T) {
+	server := larktest.NewServer(t)
+	server.Script(larktest.Slow(50*time.Millisecond), larktest.Slow(50*time.Millisecond))
+	lark := larklogger.NewLarkLogger(context.Background(), larklogger.NewLarkClient(server.WebhookURL()),
+		larklogger.WithAsync(10, 1)).(*larklogger.LarkLogger)
+	defer lark.Close(context.Background())
+	w := NewWriter(lark)
+
+	if _, err := w.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error","message":"queued"}`)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := w.WriteLevel(zerolog.FatalLevel, []byte(`{"level":"fatal","message":"exiting"}`)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sent := len(server.Cards()); sent != 2 {
+		t.Errorf("Expected the queue flushed before exit, got %d sent", sent)
+	}
+}
+
+func TestWriterMalformed(t *testing.T) {
+	lark, _ := larktest.NewLogger()
+	if _, err := NewWriter(lark).WriteLevel(zerolog.ErrorLevel, []byte("not json")); err == nil {
+		t.Error("Expected an error for a malformed event")
+	}
+}